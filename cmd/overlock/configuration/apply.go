@@ -3,6 +3,7 @@ package configuration
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -13,29 +14,45 @@ import (
 )
 
 type applyCmd struct {
-	Link    string `arg:"" required:"" help:"Link URL (or multiple comma separated) to Crossplane configuration to be applied to Environment."`
-	Wait    bool   `optional:"" short:"w" help:"Wait until configuration is installed."`
-	Timeout string `optional:"" short:"t" help:"Timeout is used to set how much to wait until configuration is installed (valid time units are ns, us, ms, s, m, h)"`
+	Link     string `arg:"" required:"" help:"Link URL (or multiple comma separated) to Crossplane configuration to be applied to Environment."`
+	Wait     bool   `optional:"" short:"w" help:"Wait until configuration is installed."`
+	Timeout  string `optional:"" short:"t" help:"Timeout is used to set how much to wait until configuration is installed (valid time units are ns, us, ms, s, m, h)"`
+	Locked   bool   `help:"Install exact package digests from lock file and disable dependency resolution."`
+	LockFile string `default:"overlock.lock" help:"Path to lock file used with --locked."`
 }
 
 func (c *applyCmd) Run(ctx context.Context, dc *dynamic.DynamicClient, config *rest.Config, logger *zap.SugaredLogger) error {
-	cfg := configuration.New(c.Link)
-	if err := cfg.Apply(ctx, config, logger); err != nil {
-		return fmt.Errorf("failed to apply configuration: %w", err)
+	links := c.Link
+	if c.Locked {
+		lock, err := configuration.ReadLock(c.LockFile)
+		if err != nil {
+			return err
+		}
+		if strings.Join(lock.Configurations, ",") != c.Link {
+			return fmt.Errorf("lock file %s was created for %s, run `overlock configuration lock %s` first", c.LockFile, strings.Join(lock.Configurations, ","), c.Link)
+		}
+		if err := configuration.ApplyLocked(ctx, lock, config, logger); err != nil {
+			return fmt.Errorf("failed to apply locked configuration: %w", err)
+		}
+		links = strings.Join(lock.Sources("Configuration"), ",")
+	} else {
+		cfg := configuration.New(c.Link)
+		if err := cfg.Apply(ctx, config, logger); err != nil {
+			return fmt.Errorf("failed to apply configuration: %w", err)
+		}
 	}
 	if !c.Wait {
 		return nil
 	}
 
-	var timeoutChan <-chan time.Time
+	var timeout time.Duration
 	if c.Timeout != "" {
-		timeout, err := time.ParseDuration(c.Timeout)
-		if err != nil {
+		var err error
+		if timeout, err = time.ParseDuration(c.Timeout); err != nil {
 			return fmt.Errorf("failed to parse timeout duration: %w", err)
 		}
-		timeoutChan = time.After(timeout)
 	}
-	if err := configuration.HealthCheck(ctx, dc, c.Link, c.Wait, timeoutChan, logger); err != nil {
+	if err := configuration.HealthCheck(ctx, dc, config, links, timeout, logger); err != nil {
 		return fmt.Errorf("configuration health check failed: %w", err)
 	}
 	return nil
@@ -1,9 +1,17 @@
 package configuration
 
+import "github.com/web-seven/overlock/cmd/overlock/pkgcmd"
+
 type Cmd struct {
-	Apply  applyCmd  `cmd:"" help:"Apply Crossplane Configuration."`
-	List   listCmd   `cmd:"" help:"Apply Crossplane Configuration."`
-	Load   loadCmd   `cmd:"" help:"Load Crossplane Configuration from archive."`
-	Serve  serveCmd  `cmd:"" help:"Serve Crossplane Configuration from filesystem."`
-	Delete deleteCmd `cmd:"" help:"Delete Crossplane Configuration."`
+	Apply    applyCmd           `cmd:"" help:"Apply Crossplane Configuration."`
+	List     listCmd            `cmd:"" help:"Apply Crossplane Configuration."`
+	Outdated pkgcmd.OutdatedCmd `cmd:"" set:"kind=Configuration" help:"List available versions of installed Crossplane Configurations."`
+	Upgrade  pkgcmd.UpgradeCmd  `cmd:"" set:"kind=Configuration" help:"Upgrade Crossplane Configuration to newer version."`
+	History  pkgcmd.HistoryCmd  `cmd:"" set:"kind=Configuration" help:"List revisions of Crossplane Configuration."`
+	Rollback pkgcmd.RollbackCmd `cmd:"" set:"kind=Configuration" help:"Roll back Crossplane Configuration to previous revision."`
+	Load     loadCmd            `cmd:"" help:"Load Crossplane Configuration from archive."`
+	Serve    serveCmd           `cmd:"" help:"Serve Crossplane Configuration from filesystem."`
+	Lint     lintCmd            `cmd:"" help:"Validate Crossplane Configuration package directory."`
+	Lock     lockCmd            `cmd:"" help:"Resolve dependencies of Crossplane Configuration into lock file."`
+	Delete   deleteCmd          `cmd:"" help:"Delete Crossplane Configuration."`
 }
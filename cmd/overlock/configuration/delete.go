@@ -6,6 +6,7 @@ import (
 
 	"go.uber.org/zap"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
 
 	"github.com/web-seven/overlock/pkg/configuration"
 )
@@ -14,8 +15,8 @@ type deleteCmd struct {
 	ConfigurationURL string `arg:"" required:"" help:"Specifies the URL (or multimple comma separated) of configuration to be deleted from Environment."`
 }
 
-func (c *deleteCmd) Run(ctx context.Context, dynamic *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
-	if err := configuration.DeleteConfiguration(ctx, c.ConfigurationURL, dynamic, logger); err != nil {
+func (c *deleteCmd) Run(ctx context.Context, config *rest.Config, dynamic *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
+	if err := configuration.DeleteConfiguration(ctx, config, c.ConfigurationURL, dynamic, logger); err != nil {
 		return fmt.Errorf("failed to delete configuration: %w", err)
 	}
 	return nil
@@ -0,0 +1,21 @@
+package configuration
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/web-seven/overlock/internal/lint"
+)
+
+type lintCmd struct {
+	Path string `default:"./" arg:"" help:"Path to package directory"`
+}
+
+func (c *lintCmd) Run(ctx context.Context, logger *zap.SugaredLogger) error {
+	if err := lint.Check(c.Path, lint.KindConfiguration, logger); err != nil {
+		return err
+	}
+	logger.Infof("Configuration package %s is valid.", c.Path)
+	return nil
+}
@@ -14,15 +14,17 @@ import (
 )
 
 type loadCmd struct {
-	Name    string `arg:"" help:"Name of configuration."`
-	Path    string `help:"Path to configuration package archive."`
-	Stdin   bool   `help:"Load configuration package from STDIN."`
-	Apply   bool   `help:"Apply configuration after load."`
-	Upgrade bool   `help:"Upgrade existing configuration."`
+	Name     string `arg:"" help:"Name of configuration."`
+	Path     string `help:"Path to configuration package archive."`
+	Stdin    bool   `help:"Load configuration package from STDIN."`
+	Apply    bool   `help:"Apply configuration after load."`
+	Upgrade  bool   `help:"Upgrade existing configuration."`
+	SkipLint bool   `help:"Do not lint package directory before load."`
 }
 
 func (c *loadCmd) Run(ctx context.Context, config *rest.Config, dc *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
 	cfg := configuration.New(c.Name)
+	cfg.SkipLint = c.SkipLint
 	if c.Upgrade {
 		if err := cfg.UpgradeConfiguration(ctx, config, dc); err != nil {
 			return fmt.Errorf("failed to upgrade configuration: %w", err)
@@ -0,0 +1,31 @@
+package configuration
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/rest"
+
+	"github.com/web-seven/overlock/pkg/configuration"
+)
+
+type lockCmd struct {
+	Link string `arg:"" required:"" help:"Link URL (or multiple comma separated) to Crossplane configuration to be locked."`
+	File string `default:"overlock.lock" help:"Path to lock file."`
+}
+
+func (c *lockCmd) Run(ctx context.Context, config *rest.Config, logger *zap.SugaredLogger) error {
+	lock, err := configuration.ResolveLock(ctx, strings.Split(c.Link, ","), config, logger)
+	if err != nil {
+		return err
+	}
+	for _, pkg := range lock.Packages {
+		logger.Infof("Locked %s %s:%s (%s)", pkg.Kind, pkg.Package, pkg.Version, pkg.Digest)
+	}
+	if err := lock.Write(c.File); err != nil {
+		return err
+	}
+	logger.Infof("Lock file %s written.", c.File)
+	return nil
+}
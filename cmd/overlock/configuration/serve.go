@@ -2,18 +2,29 @@ package configuration
 
 import (
 	"context"
+	"time"
 
 	"go.uber.org/zap"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 
+	"github.com/web-seven/overlock/internal/watcher"
 	"github.com/web-seven/overlock/pkg/configuration"
 )
 
 type serveCmd struct {
-	Path string `default:"./" arg:"" help:"Path to package directory"`
+	Path     string        `default:"./" arg:"" help:"Path to package directory"`
+	Debounce time.Duration `default:"500ms" help:"Quiet period after the last change before package is rebuilt."`
+	Ignore   []string      `help:"Additional patterns of files and directories ignored by watcher (e.g. testdata, *.tmp)."`
+	SkipLint bool          `help:"Do not lint package directory before build."`
+}
+
+// LongRunning reports that command runs until interrupted
+func (c *serveCmd) LongRunning() bool {
+	return true
 }
 
 func (c *serveCmd) Run(ctx context.Context, dc *dynamic.DynamicClient, config *rest.Config, logger *zap.SugaredLogger) error {
-	return configuration.Serve(ctx, dc, config, logger, c.Path)
+	cfg := &configuration.Configuration{SkipLint: c.SkipLint}
+	return configuration.Serve(ctx, dc, config, logger, c.Path, cfg, watcher.Options{Debounce: c.Debounce, Ignore: c.Ignore})
 }
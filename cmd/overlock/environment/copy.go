@@ -9,13 +9,14 @@ import (
 )
 
 type copyCmd struct {
-	Source       string `arg:"" required:"" help:"Name source of environment."`
-	Destination  string `arg:"" required:"" help:"Name destination of environment."`
-	SourceEngine string `arg:"" required:"" help:"Specifies the Kubernetes engine to use for the runtime environment." default:"kind"`
+	Source         string `arg:"" required:"" help:"Name source of environment."`
+	Destination    string `arg:"" required:"" help:"Name destination of environment."`
+	SourceEngine   string `arg:"" required:"" help:"Specifies the Kubernetes engine to use for the runtime environment." default:"kind"`
+	ClaimNamespace string `help:"Namespace of copied claims, all namespaces if empty."`
 }
 
 func (c *copyCmd) Run(ctx context.Context, logger *zap.SugaredLogger) error {
 	return environment.
 		New(c.Source, c.Source).
-		CopyEnvironment(ctx, logger, c.Source, c.Destination)
+		CopyEnvironment(ctx, logger, c.Source, c.Destination, c.ClaimNamespace)
 }
@@ -12,26 +12,26 @@ import (
 )
 
 type applyCmd struct {
-	Link    string `arg:"" required:"" help:"Link URL (or multiple comma separated) to Crossplane function to be applied to Environment."`
-	Wait    bool   `optional:"" short:"w" help:"Wait until function is installed."`
-	Timeout string `optional:"" short:"t" help:"Timeout is used to set how much to wait until function is installed (valid time units are ns, us, ms, s, m, h)"`
+	Link          string `arg:"" required:"" help:"Link URL (or multiple comma separated) to Crossplane function to be applied to Environment."`
+	Wait          bool   `optional:"" short:"w" help:"Wait until function is installed."`
+	Timeout       string `optional:"" short:"t" help:"Timeout is used to set how much to wait until function is installed (valid time units are ns, us, ms, s, m, h)"`
+	RuntimeConfig string `help:"Name of DeploymentRuntimeConfig referenced by function."`
 }
 
 func (c *applyCmd) Run(ctx context.Context, dc *dynamic.DynamicClient, config *rest.Config, logger *zap.SugaredLogger) error {
-	if err := function.ApplyFunction(ctx, c.Link, config, logger); err != nil {
+	if err := function.New(c.Link).WithRuntimeConfig(c.RuntimeConfig).Apply(ctx, config, logger); err != nil {
 		return err
 	}
 	if !c.Wait {
 		return nil
 	}
 
-	var timeoutChan <-chan time.Time
+	var timeout time.Duration
 	if c.Timeout != "" {
-		timeout, err := time.ParseDuration(c.Timeout)
-		if err != nil {
+		var err error
+		if timeout, err = time.ParseDuration(c.Timeout); err != nil {
 			return err
 		}
-		timeoutChan = time.After(timeout)
 	}
-	return function.HealthCheck(ctx, dc, c.Link, c.Wait, timeoutChan, logger)
+	return function.HealthCheck(ctx, dc, config, c.Link, timeout, logger)
 }
@@ -5,14 +5,17 @@ import (
 
 	"go.uber.org/zap"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
 
 	"github.com/web-seven/overlock/internal/function"
 )
 
 type deleteCmd struct {
-	FunctionURL string `arg:"" required:"" help:"Specifies the URL (or multimple comma separated) of function to be deleted from Environment."`
+	FunctionURL        string `arg:"" required:"" help:"Specifies the URL (or multimple comma separated) of function to be deleted from Environment."`
+	PruneRuntimeConfig bool   `help:"Delete runtime config of function if no other package uses it."`
 }
 
-func (c *deleteCmd) Run(ctx context.Context, dynamic *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
-	return function.DeleteFunction(ctx, c.FunctionURL, dynamic, logger)
+func (c *deleteCmd) Run(ctx context.Context, config *rest.Config, dynamic *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
+	options := function.DeleteOptions{PruneRuntimeConfig: c.PruneRuntimeConfig}
+	return function.DeleteFunction(ctx, config, c.FunctionURL, dynamic, options, logger)
 }
@@ -5,5 +5,7 @@ type Cmd struct {
 	List   listCmd   `cmd:"" help:"Apply Crossplane Function."`
 	Load   loadCmd   `cmd:"" help:"Load Crossplane Function from archive."`
 	Serve  serveCmd  `cmd:"" help:"Watch changes of Function, build and load."`
+	Run    runCmd    `cmd:"" help:"Build and run Function locally as gRPC server in insecure mode."`
+	Lint   lintCmd   `cmd:"" help:"Validate Crossplane Function package directory."`
 	Delete deleteCmd `cmd:"" help:"Delete Crossplane Function."`
 }
@@ -3,6 +3,7 @@ package function
 import (
 	"bufio"
 	"context"
+	"fmt"
 	"os"
 
 	"go.uber.org/zap"
@@ -16,14 +17,21 @@ import (
 )
 
 type loadCmd struct {
-	Name    string `arg:"" help:"Name of function."`
-	Path    string `help:"Path to function package archive."`
-	Stdin   bool   `help:"Load function package from STDIN."`
-	Apply   bool   `help:"Apply function after load."`
-	Upgrade bool   `help:"Upgrade existing function."`
+	Name     string `arg:"" help:"Name of function."`
+	Path     string `help:"Path to function package archive or directory."`
+	Stdin    bool   `help:"Load function package from STDIN."`
+	Apply    bool   `help:"Apply function after load."`
+	Upgrade  bool   `help:"Upgrade existing function."`
+	SignKey  string `type:"existingfile" help:"Path to cosign compatible private key to sign loaded image with."`
+	Builder  string `help:"Command building runtime image of directory, it must write OCI tarball to $OVERLOCK_OUTPUT."`
+	Image    string `help:"Existing runtime image combined with package of directory."`
+	SkipLint bool   `help:"Do not lint package directory before load."`
 }
 
 func (c *loadCmd) Run(ctx context.Context, config *rest.Config, dc *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
 	client, err := kube.Client(config)
 	if err != nil {
 		return err
@@ -58,11 +66,27 @@ func (c *loadCmd) Run(ctx context.Context, config *rest.Config, dc *dynamic.Dyna
 	}
 
 	logger.Debugf("Loading image to: %s", fnc.Name)
+	pushed := false
 	if c.Path != "" {
-		logger.Debugf("Loading from path: %s", c.Path)
-		err = fnc.Image.LoadPathArchive(c.Path)
+		fi, err := os.Stat(c.Path)
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to stat path %s: %w", c.Path, err)
+		}
+		if fi.IsDir() {
+			logger.Debugf("Loading from directory: %s", c.Path)
+			fnc.Builder = c.Builder
+			fnc.RuntimeImage = c.Image
+			fnc.SkipLint = c.SkipLint
+			if err := fnc.LoadDirectory(ctx, config, logger, c.Path); err != nil {
+				return fmt.Errorf("failed to load directory %s: %w", c.Path, err)
+			}
+			pushed = true
+		} else {
+			logger.Debugf("Loading from path: %s", c.Path)
+			err = fnc.Image.LoadPathArchive(c.Path)
+			if err != nil {
+				return err
+			}
 		}
 	} else if c.Stdin {
 		logger.Debug("Loading from STDIN")
@@ -76,15 +100,46 @@ func (c *loadCmd) Run(ctx context.Context, config *rest.Config, dc *dynamic.Dyna
 		return nil
 	}
 
-	logger.Debug("Pushing to local registry")
-	err = registry.PushLocalRegistry(ctx, fnc.Name, fnc.Image, config, logger)
-	if err != nil {
-		return err
+	if !pushed {
+		logger.Debug("Pushing to local registry")
+		err = registry.PushLocalRegistry(ctx, fnc.Name, fnc.Image, config, logger)
+		if err != nil {
+			return err
+		}
+		logger.Infof("Image archive %s loaded to local registry.", fnc.Name)
+	}
+	if c.SignKey != "" {
+		if err := registry.SignLocalImage(ctx, fnc.Name, fnc.Image, c.SignKey, config, logger); err != nil {
+			return err
+		}
+		logger.Infof("Image %s signed.", fnc.Name)
 	}
-	logger.Infof("Image archive %s loaded to local registry.", fnc.Name)
 
 	if c.Apply {
-		return function.ApplyFunction(ctx, fnc.Name, config, logger)
+		return fnc.Apply(ctx, config, logger)
+	}
+	return nil
+}
+
+// Validate flags before anything is built or pushed, only directories are
+// built as multi-arch images, which cannot be signed
+func (c *loadCmd) validate() error {
+	if c.SignKey == "" || c.Path == "" {
+		return nil
+	}
+	fi, err := os.Stat(c.Path)
+	if err != nil {
+		return fmt.Errorf("failed to stat path %s: %w", c.Path, err)
+	}
+	if !fi.IsDir() {
+		return nil
+	}
+	multiArch, err := function.New(c.Name).MultiArch(c.Path)
+	if err != nil {
+		return err
+	}
+	if multiArch {
+		return fmt.Errorf("signing of multi-arch image %s is not supported", c.Name)
 	}
 	return nil
 }
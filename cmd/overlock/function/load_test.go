@@ -0,0 +1,35 @@
+package function
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/web-seven/overlock/internal/build"
+)
+
+func TestLoadValidate(t *testing.T) {
+	dir := t.TempDir()
+	key := filepath.Join(dir, "cosign.key")
+	if err := os.WriteFile(key, []byte("key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &loadCmd{Name: "function-nop", Path: dir, SignKey: key}
+	if err := cmd.validate(); err != nil {
+		t.Fatalf("unexpected error for single arch directory: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, build.ConfigFileName), []byte("multiArch: true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.validate(); err == nil || !strings.Contains(err.Error(), "multi-arch") {
+		t.Errorf("expected multi-arch signing error, got %v", err)
+	}
+
+	cmd.SignKey = ""
+	if err := cmd.validate(); err != nil {
+		t.Errorf("unexpected error without sign key: %v", err)
+	}
+}
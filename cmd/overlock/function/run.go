@@ -0,0 +1,30 @@
+package function
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/web-seven/overlock/internal/function"
+	"github.com/web-seven/overlock/internal/watcher"
+)
+
+type runCmd struct {
+	Path     string        `default:"./" arg:"" help:"Path to function directory"`
+	Address  string        `default:"localhost:9443" help:"Address of function gRPC server."`
+	Arg      []string      `help:"Argument of function process (repeatable)."`
+	Delve    string        `help:"Run function with delve headless server listening on address, e.g. 127.0.0.1:2345."`
+	Debounce time.Duration `default:"500ms" help:"Quiet period after the last change before function is restarted."`
+	Ignore   []string      `help:"Additional patterns of files and directories ignored by watcher (e.g. testdata, *.tmp)."`
+}
+
+// LongRunning reports that command runs until interrupted
+func (c *runCmd) LongRunning() bool {
+	return true
+}
+
+func (c *runCmd) Run(ctx context.Context, logger *zap.SugaredLogger) error {
+	options := function.RunOptions{Address: c.Address, Args: c.Arg, Delve: c.Delve}
+	return function.Run(ctx, logger, c.Path, options, watcher.Options{Debounce: c.Debounce, Ignore: c.Ignore})
+}
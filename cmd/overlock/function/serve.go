@@ -2,6 +2,7 @@ package function
 
 import (
 	"context"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -9,12 +10,24 @@ import (
 	"k8s.io/client-go/rest"
 
 	"github.com/web-seven/overlock/internal/function"
+	"github.com/web-seven/overlock/internal/watcher"
 )
 
 type serveCmd struct {
-	Path string `default:"./" arg:"" help:"Path to package directory"`
+	Path     string        `default:"./" arg:"" help:"Path to package directory"`
+	Debounce time.Duration `default:"500ms" help:"Quiet period after the last change before package is rebuilt."`
+	Ignore   []string      `help:"Additional patterns of files and directories ignored by watcher (e.g. testdata, *.tmp)."`
+	SkipLint bool          `help:"Do not lint package directory before build."`
+	Builder  string        `help:"Command building runtime image of directory, it must write OCI tarball to $OVERLOCK_OUTPUT."`
+	Image    string        `help:"Existing runtime image combined with package of directory."`
+}
+
+// LongRunning reports that command runs until interrupted
+func (c *serveCmd) LongRunning() bool {
+	return true
 }
 
 func (c *serveCmd) Run(ctx context.Context, dc *dynamic.DynamicClient, config *rest.Config, logger *zap.SugaredLogger) error {
-	return function.Serve(ctx, dc, config, logger, c.Path)
+	fnc := &function.Function{Builder: c.Builder, RuntimeImage: c.Image, SkipLint: c.SkipLint}
+	return function.Serve(ctx, dc, config, logger, c.Path, fnc, watcher.Options{Debounce: c.Debounce, Ignore: c.Ignore})
 }
@@ -2,16 +2,44 @@ package generate
 
 import (
 	"context"
+	"os"
 
-	"go.uber.org/zap"
+	crossv1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
 
 	"github.com/web-seven/overlock/internal/generate"
+	"github.com/web-seven/overlock/internal/kube"
 )
 
 type exampleCmd struct {
-	Path string `help:"Path to CRD YAML file archive."`
+	XRD            string `arg:"" required:"" help:"Path to XRD YAML file or name of XRD installed in environment."`
+	XRDVersion     string `name:"xrd-version" help:"Version of XRD, referenceable version by default."`
+	Name           string `default:"example" help:"Name of example resources."`
+	ClaimNamespace string `default:"default" help:"Namespace of example claim."`
 }
 
-func (c *exampleCmd) Run(ctx context.Context, logger *zap.SugaredLogger) error {
-	return generate.GenerateCompositeResource(ctx, c.Path, logger)
+func (c *exampleCmd) Run(ctx context.Context) error {
+	var xrd *crossv1.CompositeResourceDefinition
+	if _, err := os.Stat(c.XRD); err == nil {
+		if xrd, err = generate.LoadXRD(c.XRD); err != nil {
+			return err
+		}
+	} else {
+		// Environment is needed only for installed XRD
+		config, err := kube.Config("")
+		if err != nil {
+			return err
+		}
+		dc, err := kube.ConfigContext(ctx, config)
+		if err != nil {
+			return err
+		}
+		if xrd, err = generate.GetXRD(ctx, dc, c.XRD); err != nil {
+			return err
+		}
+	}
+	return generate.WriteExamples(os.Stdout, xrd, generate.Options{
+		Version:   c.XRDVersion,
+		Name:      c.Name,
+		Namespace: c.ClaimNamespace,
+	})
 }
@@ -1,5 +1,5 @@
 package generate
 
 type Cmd struct {
-	Example exampleCmd `cmd:"" help:"Generate an example YAML schema by XRD YAML file"`
+	Example exampleCmd `cmd:"" help:"Generate example composite resource and claim of XRD"`
 }
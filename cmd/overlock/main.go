@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/charmbracelet/lipgloss"
@@ -17,7 +18,11 @@ import (
 	"github.com/web-seven/overlock/cmd/overlock/configuration"
 	"github.com/web-seven/overlock/cmd/overlock/environment"
 	"github.com/web-seven/overlock/cmd/overlock/function"
+	"github.com/web-seven/overlock/cmd/overlock/generate"
 	"github.com/web-seven/overlock/cmd/overlock/provider"
+	"github.com/web-seven/overlock/cmd/overlock/render"
+	"github.com/web-seven/overlock/cmd/overlock/runtimeconfig"
+	"github.com/web-seven/overlock/cmd/overlock/test"
 	"github.com/web-seven/overlock/cmd/overlock/version"
 	"github.com/web-seven/overlock/internal/engine"
 	"github.com/web-seven/overlock/internal/kube"
@@ -33,6 +38,15 @@ import (
 	"github.com/web-seven/overlock/cmd/overlock/resource"
 )
 
+// Time given to long running commands to stop after interrupt before forced exit
+const shutdownGracePeriod = 10 * time.Second
+
+// Commands running until interrupted, which are given shutdown grace period
+// to clean up after interrupt
+type longRunning interface {
+	LongRunning() bool
+}
+
 type Globals struct {
 	Debug         bool        `short:"D" help:"Enable debug mode"`
 	Version       VersionFlag `name:"version" help:"Print version information and quit"`
@@ -187,8 +201,11 @@ type cli struct {
 	InstallCompletions kongplete.InstallCompletions `cmd:"" help:"Install shell completions"`
 	Provider           provider.Cmd                 `cmd:"" name:"provider" aliases:"prv" help:"Overlock Provider commands"`
 	Function           function.Cmd                 `cmd:"" name:"function" aliases:"fnc" help:"Overlock Function commands"`
-	// Search             registry.SearchCmd           `cmd:"" help:"Search for packages"`
-	// Generate           generate.Cmd                 `cmd:"" help:"Generate example by XRD YAML file"`
+	RuntimeConfig      runtimeconfig.Cmd            `cmd:"" name:"runtimeconfig" aliases:"rc" help:"Package runtime config commands"`
+	Search             registry.SearchCmd           `cmd:"" help:"Search for packages"`
+	Render             render.Cmd                   `cmd:"" help:"Render composite resource with composition functions locally"`
+	Test               test.Cmd                     `cmd:"" help:"Run composite resource test cases against environment"`
+	Generate           generate.Cmd                 `cmd:"" help:"Generate examples from XRDs"`
 }
 
 type helpCmd struct{}
@@ -234,12 +251,30 @@ func main() {
 	parser.FatalIfErrorf(err)
 
 	ctx, cancel := context.WithCancel(context.Background())
+	gracePeriod := time.Duration(0)
+	if cmd, ok := kongCtx.Selected().Target.Addr().Interface().(longRunning); ok && cmd.LongRunning() {
+		gracePeriod = shutdownGracePeriod
+	}
+	done := make(chan struct{})
+	defer close(done)
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt)
 	defer signal.Stop(sigCh)
 	go func() {
-		defer cancel()
-		<-sigCh
+		select {
+		case <-sigCh:
+		case <-done:
+			return
+		}
+		// Let commands watching context clean up, second interrupt or
+		// grace period expiration terminates immediately.
+		cancel()
+		select {
+		case <-sigCh:
+		case <-time.After(gracePeriod):
+		case <-done:
+			return
+		}
 		kongCtx.Exit(1)
 	}()
 
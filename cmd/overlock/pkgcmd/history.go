@@ -0,0 +1,48 @@
+package pkgcmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/pterm/pterm"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/web-seven/overlock/internal/packages"
+)
+
+// HistoryCmd lists revisions of package
+type HistoryCmd struct {
+	Name string `arg:"" required:"" help:"Name of Crossplane ${kind}."`
+}
+
+func (c *HistoryCmd) Run(ctx context.Context, kctx *kong.Context, dynamicClient *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
+	k, err := kindOf(kctx)
+	if err != nil {
+		return err
+	}
+	history, err := packages.History(ctx, dynamicClient, k.revisions, c.Name)
+	if err != nil {
+		return err
+	}
+	if len(history) == 0 {
+		return fmt.Errorf("no revisions found for %s %s", strings.ToLower(kctx.Selected().Vars()["kind"]), c.Name)
+	}
+	table := pterm.TableData{[]string{"REVISION", "IMAGE", "STATE", "HEALTHY", "AGE"}}
+	for _, rev := range history {
+		healthy := strconv.FormatBool(rev.Healthy)
+		if rev.Message != "" {
+			healthy += " (" + rev.Message + ")"
+		}
+		table = append(table, []string{strconv.FormatInt(rev.Number, 10), rev.Image, rev.State, healthy, duration.HumanDuration(time.Since(rev.Created))})
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(table).Render(); err != nil {
+		return fmt.Errorf("failed to render table: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package pkgcmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alecthomas/kong"
+	"github.com/pterm/pterm"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+
+	"github.com/web-seven/overlock/internal/packages"
+	"github.com/web-seven/overlock/pkg/registry"
+)
+
+// OutdatedCmd lists available versions of installed packages
+type OutdatedCmd struct {
+}
+
+func (c *OutdatedCmd) Run(ctx context.Context, kctx *kong.Context, config *rest.Config, dynamicClient *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
+	k, err := kindOf(kctx)
+	if err != nil {
+		return err
+	}
+	list, err := dynamicClient.Resource(k.packages).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	table := pterm.TableData{[]string{"NAME", "PACKAGE", "CURRENT", "LATEST PATCH", "LATEST"}}
+	outdated := 0
+	for _, pkg := range list.Items {
+		source, _, _ := unstructured.NestedString(pkg.Object, "spec", "package")
+		versions := packages.Versions{Current: packages.SourceVersion(source)}
+		tags, err := registry.PackageTags(ctx, source, config, logger)
+		if err != nil {
+			logger.Warnf("Cannot list versions of %s: %v", source, err)
+		} else {
+			versions = packages.LatestVersions(versions.Current, tags)
+		}
+		if versions.Outdated() {
+			outdated++
+		}
+		table = append(table, []string{pkg.GetName(), source, versions.Current, versions.LatestPatch, versions.Latest})
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(table).Render(); err != nil {
+		return fmt.Errorf("failed to render table: %w", err)
+	}
+	if outdated > 0 {
+		logger.Infof("%d of %d package(s) have newer versions.", outdated, len(list.Items))
+	} else if len(list.Items) > 0 {
+		logger.Info("All packages are up to date.")
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+// Package pkgcmd provides commands shared by kinds of Crossplane packages,
+// kind of package is set by `set:"kind=..."` tag of command.
+package pkgcmd
+
+import (
+	"fmt"
+
+	"github.com/alecthomas/kong"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/web-seven/overlock/internal/packages"
+)
+
+type kind struct {
+	packages  schema.GroupVersionResource
+	revisions schema.GroupVersionResource
+	// Key of packages in engine release values
+	release string
+}
+
+var kinds = map[string]kind{
+	"Provider":      {packages: packages.Providers, revisions: packages.ProviderRevisions, release: "provider"},
+	"Configuration": {packages: packages.Configurations, revisions: packages.ConfigurationRevisions, release: "configuration"},
+	"Function":      {packages: packages.Functions, revisions: packages.FunctionRevisions, release: "function"},
+}
+
+// Kind of package set for selected command
+func kindOf(kctx *kong.Context) (kind, error) {
+	name := kctx.Selected().Vars()["kind"]
+	k, ok := kinds[name]
+	if !ok {
+		return kind{}, fmt.Errorf("unknown package kind %q", name)
+	}
+	return k, nil
+}
@@ -0,0 +1,42 @@
+package pkgcmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"go.uber.org/zap"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+
+	"github.com/web-seven/overlock/internal/engine"
+	"github.com/web-seven/overlock/internal/kube"
+	"github.com/web-seven/overlock/internal/packages"
+	"github.com/web-seven/overlock/pkg/registry"
+)
+
+// RollbackCmd rolls package back to digest of previous revision
+type RollbackCmd struct {
+	Name     string        `arg:"" required:"" help:"Name of Crossplane ${kind} to roll back."`
+	Revision int64         `help:"Revision number to roll back to, the revision preceding the active one by default."`
+	Timeout  time.Duration `default:"5m" help:"Time to wait for revision to become healthy."`
+}
+
+func (c *RollbackCmd) Run(ctx context.Context, kctx *kong.Context, config *rest.Config, dynamicClient *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
+	k, err := kindOf(kctx)
+	if err != nil {
+		return err
+	}
+	client, err := kube.Client(config)
+	if err != nil {
+		return err
+	}
+	digest := func(ctx context.Context, source string) (string, error) {
+		hash, err := registry.PackageDigest(ctx, source, config, logger)
+		return hash.String(), err
+	}
+	release := func(ctx context.Context, source string, target string) (bool, error) {
+		return engine.ReplaceReleasePackage(ctx, config, k.release, source, target, logger)
+	}
+	return packages.Rollback(ctx, dynamicClient, client, k.packages, k.revisions, c.Name, c.Revision, digest, release, c.Timeout, logger)
+}
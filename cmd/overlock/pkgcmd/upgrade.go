@@ -0,0 +1,41 @@
+package pkgcmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"go.uber.org/zap"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+
+	"github.com/web-seven/overlock/internal/engine"
+	"github.com/web-seven/overlock/internal/kube"
+	"github.com/web-seven/overlock/internal/packages"
+	"github.com/web-seven/overlock/pkg/registry"
+)
+
+// UpgradeCmd upgrades installed package to newer version
+type UpgradeCmd struct {
+	Name    string        `arg:"" required:"" help:"Name of Crossplane ${kind} to upgrade."`
+	To      string        `default:"latest" help:"Target version, 'latest' or exact version of package."`
+	Timeout time.Duration `default:"5m" help:"Time to wait for new revision to become healthy."`
+}
+
+func (c *UpgradeCmd) Run(ctx context.Context, kctx *kong.Context, config *rest.Config, dynamicClient *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
+	k, err := kindOf(kctx)
+	if err != nil {
+		return err
+	}
+	client, err := kube.Client(config)
+	if err != nil {
+		return err
+	}
+	tags := func(ctx context.Context, source string) ([]string, error) {
+		return registry.PackageTags(ctx, source, config, logger)
+	}
+	release := func(ctx context.Context, source string, target string) (bool, error) {
+		return engine.ReplaceReleasePackage(ctx, config, k.release, source, target, logger)
+	}
+	return packages.Upgrade(ctx, dynamicClient, client, k.packages, k.revisions, c.Name, c.To, tags, release, c.Timeout, logger)
+}
@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"time"
 
 	"go.uber.org/zap"
 	"k8s.io/client-go/dynamic"
@@ -11,10 +12,27 @@ import (
 )
 
 type applyCmd struct {
-	Name string   `arg:"" help:"Name of provider."`
-	Link []string `arg:"" required:"" help:"Link URL (or multiple comma separated) to Crossplane provider to be applied to Environment."`
+	Name          string   `arg:"" help:"Name of provider."`
+	Link          []string `arg:"" required:"" help:"Link URL (or multiple comma separated) to Crossplane provider to be applied to Environment."`
+	Wait          bool     `optional:"" short:"w" help:"Wait until provider is installed and healthy."`
+	Timeout       string   `optional:"" short:"t" help:"Timeout is used to set how much to wait until provider is installed (valid time units are ns, us, ms, s, m, h)"`
+	RuntimeConfig string   `help:"Name of DeploymentRuntimeConfig referenced by provider."`
 }
 
 func (c *applyCmd) Run(ctx context.Context, dc *dynamic.DynamicClient, config *rest.Config, logger *zap.SugaredLogger) error {
-	return provider.New(c.Name).ApplyProvider(ctx, c.Link, config, logger)
+	if err := provider.New(c.Name).WithRuntimeConfig(c.RuntimeConfig).ApplyProvider(ctx, c.Link, config, logger); err != nil {
+		return err
+	}
+	if !c.Wait {
+		return nil
+	}
+
+	var timeout time.Duration
+	if c.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(c.Timeout); err != nil {
+			return err
+		}
+	}
+	return provider.HealthCheck(ctx, dc, config, c.Link, timeout, logger)
 }
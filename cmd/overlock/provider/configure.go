@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/web-seven/overlock/internal/provider"
+)
+
+type configureCmd struct {
+	Provider         string   `arg:"" required:"" help:"Name or package URL of installed provider."`
+	Name             string   `default:"default" help:"Name of ProviderConfig."`
+	Group            string   `help:"API group of ProviderConfig, if provider installed several."`
+	Source           string   `help:"Credentials source, e.g. Secret or InjectedIdentity. Secret by default."`
+	SecretName       string   `help:"Name of Secret with credentials, <provider>-<name> by default."`
+	SecretNamespace  string   `help:"Namespace of Secret with credentials, engine namespace by default."`
+	SecretKey        string   `default:"credentials" help:"Key of credentials in Secret."`
+	CredentialsFile  string   `help:"Read credentials from file." type:"existingfile"`
+	CredentialsEnv   string   `help:"Read credentials from environment variable."`
+	CredentialsStdin bool     `help:"Read credentials from stdin."`
+	Set              []string `help:"Spec field of ProviderConfig as path=value, e.g. projectID=my-project (repeatable)."`
+	NonInteractive   bool     `short:"y" help:"Do not ask for missing options, required for CI."`
+}
+
+func (c *configureCmd) Run(ctx context.Context, dc *dynamic.DynamicClient, client *kubernetes.Clientset, logger *zap.SugaredLogger) error {
+	values := map[string]string{}
+	for _, set := range c.Set {
+		path, value, ok := strings.Cut(set, "=")
+		if !ok || path == "" {
+			return fmt.Errorf("invalid --set %q, expected path=value", set)
+		}
+		values[strings.TrimPrefix(path, "spec.")] = value
+	}
+	options := provider.ConfigOptions{
+		Name:             c.Name,
+		Group:            c.Group,
+		Source:           c.Source,
+		SecretName:       c.SecretName,
+		SecretNamespace:  c.SecretNamespace,
+		SecretKey:        c.SecretKey,
+		CredentialsFile:  c.CredentialsFile,
+		CredentialsEnv:   c.CredentialsEnv,
+		CredentialsStdin: c.CredentialsStdin,
+		Values:           values,
+		Interactive:      !c.NonInteractive,
+	}
+	return provider.Configure(ctx, dc, client, c.Provider, options, logger)
+}
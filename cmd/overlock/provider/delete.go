@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"time"
 
 	"go.uber.org/zap"
 	"k8s.io/client-go/dynamic"
@@ -11,9 +12,13 @@ import (
 )
 
 type deleteCmd struct {
-	ProviderUrl string `arg:"" required:"" help:"Crossplane provider package URL to be removed from the environment."`
+	ProviderUrl        string        `arg:"" required:"" help:"Crossplane provider package URL to be removed from the environment."`
+	Cascade            bool          `help:"Delete managed resources of provider and wait until they are removed."`
+	Timeout            time.Duration `default:"5m" short:"t" help:"Time to wait for managed resources removal, 0 waits without limit."`
+	PruneRuntimeConfig bool          `help:"Delete runtime config of provider if no other package uses it."`
 }
 
 func (c *deleteCmd) Run(ctx context.Context, config *rest.Config, dynamicClient *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
-	return provider.DeleteProvider(ctx, config, c.ProviderUrl, logger)
+	options := provider.DeleteOptions{Cascade: c.Cascade, Timeout: c.Timeout, PruneRuntimeConfig: c.PruneRuntimeConfig}
+	return provider.DeleteProvider(ctx, config, dynamicClient, c.ProviderUrl, options, logger)
 }
@@ -2,10 +2,13 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"go.uber.org/zap"
 
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
 	"github.com/web-seven/overlock/internal/provider"
@@ -13,10 +16,50 @@ import (
 
 type installCmd struct {
 	ProviderUrl string `arg:"" required:"" help:"Provider URL to Crossplane provider to be installed to Environment."`
+	Wait        bool   `optional:"" short:"w" help:"Wait until provider is installed and healthy."`
+	Timeout     string `optional:"" short:"t" help:"Timeout is used to set how much to wait until provider is installed (valid time units are ns, us, ms, s, m, h)"`
+	Mock        bool   `help:"Install only CRDs of provider package and run fake controller reporting its managed resources ready, until interrupted."`
+	Fixtures    string `type:"existingfile" help:"YAML file with status.atProvider fields and connection details reported by mock provider per kind."`
+	Path        string `type:"path" help:"Package archive or provider project directory to read CRDs of mock provider from, instead of pulling package."`
 }
 
-func (c *installCmd) Run(ctx context.Context, config *rest.Config, dynamicClient *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
-	provider.InstallProvider(c.ProviderUrl, config, logger)
+// LongRunning reports that mock provider runs until interrupted
+func (c *installCmd) LongRunning() bool {
+	return c.Mock
+}
+
+func (c *installCmd) Run(ctx context.Context, config *rest.Config, dynamicClient *dynamic.DynamicClient, client *kubernetes.Clientset, logger *zap.SugaredLogger) error {
+	if c.Fixtures != "" && !c.Mock {
+		return fmt.Errorf("--fixtures can be used only with --mock")
+	}
+	if c.Path != "" && !c.Mock {
+		return fmt.Errorf("--path can be used only with --mock")
+	}
+	if c.Mock {
+		fixtures := []provider.Fixture{}
+		if c.Fixtures != "" {
+			var err error
+			if fixtures, err = provider.LoadFixtures(c.Fixtures); err != nil {
+				return err
+			}
+		}
+		return provider.Mock(ctx, dynamicClient, client, config, c.ProviderUrl, c.Path, fixtures, logger)
+	}
+
+	source, err := provider.InstallProvider(ctx, c.ProviderUrl, config, logger)
+	if err != nil {
+		return err
+	}
+	if !c.Wait {
+		return nil
+	}
 
-	return nil
+	var timeout time.Duration
+	if c.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(c.Timeout); err != nil {
+			return err
+		}
+	}
+	return provider.WaitInstalled(ctx, dynamicClient, source, timeout, logger)
 }
@@ -0,0 +1,23 @@
+package provider
+
+import (
+	"context"
+	"path/filepath"
+
+	"go.uber.org/zap"
+
+	"github.com/web-seven/overlock/internal/lint"
+	"github.com/web-seven/overlock/internal/packages"
+)
+
+type lintCmd struct {
+	Path string `default:"./" arg:"" help:"Path to package directory"`
+}
+
+func (c *lintCmd) Run(ctx context.Context, logger *zap.SugaredLogger) error {
+	if err := lint.Check(filepath.Join(c.Path, packages.PackagePath), lint.KindProvider, logger); err != nil {
+		return err
+	}
+	logger.Infof("Provider package %s is valid.", c.Path)
+	return nil
+}
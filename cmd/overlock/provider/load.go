@@ -16,8 +16,9 @@ type loadCmd struct {
 	Path    string `help:"Path to provider package archive."`
 	Apply   bool   `help:"Apply provider after load."`
 	Upgrade bool   `help:"Upgrade existing provider."`
+	SignKey string `type:"existingfile" help:"Path to cosign compatible private key to sign loaded image with."`
 }
 
 func (p *loadCmd) Run(ctx context.Context, config *rest.Config, dc *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
-	return provider.New(p.Name).WithApply(p.Apply).WithUpgrade(p.Upgrade).LoadProvider(ctx, p.Path, config, dc, logger)
+	return provider.New(p.Name).WithApply(p.Apply).WithUpgrade(p.Upgrade).WithSignKey(p.SignKey).LoadProvider(ctx, p.Path, config, dc, logger)
 }
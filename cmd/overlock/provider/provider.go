@@ -1,9 +1,19 @@
 package provider
 
+import "github.com/web-seven/overlock/cmd/overlock/pkgcmd"
+
 type Cmd struct {
-	Install installCmd `cmd:"" help:"Install Crossplane Provider."`
-	List    listCmd    `cmd:"" help:"List all Crossplane Providers."`
-	Load    loadCmd    `cmd:"" help:"Load Crossplane Provider."`
-	Serve   serveCmd   `cmd:"" help:"Watch changes of Provider, build and load."`
-	Delete  deleteCmd  `cmd:"" help:"Delete Crossplane Provider."`
+	Install   installCmd         `cmd:"" help:"Install Crossplane Provider."`
+	Apply     applyCmd           `cmd:"" help:"Apply Crossplane Provider."`
+	List      listCmd            `cmd:"" help:"List all Crossplane Providers."`
+	Configure configureCmd       `cmd:"" help:"Create credentials Secret and ProviderConfig of Crossplane Provider."`
+	Outdated  pkgcmd.OutdatedCmd `cmd:"" set:"kind=Provider" help:"List available versions of installed Crossplane Providers."`
+	Upgrade   pkgcmd.UpgradeCmd  `cmd:"" set:"kind=Provider" help:"Upgrade Crossplane Provider to newer version."`
+	History   pkgcmd.HistoryCmd  `cmd:"" set:"kind=Provider" help:"List revisions of Crossplane Provider."`
+	Rollback  pkgcmd.RollbackCmd `cmd:"" set:"kind=Provider" help:"Roll back Crossplane Provider to previous revision."`
+	Load      loadCmd            `cmd:"" help:"Load Crossplane Provider."`
+	Serve     serveCmd           `cmd:"" help:"Watch changes of Provider, build and load."`
+	Run       runCmd             `cmd:"" help:"Run Provider out of cluster against environment, restart on changes."`
+	Lint      lintCmd            `cmd:"" help:"Validate Crossplane Provider package directory."`
+	Delete    deleteCmd          `cmd:"" help:"Delete Crossplane Provider."`
 }
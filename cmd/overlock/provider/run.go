@@ -0,0 +1,33 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+
+	"github.com/web-seven/overlock/internal/provider"
+	"github.com/web-seven/overlock/internal/watcher"
+)
+
+type runCmd struct {
+	Path     string        `default:"./" arg:"" help:"Path to package directory"`
+	MainPath string        `default:"cmd/provider" arg:"" help:"Path to main module"`
+	Provider string        `help:"Name of installed provider scaled to zero while running, detected from package meta by default."`
+	Arg      []string      `help:"Argument of provider process, e.g. --debug (repeatable)."`
+	Delve    string        `help:"Run provider with delve headless server listening on address, e.g. 127.0.0.1:2345."`
+	Debounce time.Duration `default:"500ms" help:"Quiet period after the last change before provider is restarted."`
+	Ignore   []string      `help:"Additional patterns of files and directories ignored by watcher (e.g. testdata, *.tmp)."`
+}
+
+// LongRunning reports that command runs until interrupted
+func (c *runCmd) LongRunning() bool {
+	return true
+}
+
+func (c *runCmd) Run(ctx context.Context, dc *dynamic.DynamicClient, config *rest.Config, logger *zap.SugaredLogger) error {
+	options := provider.RunOptions{Provider: c.Provider, Args: c.Arg, Delve: c.Delve}
+	return provider.Run(ctx, dc, config, logger, c.Path, c.MainPath, options, watcher.Options{Debounce: c.Debounce, Ignore: c.Ignore})
+}
@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -9,13 +10,23 @@ import (
 	"k8s.io/client-go/rest"
 
 	"github.com/web-seven/overlock/internal/provider"
+	"github.com/web-seven/overlock/internal/watcher"
 )
 
 type serveCmd struct {
-	Path     string `default:"./" arg:"" help:"Path to package directory"`
-	MainPath string `default:"cmd/provider" arg:"" help:"Path to main module"`
+	Path     string        `default:"./" arg:"" help:"Path to package directory"`
+	MainPath string        `default:"cmd/provider" arg:"" help:"Path to main module"`
+	Debounce time.Duration `default:"500ms" help:"Quiet period after the last change before package is rebuilt."`
+	Ignore   []string      `help:"Additional patterns of files and directories ignored by watcher (e.g. testdata, *.tmp)."`
+	SkipLint bool          `help:"Do not lint package directory before build."`
+}
+
+// LongRunning reports that command runs until interrupted
+func (c *serveCmd) LongRunning() bool {
+	return true
 }
 
 func (c *serveCmd) Run(ctx context.Context, dc *dynamic.DynamicClient, config *rest.Config, logger *zap.SugaredLogger) error {
-	return provider.Serve(ctx, dc, config, logger, c.Path, c.MainPath)
+	pvd := &provider.Provider{SkipLint: c.SkipLint}
+	return provider.Serve(ctx, dc, config, logger, c.Path, c.MainPath, pvd, watcher.Options{Debounce: c.Debounce, Ignore: c.Ignore})
 }
@@ -0,0 +1,49 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/web-seven/overlock/pkg/registry"
+)
+
+type bundleCmd struct {
+	Export bundleExportCmd `cmd:"" help:"Export packages with their dependencies to OCI layout tarball"`
+	Import bundleImportCmd `cmd:"" help:"Import OCI layout tarball to local registry, bundled dependencies are referenced from it"`
+}
+
+type bundleExportCmd struct {
+	File     string   `required:"" short:"f" type:"path" help:"Path of bundle tarball to write."`
+	Archives []string `sep:"none" help:"Package or artifact archives to export, given as path=reference (e.g. ./package.xpkg=acme/configuration:v1.0.0)."`
+	Packages []string `arg:"" optional:"" help:"Package URLs to export, dependencies are resolved from package metadata."`
+}
+
+func (c *bundleExportCmd) Run(ctx context.Context, logger *zap.SugaredLogger) error {
+	if len(c.Packages) == 0 && len(c.Archives) == 0 {
+		return fmt.Errorf("no packages or archives to export")
+	}
+	return registry.ExportBundle(ctx, c.File, c.Packages, c.Archives, logger)
+}
+
+type bundleImportCmd struct {
+	Path string `arg:"" required:"" type:"existingfile" help:"Path to bundle tarball."`
+}
+
+func (c *bundleImportCmd) Run(ctx context.Context, client *kubernetes.Clientset, config *rest.Config, logger *zap.SugaredLogger) error {
+	isLocal, err := registry.IsLocalRegistry(ctx, client)
+	if !isLocal || err != nil {
+		if err != nil {
+			logger.Debug(err)
+		}
+		reg := registry.NewLocal()
+		reg.SetDefault(true)
+		if err := reg.Create(ctx, config, logger); err != nil {
+			return fmt.Errorf("failed to create local registry: %w", err)
+		}
+	}
+	return registry.ImportBundle(ctx, c.Path, config, logger)
+}
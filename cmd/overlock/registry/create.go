@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/web-seven/overlock/internal/engine"
 	"github.com/web-seven/overlock/pkg/registry"
 
 	"k8s.io/client-go/kubernetes"
@@ -27,9 +29,22 @@ type createCmd struct {
 	Context        string   `short:"c" help:"Kubernetes context where registry will be created."`
 	Label          []string `short:"l" help:"Label to attach to the registry secret in key:value format. Can be specified multiple times."`
 	Update         bool     `help:"Update credentials of an existing registry with the same server instead of skipping."`
+
+	CredentialCommand string        `help:"Command which prints registry password or token to STDOUT, used to issue and refresh short-lived credentials."`
+	CredentialTTL     time.Duration `name:"credential-ttl" help:"Lifetime of credentials issued by credential command (e.g. 12h)."`
+
+	Route []string `help:"Package route in pattern=target format (e.g. acme/*=ghcr.io/acme), short package names matching pattern are resolved to this registry. Can be specified multiple times."`
 }
 
 func (c *createCmd) Run(ctx context.Context, client *kubernetes.Clientset, config *rest.Config, logger *zap.SugaredLogger) error {
+	if c.CredentialCommand != "" && c.Password == "" {
+		password, err := registry.RunCredentialCommand(ctx, c.CredentialCommand)
+		if err != nil {
+			return err
+		}
+		c.Password = password
+	}
+
 	reg := registry.New(c.RegistryServer, c.Username, c.Password, c.Email)
 	if c.Local {
 		reg = registry.NewLocal()
@@ -37,6 +52,15 @@ func (c *createCmd) Run(ctx context.Context, client *kubernetes.Clientset, confi
 	reg.SetDefault(c.Default)
 	reg.SetLocal(c.Local)
 	reg.WithContext(c.Context)
+	reg.WithCredentialSource(c.CredentialCommand, c.CredentialTTL)
+
+	for _, r := range c.Route {
+		route, err := engine.ParseRoute(r)
+		if err != nil {
+			return err
+		}
+		reg.AddRoutes(route)
+	}
 
 	if len(c.Label) > 0 {
 		labels := make(map[string]string, len(c.Label))
@@ -2,9 +2,11 @@ package registry
 
 import (
 	"context"
+	"time"
 
 	"github.com/pterm/pterm"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/duration"
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/web-seven/overlock/pkg/registry"
@@ -20,13 +22,24 @@ func (c listCmd) Run(ctx context.Context, client *kubernetes.Clientset, logger *
 	}
 
 	tableRegs := pterm.TableData{
-		[]string{"NAME", "SERVER", "DATE"},
+		[]string{"NAME", "SERVER", "EXPIRES", "DATE"},
 	}
 
 	for _, reg := range registries {
+		expires := "-"
+		if expiry, ok := reg.Expiry(); ok {
+			left := time.Until(expiry)
+			if left > 0 {
+				expires = "in " + duration.HumanDuration(left)
+			} else {
+				expires = "expired " + duration.HumanDuration(-left) + " ago"
+				logger.Warnf("Credentials of registry %s expired, run `overlock registry refresh %s`.", reg.GetName(), reg.GetName())
+			}
+		}
 		tableRegs = append(tableRegs, []string{
 			reg.GetName(),
 			reg.Annotations["overlock-registry-server-url"],
+			expires,
 			reg.CreationTimestamp.String(),
 		})
 	}
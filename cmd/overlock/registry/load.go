@@ -6,11 +6,8 @@ import (
 	"strings"
 
 	semver "github.com/Masterminds/semver/v3"
-	"github.com/google/go-containerregistry/pkg/crane"
 	"github.com/google/go-containerregistry/pkg/name"
 	regv1 "github.com/google/go-containerregistry/pkg/v1"
-	"github.com/google/go-containerregistry/pkg/v1/empty"
-	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"go.uber.org/zap"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -20,21 +17,13 @@ import (
 
 const tagDelim = ":"
 
-// OCI media types
-const (
-	HelmConfigMediaType  = "application/vnd.cncf.helm.config.v1+json"
-	HelmContentMediaType = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
-	OCIManifestSchema1   = "application/vnd.oci.image.manifest.v1+json"
-	OCIConfigMediaType   = "application/vnd.oci.image.config.v1+json"
-	OCILayerMediaType    = "application/vnd.oci.image.layer.v1.tar+gzip"
-)
-
 type loadImageCmd struct {
 	Registry string `arg:"" help:"Name of the registry to load the image to."`
 	Path     string `arg:"" help:"Path to OCI image TAR archive."`
 	Name     string `required:"" short:"i" help:"Image name and tag (e.g., my-image:1.0)."`
 	Upgrade  bool   `help:"Upgrade patch version if image exists."`
 	Helm     bool   `help:"Add Helm chart OCI manifest layers with proper media types."`
+	SignKey  string `type:"existingfile" help:"Path to cosign compatible private key to sign loaded image with."`
 }
 
 func (c *loadImageCmd) Run(ctx context.Context, client *kubernetes.Clientset, config *rest.Config, logger *zap.SugaredLogger) error {
@@ -58,7 +47,7 @@ func (c *loadImageCmd) Run(ctx context.Context, client *kubernetes.Clientset, co
 
 	// Always create OCI image from empty base with archive as layer
 	logger.Debug("Creating OCI image from empty base")
-	image, err = createOCIImage(c.Path, c.Helm)
+	image, err = registry.ArchiveImage(c.Path, c.Helm)
 	if err != nil {
 		return fmt.Errorf("failed to create OCI image: %w", err)
 	}
@@ -80,6 +69,12 @@ func (c *loadImageCmd) Run(ctx context.Context, client *kubernetes.Clientset, co
 	}
 
 	logger.Infof("Image %s loaded to local registry.", imageName)
+	if c.SignKey != "" {
+		if err := registry.SignLocalImage(ctx, imageName, image, c.SignKey, config, logger); err != nil {
+			return fmt.Errorf("failed to sign image: %w", err)
+		}
+		logger.Infof("Image %s signed.", imageName)
+	}
 	return nil
 }
 
@@ -137,57 +132,3 @@ func (c *loadImageCmd) upgradeImageVersion(ctx context.Context, config *rest.Con
 type Image struct {
 	regv1.Image
 }
-
-// createOCIImage creates an OCI image from an archive with empty base layer
-// If helm is true, applies Helm-specific media types
-func createOCIImage(archivePath string, helm bool) (regv1.Image, error) {
-	// Start with empty OCI base image and append the archive as a layer
-	img, err := crane.Append(empty.Image, archivePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to append layer to empty image: %w", err)
-	}
-
-	// Get the layer we just added
-	layers, err := img.Layers()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get image layers: %w", err)
-	}
-
-	if len(layers) == 0 {
-		return nil, fmt.Errorf("no layers found in image")
-	}
-
-	if helm {
-		// Rebuild image with Helm-specific media types
-		baseImg := mutate.ConfigMediaType(empty.Image, HelmConfigMediaType)
-
-		// Add the layer with Helm content media type
-		img, err = mutate.Append(baseImg, mutate.Addendum{
-			Layer:     layers[len(layers)-1],
-			MediaType: HelmContentMediaType,
-		})
-		if err != nil {
-			return nil, fmt.Errorf("failed to append layer with Helm media type: %w", err)
-		}
-
-		// Set the manifest media type to OCI
-		img = mutate.MediaType(img, OCIManifestSchema1)
-	} else {
-		// Use standard OCI media types
-		baseImg := mutate.ConfigMediaType(empty.Image, OCIConfigMediaType)
-
-		// Add the layer with standard OCI layer media type
-		img, err = mutate.Append(baseImg, mutate.Addendum{
-			Layer:     layers[len(layers)-1],
-			MediaType: OCILayerMediaType,
-		})
-		if err != nil {
-			return nil, fmt.Errorf("failed to append layer: %w", err)
-		}
-
-		// Set the manifest media type to OCI
-		img = mutate.MediaType(img, OCIManifestSchema1)
-	}
-
-	return img, nil
-}
@@ -0,0 +1,45 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/web-seven/overlock/pkg/registry"
+)
+
+type refreshCmd struct {
+	Name string `arg:"" optional:"" help:"Registry name, by default all registries with credential command are refreshed."`
+}
+
+func (c *refreshCmd) Run(ctx context.Context, client *kubernetes.Clientset, logger *zap.SugaredLogger) error {
+	registries, err := registry.Registries(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	refreshed := 0
+	for _, reg := range registries {
+		if c.Name != "" && reg.GetName() != c.Name {
+			continue
+		}
+		if c.Name == "" && reg.CredentialCommand() == "" {
+			continue
+		}
+		if err := reg.Refresh(ctx, client, logger); err != nil {
+			return fmt.Errorf("failed to refresh registry %s: %w", reg.GetName(), err)
+		}
+		logger.Infof("Registry '%s' credentials refreshed.", reg.GetName())
+		refreshed++
+	}
+
+	if refreshed == 0 {
+		if c.Name != "" {
+			return fmt.Errorf("registry %s not found", c.Name)
+		}
+		logger.Info("No registries with credential command found.")
+	}
+	return nil
+}
@@ -14,6 +14,9 @@ type Cmd struct {
 	List      listCmd      `cmd:"" help:"List registries"`
 	Delete    deleteCmd    `cmd:"" help:"Delete registry"`
 	LoadImage loadImageCmd `cmd:"" name:"load-image" help:"Load OCI image to registry"`
+	Refresh   refreshCmd   `cmd:"" help:"Refresh registry credentials using their source command"`
+	Route     routeCmd     `cmd:"" help:"Manage package routes of registries"`
+	Bundle    bundleCmd    `cmd:"" help:"Export and import air-gapped package bundles"`
 }
 
 func Predictors(ctx context.Context, client *kubernetes.Clientset) map[string]complete.Predictor {
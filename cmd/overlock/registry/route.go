@@ -0,0 +1,95 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pterm/pterm"
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/web-seven/overlock/internal/engine"
+	"github.com/web-seven/overlock/pkg/registry"
+)
+
+type routeCmd struct {
+	Add    routeAddCmd    `cmd:"" help:"Add package routes to registry"`
+	List   routeListCmd   `cmd:"" help:"List package routes of all registries"`
+	Remove routeRemoveCmd `cmd:"" help:"Remove package route from registry"`
+}
+
+type routeAddCmd struct {
+	Registry string   `arg:"" required:"" help:"Registry name."`
+	Routes   []string `arg:"" required:"" help:"Package routes in pattern=target format (e.g. acme/*=ghcr.io/acme)."`
+}
+
+func (c *routeAddCmd) Run(ctx context.Context, client *kubernetes.Clientset, logger *zap.SugaredLogger) error {
+	reg, err := findRegistry(ctx, client, c.Registry)
+	if err != nil {
+		return err
+	}
+	for _, r := range c.Routes {
+		route, err := engine.ParseRoute(r)
+		if err != nil {
+			return err
+		}
+		reg.AddRoutes(route)
+	}
+	if err := reg.SaveRoutes(ctx, client); err != nil {
+		return err
+	}
+	logger.Infof("Routes of registry '%s' updated successfully.", c.Registry)
+	return nil
+}
+
+type routeListCmd struct {
+}
+
+func (c *routeListCmd) Run(ctx context.Context, client *kubernetes.Clientset, logger *zap.SugaredLogger) error {
+	registries, err := registry.Registries(ctx, client)
+	if err != nil {
+		return err
+	}
+	table := pterm.TableData{
+		[]string{"PATTERN", "TARGET", "REGISTRY"},
+	}
+	for _, reg := range registries {
+		for _, route := range reg.Routes() {
+			table = append(table, []string{route.Pattern, route.Target, reg.GetName()})
+		}
+	}
+	return pterm.DefaultTable.WithHasHeader().WithData(table).Render()
+}
+
+type routeRemoveCmd struct {
+	Registry string `arg:"" required:"" help:"Registry name."`
+	Pattern  string `arg:"" required:"" help:"Pattern of route to remove (e.g. acme/*)."`
+}
+
+func (c *routeRemoveCmd) Run(ctx context.Context, client *kubernetes.Clientset, logger *zap.SugaredLogger) error {
+	reg, err := findRegistry(ctx, client, c.Registry)
+	if err != nil {
+		return err
+	}
+	if !reg.RemoveRoute(c.Pattern) {
+		return fmt.Errorf("route %s not found in registry %s", c.Pattern, c.Registry)
+	}
+	if err := reg.SaveRoutes(ctx, client); err != nil {
+		return err
+	}
+	logger.Infof("Route '%s' removed from registry '%s'.", c.Pattern, c.Registry)
+	return nil
+}
+
+func findRegistry(ctx context.Context, client *kubernetes.Clientset, name string) (*registry.Registry, error) {
+	registries, err := registry.Registries(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	for _, reg := range registries {
+		if reg.GetName() == name {
+			return reg, nil
+		}
+	}
+	return nil, fmt.Errorf("registry %s not found", name)
+}
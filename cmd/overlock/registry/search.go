@@ -15,11 +15,12 @@ import (
 type SearchCmd struct {
 	// Query is the search query
 	Query    string `arg:"" help:"search query"`
-	Versions bool   `optional:""  short:"v" help:"display all versions"`
+	Versions bool   `optional:"" short:"a" help:"display all versions"`
+	Registry string `optional:"" help:"Name of registry to search in, by default all registries and Upbound marketplace are searched."`
 }
 
 func (c *SearchCmd) Run(ctx context.Context, client *kubernetes.Clientset, config *rest.Config, logger *zap.SugaredLogger) error {
-	tableRegs, err := search.SearchPackages(ctx, client, config, c.Query, c.Versions, logger)
+	tableRegs, err := search.SearchPackages(ctx, client, config, c.Query, c.Versions, c.Registry, logger)
 	if err != nil {
 		return err
 	}
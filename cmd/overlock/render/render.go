@@ -0,0 +1,81 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/yaml"
+
+	"github.com/web-seven/overlock/internal/render"
+	"github.com/web-seven/overlock/pkg/registry"
+)
+
+type Cmd struct {
+	Composite         string   `arg:"" help:"YAML file with composite resource."`
+	Composition       string   `required:"" short:"c" help:"YAML file with composition in Pipeline mode."`
+	Functions         []string `help:"YAML file with functions used by composition (repeatable)."`
+	Function          []string `help:"Address of locally running function, e.g. function-patch-and-transform=localhost:9443 (repeatable)."`
+	ObservedResources string   `help:"YAML file with observed composed resources, annotated with crossplane.io/composition-resource-name."`
+	XRD               string   `name:"xrd" help:"YAML file with XRD of composite resource, defaults of its schema are applied."`
+}
+
+func (c *Cmd) Run(ctx context.Context, logger *zap.SugaredLogger) error {
+	in := render.Inputs{}
+	var err error
+	if in.Composite, err = render.LoadComposite(c.Composite); err != nil {
+		return err
+	}
+	if in.Composition, err = render.LoadComposition(c.Composition); err != nil {
+		return err
+	}
+	for _, path := range c.Functions {
+		functions, err := render.LoadFunctions(path)
+		if err != nil {
+			return err
+		}
+		in.Functions = append(in.Functions, functions...)
+	}
+	// Addresses override functions loaded from files
+	for _, fn := range c.Function {
+		name, address, ok := strings.Cut(fn, "=")
+		if !ok || name == "" || address == "" {
+			return fmt.Errorf("invalid function %q, expected name=address", fn)
+		}
+		in.Functions = append(in.Functions, render.DevelopmentFunction(name, address))
+	}
+	if c.XRD != "" {
+		if in.XRD, err = render.LoadXRD(c.XRD); err != nil {
+			return err
+		}
+	}
+	// Routes of environment are optional, render does not need cluster
+	if config, err := ctrl.GetConfig(); err == nil {
+		if in.Routes, err = registry.PackageRoutes(ctx, config); err != nil {
+			logger.Debugf("Package routes of environment not loaded: %v", err)
+		}
+	}
+	if c.ObservedResources != "" {
+		if in.Observed, err = render.LoadObjects(c.ObservedResources); err != nil {
+			return err
+		}
+	}
+
+	out, err := render.Render(ctx, in, logger)
+	if err != nil {
+		return err
+	}
+	objects := append([]unstructured.Unstructured{*out.Composite}, out.Composed...)
+	for _, obj := range objects {
+		content, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "---\n%s", content)
+	}
+	return nil
+}
@@ -2,36 +2,115 @@ package resource
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"os"
 
 	"go.uber.org/zap"
 
 	crossv1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
 
 	"github.com/web-seven/overlock/internal/resources"
 )
 
 type createCmd struct {
-	Type string `arg:"" required:"" help:"XRD type name."`
+	Type           string   `arg:"" required:"" help:"XRD type name."`
+	Name           string   `help:"Name of resource, fields are asked with form unless --name, --set, --set-file or --from-example is given."`
+	Set            []string `sep:"none" help:"Set field as path=value, value is converted to type of field in schema, objects and arrays are JSON (e.g. spec.region=eu-central-1, repeatable)."`
+	SetFile        []string `name:"set-file" sep:"none" help:"Set field as path=file, objects and arrays are read as YAML, other types as text without trailing newline (repeatable)."`
+	FromExample    string   `name:"from-example" type:"existingfile" help:"Manifest of resource used as base, e.g. from examples of configuration."`
+	DryRun         bool     `name:"dry-run" help:"Print manifest instead of creating resource."`
+	Output         string   `short:"o" enum:"yaml,json" default:"yaml" help:"Format of manifest printed with --dry-run (yaml, json)."`
+	Claim          bool     `help:"Create claim of XRD instead of composite resource."`
+	ClaimNamespace string   `default:"default" help:"Namespace of created claim."`
 }
 
 func (c *createCmd) Run(ctx context.Context, client *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
+	var xr *unstructured.Unstructured
+	var resource string
+	if c.Name == "" && len(c.Set) == 0 && len(c.SetFile) == 0 && c.FromExample == "" {
+		// Claim has schema of its composite resource, form of XRD is used
+		var claimCRD *extv1.CustomResourceDefinition
+		if c.Claim {
+			var err error
+			if claimCRD, err = resources.ClaimCRD(ctx, client, c.Type); err != nil {
+				return err
+			}
+		}
+		xResource, err := CreateXResource(ctx, c.Type, client, logger)
+		if err != nil || xResource == nil {
+			return err
+		}
+		xr, resource = &xResource.Unstructured, xResource.Resource
+		if claimCRD != nil {
+			xr.SetKind(claimCRD.Spec.Names.Kind)
+			xr.SetNamespace(c.ClaimNamespace)
+			resource = claimCRD.Spec.Names.Plural
+		}
+	} else {
+		getCRD := resources.XResourceCRD
+		if c.Claim {
+			getCRD = resources.ClaimCRD
+		}
+		crd, err := getCRD(ctx, client, c.Type)
+		if err != nil {
+			return err
+		}
+		xr, err = resources.BuildXResource(crd, resources.CreateOptions{
+			Name:      c.Name,
+			Example:   c.FromExample,
+			Values:    c.Set,
+			Files:     c.SetFile,
+			Namespace: c.ClaimNamespace,
+		})
+		if err != nil {
+			return err
+		}
+		resource = crd.Spec.Names.Plural
+	}
+
+	if c.DryRun {
+		var content []byte
+		var err error
+		if c.Output == "json" {
+			content, err = json.MarshalIndent(xr.Object, "", "  ")
+			content = append(content, '\n')
+		} else {
+			content, err = yaml.Marshal(xr.Object)
+		}
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(content)
+		return err
+	}
+
+	gvr := schema.GroupVersionResource{Group: xr.GroupVersionKind().Group, Version: xr.GroupVersionKind().Version, Resource: resource}
+	if _, err := client.Resource(gvr).Namespace(xr.GetNamespace()).Create(ctx, xr, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("cannot create %s %s: %w", xr.GetKind(), xr.GetName(), err)
+	}
+	logger.Infof("%s %s created.", xr.GetKind(), xr.GetName())
+	return nil
+}
+
+// CreateXResource builds resource of XRD type with form generated from its
+// schema, nil resource is returned if creation is not confirmed
+func CreateXResource(ctx context.Context, xrdName string, client *dynamic.DynamicClient, logger *zap.SugaredLogger) (*resources.XResource, error) {
 	xrd := crossv1.CompositeResourceDefinition{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: c.Type,
+			Name: xrdName,
 		},
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "apiextensions.k8s.io/v1",
 			Kind:       "customresourcedefinitions",
 		},
 	}
-	CreateXResource(ctx, xrd, client, logger)
-	return nil
-}
-
-func CreateXResource(ctx context.Context, xrd crossv1.CompositeResourceDefinition, client *dynamic.DynamicClient, logger *zap.SugaredLogger) bool {
 	xResource := resources.XResource{}
 	form := xResource.GetSchemaFormFromXRDefinition(
 		ctx,
@@ -39,27 +118,17 @@ func CreateXResource(ctx context.Context, xrd crossv1.CompositeResourceDefinitio
 		client,
 		logger,
 	)
-
-	if form != nil {
-		form.Run()
-	} else {
-		return false
+	if form == nil {
+		return nil, fmt.Errorf("cannot read schema of %s", xrdName)
 	}
-
-	if form.GetBool("confirm") {
-		groupVersion := schema.GroupVersionResource{
-			Group:    xResource.GroupVersionKind().Group,
-			Version:  xResource.GroupVersionKind().Version,
-			Resource: xResource.Resource,
-		}
-
-		_, err := client.Resource(
-			groupVersion,
-		).Create(ctx, &xResource.Unstructured, metav1.CreateOptions{})
-		if err != nil {
-			logger.Error(err)
-		}
-		return true
+	if err := form.Run(); err != nil {
+		return nil, err
+	}
+	if !form.GetBool("confirm") {
+		return nil, nil
+	}
+	if err := xResource.Resolve(); err != nil {
+		return nil, err
 	}
-	return false
+	return &xResource, nil
 }
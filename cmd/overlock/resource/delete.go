@@ -0,0 +1,38 @@
+package resource
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+
+	"github.com/web-seven/overlock/internal/resources"
+)
+
+type deleteCmd struct {
+	Resources      []string `arg:"" required:"" help:"Resources as kind/name, e.g. XBucket/demo or buckets.example.org/demo."`
+	ClaimNamespace string   `default:"default" help:"Namespace of deleted claims."`
+}
+
+func (c *deleteCmd) Run(ctx context.Context, config *rest.Config, dc *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+	for _, ref := range c.Resources {
+		gvk, name, err := resources.ResolveResource(mapper, ref)
+		if err != nil {
+			return err
+		}
+		if err := resources.DeleteResource(ctx, dc, mapper, gvk, c.ClaimNamespace, name); err != nil {
+			return err
+		}
+		logger.Infof("%s %s deleted.", gvk.Kind, name)
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/ghodss/yaml"
 	"github.com/rodaine/table"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/web-seven/overlock/internal/resources"
 
@@ -14,15 +15,28 @@ import (
 )
 
 type listCmd struct {
+	Claims         bool   `help:"List claims instead of composite resources."`
+	ClaimNamespace string `help:"Namespace of listed claims, all namespaces if empty."`
 }
 
-func (listCmd) Run(ctx context.Context, config *rest.Config, dynamicClient *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
-	tbl := table.New("NAME", "API-VERSION", "KIND", "CREATION-DATE", "UPDATE-DATE")
-
-	xresources := resources.GetXResources(ctx, dynamicClient, logger)
+func (c listCmd) Run(ctx context.Context, config *rest.Config, dynamicClient *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
+	var tbl table.Table
+	var xresources []unstructured.Unstructured
+	if c.Claims {
+		tbl = table.New("NAME", "NAMESPACE", "API-VERSION", "KIND", "COMPOSITE", "CREATION-DATE", "UPDATE-DATE")
+		xresources = resources.GetClaims(ctx, dynamicClient, c.ClaimNamespace, logger)
+	} else {
+		tbl = table.New("NAME", "API-VERSION", "KIND", "CREATION-DATE", "UPDATE-DATE")
+		xresources = resources.GetXResources(ctx, dynamicClient, logger)
+	}
 	for _, resource := range xresources {
 		labels := resource.GetLabels()
-		tbl.AddRow(resource.GetName(), resource.GetAPIVersion(), resource.GetKind(), labels["creation-date"], labels["update-date"])
+		if c.Claims {
+			composite, _, _ := unstructured.NestedString(resource.Object, "spec", "resourceRef", "name")
+			tbl.AddRow(resource.GetName(), resource.GetNamespace(), resource.GetAPIVersion(), resource.GetKind(), composite, labels["creation-date"], labels["update-date"])
+		} else {
+			tbl.AddRow(resource.GetName(), resource.GetAPIVersion(), resource.GetKind(), labels["creation-date"], labels["update-date"])
+		}
 
 		jsonFormat, err := resource.MarshalJSON()
 		if err != nil {
@@ -40,6 +54,8 @@ func (listCmd) Run(ctx context.Context, config *rest.Config, dynamicClient *dyna
 
 	if len(xresources) > 0 {
 		tbl.Print()
+	} else if c.Claims {
+		logger.Info("No claims found.")
 	} else {
 		logger.Info("No resources found managed by overlock.")
 	}
@@ -1,7 +1,9 @@
 package resource
 
 type Cmd struct {
-	Create createCmd `cmd:"" help:"Create an XR"`
-	List   listCmd   `cmd:"" help:"List of XRs"`
+	Create createCmd `cmd:"" help:"Create an XR or claim"`
+	List   listCmd   `cmd:"" help:"List of XRs or claims"`
 	Apply  applyCmd  `cmd:"" help:"Apply an XR"`
+	Tree   treeCmd   `cmd:"" help:"Show tree of XR or claim with its composed resources and their conditions"`
+	Delete deleteCmd `cmd:"" help:"Delete XRs and claims"`
 }
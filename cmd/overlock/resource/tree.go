@@ -0,0 +1,74 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+
+	"github.com/web-seven/overlock/internal/resources"
+)
+
+// Interval of redraws of watched tree
+const treeWatchInterval = 2 * time.Second
+
+type treeCmd struct {
+	Resource       string `arg:"" required:"" help:"Resource as kind/name, e.g. XBucket/demo or xbuckets.example.org/demo."`
+	ClaimNamespace string `default:"default" help:"Namespace of claim."`
+	Watch          bool   `short:"w" help:"Redraw tree as conditions change, until interrupted."`
+}
+
+// LongRunning reports that watched tree is redrawn until interrupted
+func (c *treeCmd) LongRunning() bool {
+	return c.Watch
+}
+
+func (c *treeCmd) Run(ctx context.Context, config *rest.Config, dc *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+	gvk, name, err := resources.ResolveResource(mapper, c.Resource)
+	if err != nil {
+		return err
+	}
+
+	previous := ""
+	for {
+		out := &bytes.Buffer{}
+		root := resources.ResourceTree(ctx, dc, mapper, gvk, c.ClaimNamespace, name)
+		if err := resources.PrintTree(out, root); err != nil {
+			return err
+		}
+		if !c.Watch {
+			if root.Err != nil {
+				return fmt.Errorf("cannot get %s: %w", c.Resource, root.Err)
+			}
+			_, err := out.WriteTo(os.Stdout)
+			return err
+		}
+		if out.String() != previous {
+			previous = out.String()
+			// Clear screen and move cursor home before redraw
+			fmt.Print("\033[H\033[2J")
+			fmt.Printf("Changed at %s, press Ctrl+C to stop.\n\n", time.Now().Format(time.TimeOnly))
+			if _, err := out.WriteTo(os.Stdout); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(treeWatchInterval):
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package runtimeconfig
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+
+	"github.com/web-seven/overlock/internal/runtimeconfig"
+)
+
+type createCmd struct {
+	Name   string   `arg:"" optional:"" help:"Name of runtime config, taken from manifest with --file."`
+	File   string   `short:"f" type:"existingfile" help:"DeploymentRuntimeConfig manifest to apply instead of shorthands."`
+	Debug  bool     `name:"runtime-debug" help:"Run package runtime with --debug, global --debug only enables debug output of Overlock."`
+	Arg    []string `help:"Additional argument of package runtime, e.g. --poll=1m (repeatable)."`
+	Env    []string `short:"e" help:"Environment variable of package runtime in K=V format (repeatable)."`
+	CPU    string   `name:"cpu" help:"CPU limit of package runtime."`
+	Memory string   `help:"Memory limit of package runtime."`
+	DryRun bool     `help:"Print generated manifest instead of applying it."`
+}
+
+func (c *createCmd) Run(ctx context.Context, dc *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
+	rc, err := c.manifest(ctx, dc)
+	if err != nil {
+		return err
+	}
+
+	if c.DryRun {
+		content, err := yaml.Marshal(rc.Object)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(content))
+		return nil
+	}
+	return runtimeconfig.Apply(ctx, dc, rc, logger)
+}
+
+// Runtime config read from file or generated from shorthands over default
+// runtime config
+func (c *createCmd) manifest(ctx context.Context, dc dynamic.Interface) (*unstructured.Unstructured, error) {
+	if c.File != "" {
+		rc, err := runtimeconfig.Load(c.File)
+		if err != nil {
+			return nil, err
+		}
+		if c.Name != "" {
+			rc.SetName(c.Name)
+		}
+		return rc, nil
+	}
+	if c.Name == "" {
+		return nil, fmt.Errorf("name of runtime config is required without --file")
+	}
+	template, err := runtimeconfig.DefaultTemplate(ctx, dc)
+	if err != nil {
+		return nil, err
+	}
+	opts := runtimeconfig.Options{Name: c.Name, Debug: c.Debug, Args: c.Arg, Env: c.Env, CPU: c.CPU, Memory: c.Memory, Template: template}
+	return opts.Manifest()
+}
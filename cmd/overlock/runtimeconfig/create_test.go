@@ -0,0 +1,34 @@
+package runtimeconfig
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/web-seven/overlock/internal/packages"
+)
+
+func TestCreateManifest(t *testing.T) {
+	dc := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		packages.RuntimeConfigs: "DeploymentRuntimeConfigList",
+	})
+	ctx := context.Background()
+
+	rc, err := (&createCmd{Name: "debug", Debug: true, Env: []string{"LOG=1"}}).manifest(ctx, dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rc.GetName() != "debug" {
+		t.Errorf("unexpected runtime config %v", rc.Object)
+	}
+
+	// Invalid shorthand is reported instead of returning no manifest
+	rc, err = (&createCmd{Name: "debug", Env: []string{"BAD"}}).manifest(ctx, dc)
+	if err == nil || !strings.Contains(err.Error(), "expected K=V") {
+		t.Errorf("expected error of invalid environment variable, got %v %v", rc, err)
+	}
+}
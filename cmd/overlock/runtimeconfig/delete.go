@@ -0,0 +1,18 @@
+package runtimeconfig
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/web-seven/overlock/internal/runtimeconfig"
+)
+
+type deleteCmd struct {
+	Name string `arg:"" required:"" help:"Name of runtime config to delete."`
+}
+
+func (c *deleteCmd) Run(ctx context.Context, dc *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
+	return runtimeconfig.Delete(ctx, dc, c.Name, logger)
+}
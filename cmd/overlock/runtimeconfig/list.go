@@ -0,0 +1,33 @@
+package runtimeconfig
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pterm/pterm"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/web-seven/overlock/internal/runtimeconfig"
+)
+
+type listCmd struct {
+}
+
+func (c *listCmd) Run(ctx context.Context, dc *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
+	configs, err := runtimeconfig.List(ctx, dc)
+	if err != nil {
+		return err
+	}
+	table := pterm.TableData{[]string{"NAME", "ARGS", "ENV", "LIMITS", "AGE"}}
+	for _, rc := range configs {
+		args, env, limits := runtimeconfig.Summary(&rc)
+		table = append(table, []string{rc.GetName(), args, env, limits, duration.HumanDuration(time.Since(rc.GetCreationTimestamp().Time))})
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(table).Render(); err != nil {
+		return fmt.Errorf("failed to render table: %w", err)
+	}
+	return nil
+}
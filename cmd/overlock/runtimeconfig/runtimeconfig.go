@@ -0,0 +1,7 @@
+package runtimeconfig
+
+type Cmd struct {
+	Create createCmd `cmd:"" help:"Create or update DeploymentRuntimeConfig."`
+	List   listCmd   `cmd:"" help:"List DeploymentRuntimeConfigs."`
+	Delete deleteCmd `cmd:"" help:"Delete DeploymentRuntimeConfig not used by any package."`
+}
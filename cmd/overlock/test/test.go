@@ -0,0 +1,65 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+
+	"github.com/web-seven/overlock/internal/testcase"
+)
+
+type Cmd struct {
+	Path  string `default:"./" arg:"" help:"Directory with *.test.yaml test case files."`
+	JUnit string `name:"junit" help:"Write JUnit XML report to file."`
+	Keep  bool   `help:"Leave resources of test cases in environment for debugging."`
+}
+
+func (c *Cmd) Run(ctx context.Context, config *rest.Config, dc *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
+	cases, err := testcase.Load(c.Path)
+	if err != nil {
+		return err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return err
+	}
+	runner := &testcase.Runner{
+		Dynamic: dc,
+		Mapper:  restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient)),
+		Keep:    c.Keep,
+		Logger:  logger,
+	}
+	results := runner.Run(ctx, cases)
+
+	if c.JUnit != "" {
+		file, err := os.Create(c.JUnit)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		suite, _ := filepath.Abs(c.Path)
+		if err := testcase.WriteJUnit(file, filepath.Base(suite), results); err != nil {
+			return err
+		}
+	}
+
+	failed := 0
+	for _, r := range results {
+		if !r.Passed() {
+			failed++
+		}
+	}
+	if failed > 0 || len(results) < len(cases) {
+		return fmt.Errorf("%d of %d test case(s) failed", failed+len(cases)-len(results), len(cases))
+	}
+	logger.Infof("%d test case(s) passed.", len(results))
+	return nil
+}
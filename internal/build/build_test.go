@@ -0,0 +1,189 @@
+package build
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func node(name, os, arch string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			NodeInfo: corev1.NodeSystemInfo{OperatingSystem: os, Architecture: arch},
+		},
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.BaseImage != DefaultBaseImage {
+		t.Errorf("expected default base image, got %q", cfg.BaseImage)
+	}
+
+	content := "baseImage: scratch\nplatforms: [linux/arm64]\nldflags: -s -w\ntags: [netgo]\n"
+	if err := os.WriteFile(filepath.Join(dir, ConfigFileName), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = LoadConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.BaseImage != ScratchImage || cfg.LDFlags != "-s -w" || len(cfg.Platforms) != 1 {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ConfigFileName), []byte("base: scratch\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(dir); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
+
+func TestTargetPlatforms(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	client := fake.NewSimpleClientset(
+		node("a", "linux", "arm64"),
+		node("b", "linux", "amd64"),
+		node("c", "linux", "amd64"),
+	)
+
+	cases := map[string]struct {
+		config Config
+		want   []string
+	}{
+		"FirstNodePlatform": {config: Config{}, want: []string{"linux/amd64"}},
+		"AllNodePlatforms":  {config: Config{MultiArch: true}, want: []string{"linux/amd64", "linux/arm64"}},
+		"Configured":        {config: Config{Platforms: []string{"linux/arm/v7"}}, want: []string{"linux/arm/v7"}},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			platforms, err := tc.config.TargetPlatforms(context.Background(), client, logger)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, p := range platforms {
+				got = append(got, p.String())
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("expected %v, got %v", tc.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRuntimeIndex(t *testing.T) {
+	packageLayer, err := random.Layer(64, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryLayer, err := random.Layer(64, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runtime := Runtime{PackageLayer: packageLayer, Entrypoint: []string{"/function"}, Ports: []string{"9443"}}
+
+	platforms := []v1.Platform{{OS: "linux", Architecture: "amd64"}, {OS: "linux", Architecture: "arm64"}}
+	images := []v1.Image{}
+	for _, platform := range platforms {
+		img, err := runtime.Image(empty.Image, platform, binaryLayer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := img.ConfigFile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Architecture != platform.Architecture || cfg.Config.Entrypoint[0] != "/function" {
+			t.Errorf("unexpected image config: %+v", cfg)
+		}
+		layers, err := img.Layers()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(layers) != 2 {
+			t.Errorf("expected 2 layers, got %d", len(layers))
+		}
+		images = append(images, img)
+	}
+
+	index, err := Index(platforms, images)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Manifests) != 2 || manifest.Manifests[1].Platform.Architecture != "arm64" {
+		t.Errorf("unexpected index manifest: %+v", manifest.Manifests)
+	}
+}
+
+func TestBaseImage(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	u, _ := url.Parse(server.URL)
+	ref := u.Host + "/distroless/static:nonroot"
+	platform := v1.Platform{OS: "linux", Architecture: "amd64"}
+	img, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, _ := name.NewTag(ref)
+	if err := remote.Write(tag, img); err != nil {
+		t.Fatal(err)
+	}
+	digest, _ := img.Digest()
+
+	dir := t.TempDir()
+	if _, err := cachedImage(context.Background(), ref, platform, dir); err != nil {
+		t.Fatal(err)
+	}
+	// Cached image is used without registry
+	server.Close()
+	cached, err := cachedImage(context.Background(), ref, platform, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cachedDigest, _ := cached.Digest(); cachedDigest != digest {
+		t.Errorf("expected cached image %s, got %s", digest, cachedDigest)
+	}
+
+	archive := filepath.Join(dir, "base.tar")
+	if err := tarball.WriteToFile(archive, tag, img); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := BaseImage(context.Background(), archive, platform)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loadedDigest, _ := loaded.Digest(); loadedDigest != digest {
+		t.Errorf("expected image of archive %s, got %s", digest, loadedDigest)
+	}
+}
@@ -0,0 +1,231 @@
+package build
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/web-seven/overlock/internal/loader"
+)
+
+const (
+	// Dockerfile detected in package directory
+	Dockerfile = "Dockerfile"
+	// Environment variable with path of OCI tarball builder command must write
+	OutputEnv = "OVERLOCK_OUTPUT"
+	// Environment variable with target platform of builder command
+	PlatformEnv = "OVERLOCK_PLATFORM"
+)
+
+// Builder builds runtime image of package directory, package layer is
+// appended to the built image afterwards
+type Builder interface {
+	Build(ctx context.Context, dir string, platform v1.Platform) (v1.Image, error)
+}
+
+// DockerBuilder builds Dockerfile of package directory with local Docker daemon
+type DockerBuilder struct {
+	Dockerfile string
+}
+
+// CommandBuilder runs shell command in package directory, command writes
+// image as OCI layout or docker tarball to path from OVERLOCK_OUTPUT
+type CommandBuilder struct {
+	Command string
+}
+
+// ImageBuilder uses existing image reference as runtime image
+type ImageBuilder struct {
+	Ref string
+}
+
+// NewBuilder returns builder configured for package directory, nil builder
+// means package is built as Go module
+func (c *Config) NewBuilder(dir string) Builder {
+	switch {
+	case c.Image != "":
+		return &ImageBuilder{Ref: c.Image}
+	case c.Builder != "":
+		return &CommandBuilder{Command: c.Builder}
+	}
+	dockerfile := c.Dockerfile
+	if dockerfile == "" {
+		dockerfile = Dockerfile
+	}
+	if _, err := os.Stat(filepath.Join(dir, dockerfile)); err == nil {
+		return &DockerBuilder{Dockerfile: dockerfile}
+	}
+	return nil
+}
+
+// BuildImage builds runtime image with builder for target platforms and
+// appends package layer to it. Image index is returned for multi-arch
+// builds only, the image of the first platform is returned in any case.
+func (c *Config) BuildImage(ctx context.Context, client kubernetes.Interface, logger *zap.SugaredLogger, builder Builder, dir string, runtime Runtime) (v1.Image, v1.ImageIndex, error) {
+	platforms, err := c.TargetPlatforms(ctx, client, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	images := []v1.Image{}
+	for _, platform := range platforms {
+		logger.Debugf("Building %s for %s with %T...", dir, platform.String(), builder)
+		img, err := builder.Build(ctx, dir, platform)
+		if err != nil {
+			return nil, nil, err
+		}
+		img, err = runtime.Package(img)
+		if err != nil {
+			return nil, nil, err
+		}
+		images = append(images, img)
+	}
+	if !c.MultiArch {
+		return images[0], nil, nil
+	}
+	index, err := Index(platforms, images)
+	return images[0], index, err
+}
+
+// Build image with docker build and export it with docker save
+func (b *DockerBuilder) Build(ctx context.Context, dir string, platform v1.Platform) (v1.Image, error) {
+	output, err := outputDir(dir, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := "overlock.local/build/" + filepath.Base(output) + ":latest"
+	build := exec.CommandContext(ctx, "docker", "build", "--platform", platform.String(), "-f", filepath.Join(dir, b.Dockerfile), "-t", tag, dir)
+	build.Stdout = os.Stdout
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		return nil, fmt.Errorf("docker build failed: %w", err)
+	}
+
+	archive := filepath.Join(output, "image.tar")
+	save := exec.CommandContext(ctx, "docker", "save", "-o", archive, tag)
+	save.Stderr = os.Stderr
+	if err := save.Run(); err != nil {
+		return nil, fmt.Errorf("docker save failed: %w", err)
+	}
+	return readImage(archive, output, platform)
+}
+
+// Build image by running builder command
+func (b *CommandBuilder) Build(ctx context.Context, dir string, platform v1.Platform) (v1.Image, error) {
+	output, err := outputDir(dir, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := filepath.Join(output, "image.tar")
+	cmd := exec.CommandContext(ctx, "sh", "-c", b.Command)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), OutputEnv+"="+archive, PlatformEnv+"="+platform.String())
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("builder command failed: %w", err)
+	}
+	if _, err := os.Stat(archive); err != nil {
+		return nil, fmt.Errorf("builder command did not write image to $%s: %w", OutputEnv, err)
+	}
+	return readImage(archive, output, platform)
+}
+
+// Pull image reference for platform
+func (b *ImageBuilder) Build(ctx context.Context, dir string, platform v1.Platform) (v1.Image, error) {
+	ref, err := name.ParseReference(b.Ref)
+	if err != nil {
+		return nil, err
+	}
+	img, err := remote.Image(ref,
+		remote.WithContext(ctx),
+		remote.WithPlatform(platform),
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cannot pull image %s: %w", b.Ref, err)
+	}
+	return img, nil
+}
+
+// Prepare empty directory for build output of package directory. Built
+// images are read lazily, so output stays on disk until the next build.
+func outputDir(dir string, platform v1.Platform) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	root, err := os.UserCacheDir()
+	if err != nil {
+		root = os.TempDir()
+	}
+	sum := sha256.Sum256([]byte(abs))
+	output := filepath.Join(root, "overlock", "builds", fmt.Sprintf("%x-%s-%s%s", sum[:6], platform.OS, platform.Architecture, platform.Variant))
+	if err := os.RemoveAll(output); err != nil {
+		return "", err
+	}
+	return output, os.MkdirAll(output, 0o755)
+}
+
+// Read image from docker tarball or from tar archive of OCI layout
+func readImage(archive string, output string, platform v1.Platform) (v1.Image, error) {
+	if img, err := tarball.ImageFromPath(archive, nil); err == nil {
+		if _, err := img.Manifest(); err == nil {
+			return img, nil
+		}
+	}
+
+	dir := filepath.Join(output, "layout")
+	if err := loader.ExtractArchive(archive, dir); err != nil {
+		return nil, err
+	}
+	p, err := layout.FromPath(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%s is neither docker tarball nor OCI layout: %w", archive, err)
+	}
+	index, err := p.ImageIndex()
+	if err != nil {
+		return nil, err
+	}
+	return platformImage(index, platform)
+}
+
+// Find image of platform in index, single image of index is used regardless of its platform
+func platformImage(index v1.ImageIndex, platform v1.Platform) (v1.Image, error) {
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+	if len(manifest.Manifests) == 1 && !manifest.Manifests[0].MediaType.IsIndex() {
+		return index.Image(manifest.Manifests[0].Digest)
+	}
+	for _, desc := range manifest.Manifests {
+		switch {
+		case desc.MediaType.IsIndex():
+			child, err := index.ImageIndex(desc.Digest)
+			if err != nil {
+				return nil, err
+			}
+			if img, err := platformImage(child, platform); err == nil {
+				return img, nil
+			}
+		case desc.Platform != nil && desc.Platform.Satisfies(platform):
+			return index.Image(desc.Digest)
+		}
+	}
+	return nil, fmt.Errorf("OCI layout has no image for %s", platform.String())
+}
@@ -0,0 +1,82 @@
+package build
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+)
+
+func TestNewBuilder(t *testing.T) {
+	dir := t.TempDir()
+	if b := (&Config{}).NewBuilder(dir); b != nil {
+		t.Errorf("expected Go build, got %T", b)
+	}
+	if err := os.WriteFile(filepath.Join(dir, Dockerfile), []byte("FROM scratch\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if b, ok := (&Config{}).NewBuilder(dir).(*DockerBuilder); !ok || b.Dockerfile != Dockerfile {
+		t.Errorf("expected docker builder, got %T", b)
+	}
+	if _, ok := (&Config{Builder: "make image"}).NewBuilder(dir).(*CommandBuilder); !ok {
+		t.Error("expected command builder")
+	}
+	if _, ok := (&Config{Builder: "make image", Image: "python:3"}).NewBuilder(dir).(*ImageBuilder); !ok {
+		t.Error("expected image builder")
+	}
+}
+
+func TestCommandBuilder(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	platform := v1.Platform{OS: "linux", Architecture: "amd64"}
+
+	img, err := random.Image(64, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := t.TempDir()
+	ref, _ := name.ParseReference("example.com/function:latest")
+	if err := tarball.WriteToFile(filepath.Join(src, "docker.tar"), ref, img); err != nil {
+		t.Fatal(err)
+	}
+	oci := filepath.Join(src, "oci")
+	if _, err := layout.Write(oci, mutate.AppendManifests(empty.Index, mutate.IndexAddendum{Add: img})); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"DockerTarball": `cp "` + filepath.Join(src, "docker.tar") + `" "$OVERLOCK_OUTPUT"`,
+		"OCILayout":     `tar -cf "$OVERLOCK_OUTPUT" -C "` + oci + `" .`,
+	}
+	for name, command := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := (&CommandBuilder{Command: command}).Build(context.Background(), t.TempDir(), platform)
+			if err != nil {
+				t.Fatal(err)
+			}
+			digest, err := got.Digest()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if digest != want {
+				t.Errorf("expected %s, got %s", want, digest)
+			}
+		})
+	}
+
+	if _, err := (&CommandBuilder{Command: "true"}).Build(context.Background(), t.TempDir(), platform); err == nil {
+		t.Error("expected error when command writes no image")
+	}
+}
@@ -0,0 +1,122 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// Build configuration file in package directory
+	ConfigFileName = "overlock.build.yaml"
+	// Base image of runtime images when not configured
+	DefaultBaseImage = "gcr.io/distroless/static:nonroot"
+	// Base image value for images without base layers
+	ScratchImage = "scratch"
+)
+
+// Config of runtime image build
+type Config struct {
+	// Base image of runtime image, reference or path of image archive
+	BaseImage string `json:"baseImage,omitempty"`
+	// Target platforms in os/arch[/variant] format, derived from environment nodes when empty
+	Platforms []string `json:"platforms,omitempty"`
+	// Build image index with image for every platform
+	MultiArch bool `json:"multiArch,omitempty"`
+	// Flags passed to go build -ldflags
+	LDFlags string `json:"ldflags,omitempty"`
+	// Build tags passed to go build -tags
+	Tags []string `json:"tags,omitempty"`
+	// Dockerfile used instead of Go build, relative to package directory
+	Dockerfile string `json:"dockerfile,omitempty"`
+	// Command writing runtime image tarball to $OVERLOCK_OUTPUT
+	Builder string `json:"builder,omitempty"`
+	// Existing runtime image reference
+	Image string `json:"image,omitempty"`
+}
+
+// LoadConfig reads build configuration from package directory, defaults
+// are used when configuration file does not exist
+func LoadConfig(dir string) (*Config, error) {
+	cfg := &Config{}
+	content, err := os.ReadFile(filepath.Join(dir, ConfigFileName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err := yaml.UnmarshalStrict(content, cfg); err != nil {
+			return nil, fmt.Errorf("cannot parse %s: %w", ConfigFileName, err)
+		}
+	}
+	if cfg.BaseImage == "" {
+		cfg.BaseImage = DefaultBaseImage
+	}
+	if isPath(cfg.BaseImage) && !filepath.IsAbs(cfg.BaseImage) {
+		cfg.BaseImage = filepath.Join(dir, cfg.BaseImage)
+	}
+	return cfg, nil
+}
+
+// TargetPlatforms returns platforms runtime image is built for. Without
+// configured platforms they are derived from environment nodes, only the
+// first one is used unless multi-arch build is enabled.
+func (c *Config) TargetPlatforms(ctx context.Context, client kubernetes.Interface, logger *zap.SugaredLogger) ([]v1.Platform, error) {
+	platforms := []v1.Platform{}
+	for _, p := range c.Platforms {
+		platform, err := v1.ParsePlatform(p)
+		if err != nil {
+			return nil, err
+		}
+		platforms = append(platforms, *platform)
+	}
+
+	if len(platforms) == 0 && client != nil {
+		nodePlatforms, err := NodePlatforms(ctx, client)
+		if err != nil {
+			logger.Debugf("Cannot detect node platforms: %v", err)
+		}
+		platforms = nodePlatforms
+	}
+	if len(platforms) == 0 {
+		platforms = []v1.Platform{{OS: "linux", Architecture: runtime.GOARCH}}
+	}
+
+	if !c.MultiArch && len(platforms) > 1 {
+		logger.Warnf("Environment runs on %d platforms, image is built only for %s. Set multiArch: true in %s to build all of them.", len(platforms), platforms[0].String(), ConfigFileName)
+		platforms = platforms[:1]
+	}
+	return platforms, nil
+}
+
+// NodePlatforms returns distinct platforms of environment nodes
+func NodePlatforms(ctx context.Context, client kubernetes.Interface) ([]v1.Platform, error) {
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	platforms := []v1.Platform{}
+	for _, node := range nodes.Items {
+		platform := v1.Platform{
+			OS:           node.Status.NodeInfo.OperatingSystem,
+			Architecture: node.Status.NodeInfo.Architecture,
+		}
+		if platform.OS == "" || platform.Architecture == "" || seen[platform.String()] {
+			continue
+		}
+		seen[platform.String()] = true
+		platforms = append(platforms, platform)
+	}
+	sort.Slice(platforms, func(i, j int) bool { return platforms[i].String() < platforms[j].String() })
+	return platforms, nil
+}
@@ -0,0 +1,45 @@
+package build
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// GoArgs returns arguments of go build for module path and output binary
+func (c *Config) GoArgs(module string, output string) []string {
+	args := []string{"build", "-C", module, "-o", output, "-trimpath"}
+	if c.LDFlags != "" {
+		args = append(args, "-ldflags", c.LDFlags)
+	}
+	if len(c.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(c.Tags, ","))
+	}
+	return args
+}
+
+// GoBinary builds static binary of Go module for platform
+func (c *Config) GoBinary(ctx context.Context, module string, binaryName string, platform v1.Platform) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "overlock-build-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	output := filepath.Join(dir, binaryName)
+
+	cmd := exec.CommandContext(ctx, "go", c.GoArgs(module, output)...)
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=0", "GOOS="+platform.OS, "GOARCH="+platform.Architecture)
+	if platform.Architecture == "arm" && strings.HasPrefix(platform.Variant, "v") {
+		cmd.Env = append(cmd.Env, "GOARM="+strings.TrimPrefix(platform.Variant, "v"))
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(output)
+}
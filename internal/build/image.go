@@ -0,0 +1,227 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/web-seven/overlock/internal/image"
+	"github.com/web-seven/overlock/internal/loader"
+)
+
+// Runtime describes container of package runtime image
+type Runtime struct {
+	// Package layer with package.yaml
+	PackageLayer v1.Layer
+	// Entrypoint of runtime image
+	Entrypoint []string
+	// Ports exposed by runtime
+	Ports []string
+}
+
+// Permissions of runtime binaries in image
+const BinaryMode os.FileMode = 0o755
+
+// Annotation of base image cache entries holding reference and platform of image
+const baseImageAnnotation = "org.opencontainers.image.ref.name"
+
+var (
+	baseImages   = map[string]v1.Image{}
+	baseImagesMu sync.Mutex
+)
+
+// BaseImage returns base image for platform. Image archives given by path
+// are loaded from filesystem, pulled images are kept in user cache directory
+// and reused by next builds without reaching registry.
+func BaseImage(ctx context.Context, ref string, platform v1.Platform) (v1.Image, error) {
+	if ref == ScratchImage {
+		return empty.Image, nil
+	}
+	if isPath(ref) {
+		return loader.LoadPathArchive(ref)
+	}
+
+	key := ref + "@" + platform.String()
+	baseImagesMu.Lock()
+	defer baseImagesMu.Unlock()
+	if img, ok := baseImages[key]; ok {
+		return img, nil
+	}
+
+	dir := ""
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		dir = filepath.Join(cacheDir, "overlock", "base-images")
+	}
+	img, err := cachedImage(ctx, ref, platform, dir)
+	if err != nil {
+		return nil, err
+	}
+	baseImages[key] = img
+	return img, nil
+}
+
+// Image of platform from OCI layout in dir, image is pulled and added to layout
+// when missing. Layout is skipped when dir is empty or not writable.
+func cachedImage(ctx context.Context, ref string, platform v1.Platform, dir string) (v1.Image, error) {
+	key := ref + "@" + platform.String()
+	var lp layout.Path
+	if dir != "" {
+		var err error
+		if lp, err = layout.FromPath(dir); err != nil {
+			lp, _ = layout.Write(dir, empty.Index)
+		}
+	}
+	if lp != "" {
+		if index, err := lp.ImageIndex(); err == nil {
+			if manifest, err := index.IndexManifest(); err == nil {
+				for _, desc := range manifest.Manifests {
+					if desc.Annotations[baseImageAnnotation] == key {
+						return index.Image(desc.Digest)
+					}
+				}
+			}
+		}
+	}
+
+	r, err := name.ParseReference(ref)
+	if err != nil {
+		return nil, err
+	}
+	img, err := remote.Image(r,
+		remote.WithContext(ctx),
+		remote.WithPlatform(platform),
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cannot pull base image %s for %s: %w", ref, platform.String(), err)
+	}
+	if lp == "" {
+		return img, nil
+	}
+	if err := lp.AppendImage(img, layout.WithAnnotations(map[string]string{baseImageAnnotation: key})); err != nil {
+		return img, nil
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		return nil, err
+	}
+	return lp.Image(digest)
+}
+
+// Base image references starting with ./, ../ or / are paths of image archives
+func isPath(ref string) bool {
+	return strings.HasPrefix(ref, "./") || strings.HasPrefix(ref, "../") || filepath.IsAbs(ref)
+}
+
+// Image assembles runtime image of platform from base image, package layer and extra layers
+func (r *Runtime) Image(base v1.Image, platform v1.Platform, layers ...v1.Layer) (v1.Image, error) {
+	cfg, err := base.ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	cfg = cfg.DeepCopy()
+	cfg.OS = platform.OS
+	cfg.Architecture = platform.Architecture
+	cfg.Variant = platform.Variant
+	cfg.Config.WorkingDir = "/"
+	cfg.Config.ArgsEscaped = true
+	cfg.Config.Entrypoint = r.Entrypoint
+	cfg.Config.Cmd = nil
+	cfg.Config.ExposedPorts = map[string]struct{}{}
+	for _, port := range r.Ports {
+		cfg.Config.ExposedPorts[port] = struct{}{}
+	}
+
+	img, err := mutate.ConfigFile(base, cfg)
+	if err != nil {
+		return nil, err
+	}
+	img, err = r.Package(img)
+	if err != nil {
+		return nil, err
+	}
+
+	addenda := []mutate.Addendum{}
+	for _, layer := range layers {
+		addenda = append(addenda, mutate.Addendum{Layer: layer})
+	}
+	return mutate.Append(img, addenda...)
+}
+
+// Package appends package layer to runtime image
+func (r *Runtime) Package(img v1.Image) (v1.Image, error) {
+	return mutate.Append(img, mutate.Addendum{
+		Layer:       r.PackageLayer,
+		Annotations: map[string]string{image.AnnotationKey: image.AnnotationBase},
+	})
+}
+
+// Index combines images into multi-arch image index, images are ordered as platforms
+func Index(platforms []v1.Platform, images []v1.Image) (v1.ImageIndex, error) {
+	if len(platforms) != len(images) {
+		return nil, fmt.Errorf("got %d images for %d platforms", len(images), len(platforms))
+	}
+	index := mutate.IndexMediaType(empty.Index, types.OCIImageIndex)
+	for i, img := range images {
+		platform := platforms[i]
+		index = mutate.AppendManifests(index, mutate.IndexAddendum{
+			Add: img,
+			Descriptor: v1.Descriptor{
+				Platform: &platform,
+			},
+		})
+	}
+	return index, nil
+}
+
+// GoImage builds Go module for every target platform and assembles runtime
+// images of them. Image index is returned for multi-arch builds only, the
+// image of the first platform is returned in any case.
+func (c *Config) GoImage(ctx context.Context, client kubernetes.Interface, logger *zap.SugaredLogger, module string, binaryName string, runtime Runtime) (v1.Image, v1.ImageIndex, error) {
+	platforms, err := c.TargetPlatforms(ctx, client, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	images := []v1.Image{}
+	for _, platform := range platforms {
+		logger.Debugf("Building %s for %s...", binaryName, platform.String())
+		content, err := c.GoBinary(ctx, module, binaryName, platform)
+		if err != nil {
+			return nil, nil, err
+		}
+		layer, err := image.LoadBinaryLayer(content, binaryName, BinaryMode)
+		if err != nil {
+			return nil, nil, err
+		}
+		base, err := BaseImage(ctx, c.BaseImage, platform)
+		if err != nil {
+			return nil, nil, err
+		}
+		img, err := runtime.Image(base, platform, layer)
+		if err != nil {
+			return nil, nil, err
+		}
+		images = append(images, img)
+	}
+
+	if !c.MultiArch {
+		return images[0], nil, nil
+	}
+	index, err := Index(platforms, images)
+	return images[0], index, err
+}
@@ -0,0 +1,66 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// Time given to local process to stop after interrupt
+const localStopTimeout = 5 * time.Second
+
+// LocalProcess is binary built for host running until its context is cancelled
+type LocalProcess struct {
+	Binary string
+	Args   []string
+	// Additional environment variables in K=V format
+	Env []string
+	// Run binary with delve headless server listening on address
+	Delve string
+}
+
+// LocalBinary builds Go module for host, optimizations are disabled for debugger
+func (c *Config) LocalBinary(ctx context.Context, module string, output string, debug bool) error {
+	args := c.GoArgs(module, output)
+	if debug {
+		args = append(args, "-gcflags", "all=-N -l")
+	}
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("go build failed: %w", err)
+	}
+	return nil
+}
+
+// Run process until ctx is cancelled, exit of process before is an error
+func (p *LocalProcess) Run(ctx context.Context) error {
+	var cmd *exec.Cmd
+	if p.Delve != "" {
+		args := []string{"exec", p.Binary, "--headless", "--listen", p.Delve, "--api-version", "2", "--accept-multiclient", "--continue"}
+		if len(p.Args) > 0 {
+			args = append(append(args, "--"), p.Args...)
+		}
+		cmd = exec.CommandContext(ctx, "dlv", args...)
+	} else {
+		cmd = exec.CommandContext(ctx, p.Binary, p.Args...)
+	}
+	cmd.Env = append(os.Environ(), p.Env...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Cancel = func() error { return cmd.Process.Signal(syscall.SIGTERM) }
+	cmd.WaitDelay = localStopTimeout
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s exited: %w", p.Binary, err)
+	}
+	return fmt.Errorf("%s exited", p.Binary)
+}
@@ -9,6 +9,7 @@ import (
 	v1 "github.com/crossplane/crossplane/apis/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/pkg/errors"
+	"helm.sh/helm/v3/pkg/storage/driver"
 	"k8s.io/client-go/rest"
 
 	"github.com/web-seven/overlock/internal/install"
@@ -100,6 +101,53 @@ func InstallEngine(ctx context.Context, configClient *rest.Config, params map[st
 	return engine.Install(Version, params)
 }
 
+// ReplaceReleasePackage replaces package source in packages of engine
+// release values under key (provider, configuration or function) and
+// upgrades release, reports false if package is not installed with release
+func ReplaceReleasePackage(ctx context.Context, configClient *rest.Config, key string, source string, target string, logger *zap.SugaredLogger) (bool, error) {
+	installer, err := GetEngine(configClient)
+	if err != nil {
+		return false, err
+	}
+	release, err := installer.GetRelease()
+	if errors.Is(err, driver.ErrReleaseNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !replacePackage(release.Config, key, source, target) {
+		return false, nil
+	}
+
+	version, err := installer.GetCurrentVersion()
+	if err != nil {
+		return false, err
+	}
+	logger.Debugf("Updating %s in engine release", source)
+	return true, installer.Upgrade(version, release.Config)
+}
+
+// Replace package source in packages of release values under key
+func replacePackage(params map[string]any, key string, source string, target string) bool {
+	values, ok := params[key].(map[string]any)
+	if !ok {
+		return false
+	}
+	packages, ok := values["packages"].([]any)
+	if !ok {
+		return false
+	}
+	replaced := false
+	for i, p := range packages {
+		if pstr, ok := p.(string); ok && pstr == source {
+			packages[i] = target
+			replaced = true
+		}
+	}
+	return replaced
+}
+
 // Verify if Crossplane API exists
 func VerifyApi(ctx context.Context, configClient *rest.Config, apiName string) (bool, error) {
 	crdClientSet, err := clientset.NewForConfig(configClient)
@@ -149,8 +197,8 @@ func ManagedSelector(m map[string]string) string {
 	return strings.Join(selectors, ",")
 }
 
-func BuildPack(pack v1.Package, img string, pkgMap map[string]string) error {
-	ref, err := name.ParseReference(img, name.WithDefaultRegistry(""))
+func BuildPack(pack v1.Package, img string, pkgMap map[string]string, routes ...Route) error {
+	ref, err := name.ParseReference(ExpandPackage(img, routes), name.WithDefaultRegistry(""))
 	if err != nil {
 		return errors.Wrap(err, errParsePackageName)
 	}
@@ -163,6 +211,15 @@ func BuildPack(pack v1.Package, img string, pkgMap map[string]string) error {
 	return nil
 }
 
+// PackageName returns name of package object built by BuildPack for image
+func PackageName(img string, routes ...Route) (string, error) {
+	ref, err := name.ParseReference(ExpandPackage(img, routes), name.WithDefaultRegistry(""))
+	if err != nil {
+		return "", errors.Wrap(err, errParsePackageName)
+	}
+	return ToDNSLabel(ref.Context().RepositoryStr()), nil
+}
+
 // ToDNSLabel converts the string to a valid DNS label.
 func ToDNSLabel(s string) string {
 	var cut strings.Builder
@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReplacePackage(t *testing.T) {
+	cases := map[string]struct {
+		params   map[string]any
+		want     bool
+		packages []any
+	}{
+		"Installed": {
+			params: map[string]any{
+				"provider": map[string]any{
+					"packages": []any{"xpkg.upbound.io/acme/provider-foo:v1.0.0", "xpkg.upbound.io/acme/provider-bar:v1.0.0"},
+				},
+			},
+			want:     true,
+			packages: []any{"xpkg.upbound.io/acme/provider-foo:v1.1.0", "xpkg.upbound.io/acme/provider-bar:v1.0.0"},
+		},
+		"NotInstalled": {
+			params: map[string]any{
+				"provider": map[string]any{
+					"packages": []any{"xpkg.upbound.io/acme/provider-bar:v1.0.0"},
+				},
+			},
+			want:     false,
+			packages: []any{"xpkg.upbound.io/acme/provider-bar:v1.0.0"},
+		},
+		"NoPackages": {
+			params: map[string]any{},
+			want:   false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := replacePackage(tc.params, "provider", "xpkg.upbound.io/acme/provider-foo:v1.0.0", "xpkg.upbound.io/acme/provider-foo:v1.1.0")
+			if got != tc.want {
+				t.Fatalf("replacePackage() = %v, want %v", got, tc.want)
+			}
+			if tc.packages == nil {
+				return
+			}
+			packages := tc.params["provider"].(map[string]any)["packages"]
+			if !reflect.DeepEqual(packages, tc.packages) {
+				t.Errorf("packages = %v, want %v", packages, tc.packages)
+			}
+		})
+	}
+}
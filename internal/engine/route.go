@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Route maps short package names matching pattern (e.g. `acme/*`)
+// to repository prefix of registry (e.g. `ghcr.io/acme`)
+type Route struct {
+	Pattern string `json:"pattern"`
+	Target  string `json:"target"`
+}
+
+// Prefix of package name matched by route pattern
+func (r Route) Prefix() string {
+	return strings.TrimSuffix(strings.TrimSuffix(r.Pattern, "*"), "/")
+}
+
+// Expand short package name through routes, the route with longest matching
+// prefix wins. Fully qualified names and names without matching route are returned as is.
+func ExpandPackage(img string, routes []Route) string {
+	if hasRegistryHost(img) {
+		return img
+	}
+	matched := -1
+	for i, route := range routes {
+		prefix := route.Prefix()
+		if prefix == "" || !strings.HasPrefix(img, prefix+"/") {
+			continue
+		}
+		if matched < 0 || len(prefix) > len(routes[matched].Prefix()) {
+			matched = i
+		}
+	}
+	if matched < 0 {
+		return img
+	}
+	route := routes[matched]
+	return strings.TrimSuffix(route.Target, "/") + strings.TrimPrefix(img, route.Prefix())
+}
+
+// Check if first segment of package name is a registry host
+func hasRegistryHost(img string) bool {
+	first, _, found := strings.Cut(img, "/")
+	if !found {
+		return false
+	}
+	return strings.ContainsAny(first, ".:") || first == "localhost"
+}
+
+// Parse route from `pattern=target` format
+func ParseRoute(s string) (Route, error) {
+	pattern, target, found := strings.Cut(s, "=")
+	route := Route{Pattern: strings.TrimSpace(pattern), Target: strings.TrimSpace(target)}
+	if !found || route.Prefix() == "" || route.Target == "" {
+		return Route{}, fmt.Errorf("route %q is not in pattern=target format", s)
+	}
+	// Prefix drops only trailing /*, other wildcards would be matched literally
+	prefix := route.Prefix()
+	if strings.ContainsAny(prefix, "*?[]") || strings.HasPrefix(prefix, "/") || strings.Contains(prefix, "//") ||
+		strings.HasSuffix(route.Pattern, "*") && !strings.HasSuffix(route.Pattern, "/*") {
+		return Route{}, fmt.Errorf("route pattern %q must be package path prefix, e.g. acme/*", route.Pattern)
+	}
+	if !hasRegistryHost(route.Target + "/") {
+		return Route{}, fmt.Errorf("route target %q must start with registry host", route.Target)
+	}
+	return route, nil
+}
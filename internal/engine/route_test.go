@@ -0,0 +1,70 @@
+package engine
+
+import "testing"
+
+func TestExpandPackage(t *testing.T) {
+	routes := []Route{
+		{Pattern: "acme/*", Target: "ghcr.io/acme"},
+		{Pattern: "acme/internal/*", Target: "registry.acme.io/platform/"},
+		{Pattern: "crossplane-contrib/*", Target: "xpkg.upbound.io/crossplane-contrib"},
+	}
+
+	cases := map[string]struct {
+		img  string
+		want string
+	}{
+		"ShortName": {
+			img:  "acme/provider-foo:v1.0.0",
+			want: "ghcr.io/acme/provider-foo:v1.0.0",
+		},
+		"LongestPrefix": {
+			img:  "acme/internal/configuration-bar:v2",
+			want: "registry.acme.io/platform/configuration-bar:v2",
+		},
+		"FullyQualified": {
+			img:  "ghcr.io/acme/provider-foo:v1.0.0",
+			want: "ghcr.io/acme/provider-foo:v1.0.0",
+		},
+		"LocalhostHost": {
+			img:  "localhost/acme/provider-foo",
+			want: "localhost/acme/provider-foo",
+		},
+		"NoMatch": {
+			img:  "other/provider-foo:v1",
+			want: "other/provider-foo:v1",
+		},
+		"PrefixIsNotSegment": {
+			img:  "acmecorp/provider-foo:v1",
+			want: "acmecorp/provider-foo:v1",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := ExpandPackage(tc.img, routes); got != tc.want {
+				t.Errorf("ExpandPackage(%q) = %q, want %q", tc.img, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseRoute(t *testing.T) {
+	for _, s := range []string{"acme=ghcr.io/acme", "acme/team/=ghcr.io/acme"} {
+		if _, err := ParseRoute(s); err != nil {
+			t.Errorf("unexpected error for %q: %v", s, err)
+		}
+	}
+	route, err := ParseRoute("acme/*=ghcr.io/acme")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if route.Pattern != "acme/*" || route.Target != "ghcr.io/acme" {
+		t.Errorf("unexpected route %+v", route)
+	}
+
+	for _, s := range []string{"acme/*", "=ghcr.io/acme", "acme/*=acme", "acme*=ghcr.io/acme", "acme/*/x=ghcr.io/acme", "*/acme=ghcr.io/acme", "acme/?=ghcr.io/acme"} {
+		if _, err := ParseRoute(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
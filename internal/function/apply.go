@@ -13,47 +13,32 @@ import (
 	"k8s.io/client-go/rest"
 
 	"github.com/web-seven/overlock/internal/engine"
+	"github.com/web-seven/overlock/internal/packages"
+	"github.com/web-seven/overlock/pkg/registry"
 
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	pkgv1 "github.com/crossplane/crossplane/apis/pkg/v1"
 	crossv1 "github.com/crossplane/crossplane/apis/pkg/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const apiName = "functions.pkg.crossplane.io"
 
-// RunFunctionHealthCheck performs a health check on functions defined by the links string.
-func HealthCheck(ctx context.Context, dc dynamic.Interface, links string, wait bool, timeoutChan <-chan time.Time, logger *zap.SugaredLogger) error {
-	linkSet := make(map[string]struct{})
-	for _, link := range strings.Split(links, ",") {
-		linkSet[link] = struct{}{}
+// HealthCheck waits until functions applied for links are healthy, zero
+// timeout waits without limit
+func HealthCheck(ctx context.Context, dc dynamic.Interface, config *rest.Config, links string, timeout time.Duration, logger *zap.SugaredLogger) error {
+	names, err := registry.PackageNames(ctx, config, strings.Split(links, ","))
+	if err != nil {
+		return err
 	}
-	cfgs := GetFunctions(ctx, dc)
-
-	for {
-		select {
-		case <-timeoutChan:
-			logger.Error("Timeout reached.")
-			return nil
-		default:
-			allHealthy := true
-			for _, cfg := range cfgs {
-				if _, linkMatched := linkSet[cfg.Spec.Package]; linkMatched {
-					if !CheckHealthStatus(cfg.Status.Conditions) {
-						allHealthy = false
-						break
-					}
-				}
-			}
-			if allHealthy {
-				logger.Info("Function(s) are healthy.")
-				return nil
-			}
-			time.Sleep(5 * time.Second)
-		}
+	if err := packages.WaitHealthy(ctx, dc, packages.Functions, names, timeout, logger); err != nil {
+		return err
 	}
+	logger.Info("Function(s) are healthy.")
+	return nil
 }
 
-func ApplyFunction(ctx context.Context, links string, config *rest.Config, logger *zap.SugaredLogger) error {
+func (c *Function) Apply(ctx context.Context, config *rest.Config, logger *zap.SugaredLogger) error {
 	_, err := engine.VerifyApi(ctx, config, apiName)
 	if err != nil {
 		logger.Debug(err)
@@ -62,33 +47,13 @@ func ApplyFunction(ctx context.Context, links string, config *rest.Config, logge
 		return nil
 	}
 
-	scheme := runtime.NewScheme()
-	crossv1.AddToScheme(scheme)
-	if kube, err := client.New(config, client.Options{Scheme: scheme}); err == nil {
-		for _, link := range strings.Split(links, ",") {
-			cfg := &crossv1.Function{}
-			engine.BuildPack(cfg, link, map[string]string{})
-			pa := resource.NewAPIPatchingApplicator(kube)
-
-			if err := pa.Apply(ctx, cfg); err != nil {
-				return errors.Wrap(err, "error applying function(s)")
-			}
-		}
-	} else {
+	routes, err := registry.PackageRoutes(ctx, config)
+	if err != nil {
 		return err
 	}
-
-	logger.Info("Function(s) applied successfully.")
-	return nil
-}
-
-func (c *Function) Apply(ctx context.Context, config *rest.Config, logger *zap.SugaredLogger) error {
-	_, err := engine.VerifyApi(ctx, config, apiName)
+	verifier, err := registry.NewPackageVerifier(config, logger)
 	if err != nil {
-		logger.Debug(err)
-		logger.Infoln("Crossplane not installed in current context.")
-		logger.Infoln("Function not applied.")
-		return nil
+		return err
 	}
 
 	scheme := runtime.NewScheme()
@@ -97,7 +62,15 @@ func (c *Function) Apply(ctx context.Context, config *rest.Config, logger *zap.S
 		for _, link := range strings.Split(c.Name, ",") {
 			cfg := &crossv1.Function{}
 			logger.Debugf("Building package %s", link)
-			engine.BuildPack(cfg, link, map[string]string{})
+			engine.BuildPack(cfg, link, map[string]string{}, routes...)
+			if c.RuntimeConfig != "" {
+				cfg.SetRuntimeConfigRef(&pkgv1.RuntimeConfigReference{Name: c.RuntimeConfig})
+			}
+			source, err := verifier.VerifyPackage(ctx, cfg.GetSource())
+			if err != nil {
+				return err
+			}
+			cfg.SetSource(source)
 			pa := resource.NewAPIPatchingApplicator(kube)
 
 			if err := pa.Apply(ctx, cfg); err != nil {
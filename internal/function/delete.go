@@ -2,27 +2,89 @@ package function
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"strings"
 
-	crossv1 "github.com/crossplane/crossplane/apis/pkg/v1beta1"
+	xpv1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
 	"go.uber.org/zap"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
 
-	"github.com/web-seven/overlock/internal/engine"
+	"github.com/web-seven/overlock/internal/runtimeconfig"
+	"github.com/web-seven/overlock/pkg/registry"
 )
 
-func DeleteFunction(ctx context.Context, urls string, dynamicClient *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
-	for _, url := range strings.Split(urls, ",") {
-		cfg := crossv1.Function{}
-		engine.BuildPack(&cfg, url, map[string]string{})
+var compositions = schema.GroupVersionResource{Group: "apiextensions.crossplane.io", Version: "v1", Resource: "compositions"}
 
-		err := dynamicClient.Resource(ResourceId()).Namespace("").Delete(ctx, cfg.GetName(), metav1.DeleteOptions{})
+// DeleteOptions of function deletion
+type DeleteOptions struct {
+	// Delete runtime config of function if no other package uses it
+	PruneRuntimeConfig bool
+}
+
+func DeleteFunction(ctx context.Context, config *rest.Config, urls string, dynamicClient dynamic.Interface, options DeleteOptions, logger *zap.SugaredLogger) error {
+	names, err := registry.PackageNames(ctx, config, strings.Split(urls, ","))
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		fnc, err := dynamicClient.Resource(ResourceId()).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		users, err := CompositionsUsing(ctx, dynamicClient, name)
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			logger.Warnf("Composition %s references function %s, its composite resources will fail to reconcile.", user, name)
+		}
+
+		err = dynamicClient.Resource(ResourceId()).Namespace("").Delete(ctx, name, metav1.DeleteOptions{})
 		if err != nil {
 			return err
 		}
+
+		if options.PruneRuntimeConfig {
+			rcName, _, _ := unstructured.NestedString(fnc.Object, "spec", "runtimeConfigRef", "name")
+			if err := runtimeconfig.Prune(ctx, dynamicClient, rcName, "Function/"+name, logger); err != nil {
+				return err
+			}
+		}
 	}
 
 	logger.Info("Function(s) removed successfully.")
 	return nil
 }
+
+// CompositionsUsing lists compositions with pipeline steps calling function,
+// as composition (step)
+func CompositionsUsing(ctx context.Context, dc dynamic.Interface, fncName string) ([]string, error) {
+	list, err := dc.Resource(compositions).List(ctx, metav1.ListOptions{})
+	if kerrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	users := []string{}
+	for _, u := range list.Items {
+		comp := xpv1.Composition{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &comp); err != nil {
+			return nil, fmt.Errorf("cannot parse composition %s: %w", u.GetName(), err)
+		}
+		for _, step := range comp.Spec.Pipeline {
+			if step.FunctionRef.Name == fncName {
+				users = append(users, fmt.Sprintf("%s (%s)", comp.GetName(), step.Step))
+			}
+		}
+	}
+	sort.Strings(users)
+	return users, nil
+}
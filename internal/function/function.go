@@ -3,7 +3,7 @@ package function
 import (
 	"context"
 
-	condition "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/empty"
 
 	"go.uber.org/zap"
@@ -25,6 +25,15 @@ const (
 type Function struct {
 	Name  string
 	Image image.Image
+	Index v1.ImageIndex
+	// Command building runtime image instead of Go build
+	Builder string
+	// Existing runtime image used instead of build
+	RuntimeImage string
+	// Load directory without linting it
+	SkipLint bool
+	// DeploymentRuntimeConfig referenced by applied function
+	RuntimeConfig string
 	packages.Package
 }
 
@@ -35,14 +44,9 @@ func New(name string) *Function {
 	}
 }
 
-func CheckHealthStatus(status []condition.Condition) bool {
-	healthStatus := false
-	for _, condition := range status {
-		if condition.Type == "Healthy" && condition.Status == "True" {
-			healthStatus = true
-		}
-	}
-	return healthStatus
+func (f *Function) WithRuntimeConfig(name string) *Function {
+	f.RuntimeConfig = name
+	return f
 }
 
 func GetFunction(ctx context.Context, logger *zap.SugaredLogger, sourceDynamicClient dynamic.Interface, paramsFunction kube.ResourceParams) ([]unstructured.Unstructured, error) {
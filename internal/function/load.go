@@ -6,25 +6,25 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
+	"path/filepath"
 	"strings"
 
-	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"go.uber.org/zap"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 
+	"github.com/web-seven/overlock/internal/build"
 	"github.com/web-seven/overlock/internal/image"
 	"github.com/web-seven/overlock/internal/kube"
+	"github.com/web-seven/overlock/internal/lint"
 	"github.com/web-seven/overlock/internal/packages"
 	"github.com/web-seven/overlock/pkg/registry"
 )
 
 const (
-	tagDelim                     = ":"
-	regRepoDelimiter             = "/"
-	functionFileName             = "function"
-	fileMode         os.FileMode = 0o777
+	tagDelim         = ":"
+	regRepoDelimiter = "/"
+	functionFileName = "function"
 )
 
 func (c *Function) UpgradeFunction(ctx context.Context, config *rest.Config, dc *dynamic.DynamicClient) error {
@@ -63,78 +63,84 @@ func (c *Function) LoadStdinArchive(stream *bufio.Reader) error {
 
 // Load function package from directory
 func (c *Function) LoadDirectory(ctx context.Context, config *rest.Config, logger *zap.SugaredLogger, path string) error {
-	logger.Debug("Building function...")
-	fileContent, err := c.build(path)
-	if err != nil {
-		logger.Infof("Error function build: %v", err)
+	if err := c.lint(path, logger); err != nil {
+		return err
+	}
+	if err := c.buildDirectory(ctx, config, logger, path); err != nil {
+		return err
 	}
+	return c.load(ctx, config, logger)
+}
 
-	logger.Debug("Loading function binaries...")
-	functionLayer, err := image.LoadBinaryLayer(fileContent, functionFileName, fileMode)
+// Build function image from directory
+func (c *Function) buildDirectory(ctx context.Context, config *rest.Config, logger *zap.SugaredLogger, path string) error {
+	cfg, err := c.buildConfig(path)
 	if err != nil {
 		return err
 	}
 
 	logger.Debug("Loading function package...")
-	packageLayer, err := image.LoadPackageLayerDirectory(ctx, config, fmt.Sprintf("%s/%s", strings.TrimRight(path, "/"), packages.PackagePath), []string{"Function", "CustomResourceDefinition"})
+	packageLayer, err := image.LoadPackageLayerDirectory(ctx, config, fmt.Sprintf("%s/%s", strings.TrimRight(path, "/"), packages.PackagePath), lint.PackageKinds[lint.KindFunction])
 	if err != nil {
 		return err
 	}
 	logger.Debug("Function package loaded.")
-	cfg, err := c.Image.ConfigFile()
+
+	client, err := kube.Client(config)
 	if err != nil {
 		return err
 	}
-	cfg = cfg.DeepCopy()
-	cfg.Config.WorkingDir = "/"
-	cfg.Config.ArgsEscaped = true
-	cfg.Config.Entrypoint = []string{
-		"/function",
-	}
-	cfg.Config.ExposedPorts = map[string]struct{}{
-		"9443": {},
-	}
-	logger.Debug("Update image configuration...")
-	c.Image.Image, err = mutate.ConfigFile(c.Image.Image, cfg)
-	if err != nil {
-		return err
+
+	if builder := cfg.NewBuilder(path); builder != nil {
+		c.Image.Image, c.Index, err = cfg.BuildImage(ctx, client, logger, builder, path, build.Runtime{PackageLayer: packageLayer})
+		if err != nil {
+			return fmt.Errorf("function build failed: %w", err)
+		}
+		return nil
 	}
 
-	c.Image.Image, err = mutate.Append(c.Image.Image,
-		mutate.Addendum{
-			Layer: packageLayer,
-		},
-		mutate.Addendum{
-			Layer: functionLayer,
-		},
-	)
+	logger.Debugf("Building function on %s...", cfg.BaseImage)
+	c.Image.Image, c.Index, err = cfg.GoImage(ctx, client, logger, path, functionFileName, build.Runtime{
+		PackageLayer: packageLayer,
+		Entrypoint:   []string{"/" + functionFileName},
+		Ports:        []string{"9443"},
+	})
 	if err != nil {
-		return err
+		return fmt.Errorf("function build failed: %w", err)
 	}
-	return c.load(ctx, config, logger)
+	return nil
 }
 
-// Build Go module
-func (c *Function) build(path string) ([]byte, error) {
-	args := []string{
-		"build", "-C", path, "-o", functionFileName,
+// Lint function package directory unless linting is skipped
+func (c *Function) lint(path string, logger *zap.SugaredLogger) error {
+	if c.SkipLint {
+		return nil
 	}
-	cmd := exec.Command("go", args...)
-	cmd.Env = os.Environ()
-	cmd.Env = append(cmd.Env, "CGO_ENABLED=0")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	return lint.Check(filepath.Join(path, packages.PackagePath), lint.KindFunction, logger)
+}
 
-	err := cmd.Run()
+// MultiArch reports whether function directory is built as multi-arch image
+func (c *Function) MultiArch(path string) (bool, error) {
+	cfg, err := c.buildConfig(path)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
+	return cfg.MultiArch, nil
+}
 
-	fileContent, err := os.ReadFile(fmt.Sprintf("%s/%s", strings.TrimRight(path, "/"), functionFileName))
+// Build configuration of directory with builder and image overrides of function
+func (c *Function) buildConfig(path string) (*build.Config, error) {
+	cfg, err := build.LoadConfig(path)
 	if err != nil {
 		return nil, err
 	}
-	return fileContent, nil
+	if c.Builder != "" {
+		cfg.Builder = c.Builder
+	}
+	if c.RuntimeImage != "" {
+		cfg.Image = c.RuntimeImage
+	}
+	return cfg, nil
 }
 
 // Load function to registry
@@ -153,7 +159,11 @@ func (c *Function) load(ctx context.Context, config *rest.Config, logger *zap.Su
 		}
 	}
 
-	err = registry.PushLocalRegistry(ctx, c.Name, c.Image, config, logger)
+	if c.Index != nil {
+		err = registry.PushLocalRegistryIndex(ctx, c.Name, c.Index, config, logger)
+	} else {
+		err = registry.PushLocalRegistry(ctx, c.Name, c.Image, config, logger)
+	}
 	if err != nil {
 		return err
 	}
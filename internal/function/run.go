@@ -0,0 +1,75 @@
+package function
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	cmv1beta1 "github.com/crossplane/crossplane/apis/pkg/meta/v1beta1"
+	"go.uber.org/zap"
+
+	"github.com/web-seven/overlock/internal/build"
+	"github.com/web-seven/overlock/internal/packages"
+	"github.com/web-seven/overlock/internal/watcher"
+)
+
+// Address local function listens on by default, the default target of Development render runtime
+const DefaultRunAddress = "localhost:9443"
+
+// RunOptions of function running locally
+type RunOptions struct {
+	// Address of gRPC server of function
+	Address string
+	// Additional arguments of function process
+	Args []string
+	// Run function with delve headless server listening on address
+	Delve string
+}
+
+// Run function from source directory as local gRPC server in insecure
+// development mode. Function is rebuilt and restarted on changes.
+func Run(ctx context.Context, logger *zap.SugaredLogger, path string, options RunOptions, watchOptions watcher.Options) error {
+	meta := &cmv1beta1.Function{}
+	if err := packages.ReadMeta(filepath.Join(path, packages.PackagePath), "Function", meta); err != nil {
+		return err
+	}
+	cfg, err := build.LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	if cfg.NewBuilder(path) != nil {
+		return fmt.Errorf("function %s is not built as Go module, render it with its runtime image instead", meta.GetName())
+	}
+	if options.Address == "" {
+		options.Address = DefaultRunAddress
+	}
+
+	dir, err := os.MkdirTemp("", "overlock-run-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	binary := filepath.Join(dir, functionFileName)
+
+	watchOptions.Extensions = []string{".yaml", ".go"}
+	w := watcher.New(path, logger, watchOptions)
+	return w.Run(ctx, func(ctx context.Context, cycle *watcher.Cycle) error {
+		err := cycle.Step("build", func() error {
+			return cfg.LocalBinary(ctx, path, binary, options.Delve != "")
+		})
+		if err != nil {
+			return err
+		}
+		cycle.Follow(func(ctx context.Context) error {
+			fmt.Printf("──── cycle #%d: %s listening on %s ────\n", cycle.Number, meta.GetName(), options.Address)
+			process := &build.LocalProcess{
+				Binary: binary,
+				Args:   append([]string{"--insecure", "--address=" + options.Address}, options.Args...),
+				Delve:  options.Delve,
+			}
+			return process.Run(ctx)
+		})
+		return nil
+	})
+}
@@ -4,102 +4,82 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"path/filepath"
 
 	cmv1beta1 "github.com/crossplane/crossplane/apis/pkg/meta/v1beta1"
-	"github.com/rjeczalik/notify"
 	"go.uber.org/zap"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
-	"sigs.k8s.io/yaml"
 
+	"github.com/web-seven/overlock/internal/kube"
 	"github.com/web-seven/overlock/internal/packages"
+	"github.com/web-seven/overlock/internal/watcher"
 )
 
-func Serve(ctx context.Context, dc *dynamic.DynamicClient, config *rest.Config, logger *zap.SugaredLogger, path string) error {
+// Serve function directory, fnc holds builder options of served function
+func Serve(ctx context.Context, dc *dynamic.DynamicClient, config *rest.Config, logger *zap.SugaredLogger, path string, fnc *Function, options watcher.Options) error {
 	logger.Infof("Started serve path: %s", path)
 
-	loadServed(ctx, dc, config, logger, path)
-
-	c := make(chan notify.EventInfo, 1)
-
-	if err := notify.Watch(fmt.Sprintf("%s/%s", path, "..."), c, notify.Create, notify.Write, notify.Rename, notify.Remove); err != nil {
+	cfg, err := fnc.buildConfig(path)
+	if err != nil {
 		return err
 	}
-	defer notify.Stop(c)
-
-	go func() {
-		for {
-			select {
-			case ev := <-c:
-				fileExt := filepath.Ext(ev.Path())
-				if fileExt == ".yaml" || fileExt == ".go" {
-					logger.Debugf("Changed file: %s", ev)
-					loadServed(ctx, dc, config, logger, path)
-				}
-			case <-ctx.Done():
-				return
-			}
-		}
-	}()
-
-	<-make(chan struct{})
-	return nil
+	if cfg.NewBuilder(path) == nil {
+		options.Extensions = []string{".yaml", ".go"}
+	}
+	w := watcher.New(path, logger, options)
+	return w.Run(ctx, func(ctx context.Context, cycle *watcher.Cycle) error {
+		return loadServed(ctx, cycle, dc, config, logger, path, fnc)
+	})
 }
 
-func loadServed(ctx context.Context, dc *dynamic.DynamicClient, config *rest.Config, logger *zap.SugaredLogger, path string) {
-	packagePath := fmt.Sprintf("%s/%s", path, packages.PackagePath)
-	packFiles, err := os.ReadDir(packagePath)
-	if err != nil {
-		logger.Error(err)
+// Build and load served function into k8s context
+func loadServed(ctx context.Context, cycle *watcher.Cycle, dc *dynamic.DynamicClient, config *rest.Config, logger *zap.SugaredLogger, path string, served *Function) error {
+	cfnc := &cmv1beta1.Function{}
+	if err := packages.ReadMeta(fmt.Sprintf("%s/%s", path, packages.PackagePath), "Function", cfnc); err != nil {
+		return err
 	}
+	fnc := New(fmt.Sprintf("%s:0.0.0", cfnc.GetName()))
+	fnc.Builder = served.Builder
+	fnc.RuntimeImage = served.RuntimeImage
+	fnc.SkipLint = served.SkipLint
 
-	for _, e := range packFiles {
-		if e.Type().IsRegular() {
-			if filepath.Ext(e.Name()) != ".yaml" {
-				continue
-			}
+	if err := cycle.Step("lint", func() error { return fnc.lint(path, logger) }); err != nil {
+		return err
+	}
 
-			res := &metav1.TypeMeta{}
-			yamlFile, err := os.ReadFile(fmt.Sprintf("%s/%s", packagePath, e.Name()))
-			if err != nil {
-				logger.Error(err)
-			}
-			err = yaml.Unmarshal(yamlFile, res)
-			if err != nil {
-				logger.Error(err)
-			}
-			logger.Debugf("Package found with kind: %s", res.Kind)
-			if res.Kind == "Function" {
-				cfnc := &cmv1beta1.Function{}
-				err = yaml.Unmarshal(yamlFile, cfnc)
-				if err != nil {
-					logger.Error(err)
-				}
-				fncName := fmt.Sprintf("%s:0.0.0", cfnc.GetName())
-				fnc := New(fncName)
+	logger.Debugf("Upgrade function: %s", fnc.Name)
+	if err := cycle.Step("version", func() error { return fnc.UpgradeFunction(ctx, config, dc) }); err != nil {
+		return err
+	}
 
-				logger.Debugf("Upgrade function: %s", fnc)
-				err := fnc.UpgradeFunction(ctx, config, dc)
+	logger.Infof("Apply function: %s", fnc.Name)
+	if err := cycle.Step("build", func() error { return fnc.buildDirectory(ctx, config, logger, path) }); err != nil {
+		return err
+	}
+	if err := cycle.Step("push", func() error { return fnc.load(ctx, config, logger) }); err != nil {
+		return err
+	}
+	if err := cycle.Step("apply", func() error { return fnc.Apply(ctx, config, logger) }); err != nil {
+		return err
+	}
 
-				logger.Infof("Changes detected, apply function: %s", cfnc.GetName())
-				if err != nil {
-					logger.Error(err)
-				} else {
-					logger.Debugf("Loading function: %s", fnc)
-					err = fnc.LoadDirectory(ctx, config, logger, path)
-					if err != nil {
-						logger.Error(err)
-					} else {
-						logger.Debugf("Loading function: %s", fnc)
-						err = fnc.Apply(ctx, config, logger)
-						if err != nil {
-							logger.Error(err)
-						}
-					}
-				}
-			}
-		}
+	client, err := kube.Client(config)
+	if err != nil {
+		return err
 	}
+	var revision *unstructured.Unstructured
+	err = cycle.Step("healthy", func() (err error) {
+		revision, err = packages.WaitRevision(ctx, dc, client, packages.FunctionRevisions, fnc.Name, packages.DefaultRevisionTimeout, logger)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	cycle.Follow(func(ctx context.Context) error {
+		fmt.Printf("──── cycle #%d: logs of %s ────\n", cycle.Number, revision.GetName())
+		return packages.StreamRevisionLogs(ctx, client, revision.GetName(), os.Stdout)
+	})
+	return nil
 }
@@ -1,122 +1,337 @@
-// generate/composite_resource.go
-
 package generate
 
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"math"
 	"os"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
 
 	crossv1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
-	"go.uber.org/zap"
 	yaml "gopkg.in/yaml.v3"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/client-go/dynamic"
+	kyaml "sigs.k8s.io/yaml"
 )
 
-// xr represents a Crossplane composite resource with metadata and specifications.
-type xr struct {
-	metav1.TypeMeta   `json:",inline"`
-	metav1.ObjectMeta `json:"metadata,omitempty"`
-	Spec              map[string]interface{} `json:"spec"`
+var compositeResourceDefinitions = schema.GroupVersionResource{Group: "apiextensions.crossplane.io", Version: "v1", Resource: "compositeresourcedefinitions"}
+
+// Example values of string formats
+var formatExamples = map[string]string{
+	"date":      "2024-01-01",
+	"date-time": "2024-01-01T00:00:00Z",
+	"duration":  "1h",
+	"email":     "user@example.com",
+	"hostname":  "example.com",
+	"ipv4":      "192.0.2.1",
+	"ipv6":      "2001:db8::1",
+	"uri":       "https://example.com",
+	"uuid":      "00000000-0000-0000-0000-000000000000",
+	"byte":      "ZXhhbXBsZQ==",
+}
+
+// Options of example generation
+type Options struct {
+	// Version of XRD, referenceable version by default
+	Version string
+	// Name of example resources
+	Name string
+	// Namespace of example claim
+	Namespace string
 }
 
-// GenerateCompositeResource reads a CompositeResourceDefinition from a YAML file,
-// generates an example composite resource, and prints it as YAML.
-func GenerateCompositeResource(ctx context.Context, path string, logger *zap.SugaredLogger) error {
+// LoadXRD reads XRD from YAML file
+func LoadXRD(path string) (*crossv1.CompositeResourceDefinition, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		logger.Errorf("failed to read file: %v", err)
+		return nil, err
 	}
+	xrd := &crossv1.CompositeResourceDefinition{}
+	if err := kyaml.Unmarshal(data, xrd); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+	if xrd.Kind != "CompositeResourceDefinition" {
+		return nil, fmt.Errorf("%s is not CompositeResourceDefinition", path)
+	}
+	return xrd, nil
+}
 
-	var xrd crossv1.CompositeResourceDefinition
-	if err := yaml.Unmarshal(data, &xrd); err != nil {
-		logger.Errorf("failed to unmarshal YAML: %v", err)
+// GetXRD reads XRD from environment
+func GetXRD(ctx context.Context, dc dynamic.Interface, name string) (*crossv1.CompositeResourceDefinition, error) {
+	u, err := dc.Resource(compositeResourceDefinitions).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
 	}
+	xrd := &crossv1.CompositeResourceDefinition{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, xrd); err != nil {
+		return nil, err
+	}
+	return xrd, nil
+}
 
-	xrSpec := generateSpec(xrd, logger)
+// WriteExamples writes example composite resource of XRD, and example claim
+// if XRD offers claims, as YAML documents. Values follow defaults, enums,
+// bounds and patterns of schema, descriptions of fields are comments.
+func WriteExamples(w io.Writer, xrd *crossv1.CompositeResourceDefinition, options Options) error {
+	version, err := xrdVersion(xrd, options.Version)
+	if err != nil {
+		return err
+	}
+	spec := &extv1.JSONSchemaProps{Type: "object"}
+	if version.Schema != nil && len(version.Schema.OpenAPIV3Schema.Raw) > 0 {
+		props := &extv1.JSONSchemaProps{}
+		if err := json.Unmarshal(version.Schema.OpenAPIV3Schema.Raw, props); err != nil {
+			return fmt.Errorf("cannot parse schema of %s: %w", version.Name, err)
+		}
+		if s, ok := props.Properties["spec"]; ok {
+			spec = &s
+		}
+	}
+	if options.Name == "" {
+		options.Name = "example"
+	}
+	if options.Namespace == "" {
+		options.Namespace = "default"
+	}
 
-	xr := xr{
-		TypeMeta: metav1.TypeMeta{
-			Kind:       xrd.Spec.Names.Kind,
-			APIVersion: xrd.Spec.Group + "/" + xrd.Spec.Versions[0].Name,
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name: "example-resource",
-		},
-		Spec: xrSpec,
+	apiVersion := xrd.Spec.Group + "/" + version.Name
+	documents := []*yaml.Node{
+		document(apiVersion, xrd.Spec.Names.Kind, options.Name, "", spec, fmt.Sprintf("Example %s generated from %s", xrd.Spec.Names.Kind, xrd.GetName())),
+	}
+	if xrd.Spec.ClaimNames != nil {
+		documents = append(documents, document(apiVersion, xrd.Spec.ClaimNames.Kind, options.Name, options.Namespace, spec, fmt.Sprintf("Example claim %s of %s", xrd.Spec.ClaimNames.Kind, xrd.Spec.Names.Kind)))
 	}
 
-	yamlXR, err := yaml.Marshal(xr)
-	if err != nil {
-		logger.Errorf("failed to marshal YAML: %v", err)
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	for _, doc := range documents {
+		if err := encoder.Encode(doc); err != nil {
+			return err
+		}
+	}
+	return encoder.Close()
+}
+
+// Version of XRD by name, referenceable version by default
+func xrdVersion(xrd *crossv1.CompositeResourceDefinition, name string) (*crossv1.CompositeResourceDefinitionVersion, error) {
+	names := []string{}
+	for i, version := range xrd.Spec.Versions {
+		if version.Name == name || name == "" && version.Referenceable {
+			return &xrd.Spec.Versions[i], nil
+		}
+		names = append(names, version.Name)
 	}
+	if name == "" && len(xrd.Spec.Versions) > 0 {
+		return &xrd.Spec.Versions[0], nil
+	}
+	return nil, fmt.Errorf("%s has no version %q, versions: %s", xrd.GetName(), name, strings.Join(names, ", "))
+}
 
-	logger.Info(string(yamlXR))
-	return nil
+func document(apiVersion string, kind string, name string, namespace string, spec *extv1.JSONSchemaProps, comment string) *yaml.Node {
+	metadata := &yaml.Node{Kind: yaml.MappingNode}
+	metadata.Content = append(metadata.Content, scalar("name"), scalar(name))
+	if namespace != "" {
+		metadata.Content = append(metadata.Content, scalar("namespace"), scalar(namespace))
+	}
+	root := &yaml.Node{Kind: yaml.MappingNode, HeadComment: comment}
+	root.Content = append(root.Content,
+		scalar("apiVersion"), scalar(apiVersion),
+		scalar("kind"), scalar(kind),
+		scalar("metadata"), metadata,
+		scalar("spec"), example(spec),
+	)
+	return &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}
 }
 
-// generateSpec creates an example spec map based on the schema defined in the CompositeResourceDefinition.
-func generateSpec(xrd crossv1.CompositeResourceDefinition, logger *zap.SugaredLogger) map[string]interface{} {
-	xrSpec := make(map[string]interface{})
-	rawData := xrd.Spec.Versions[0].Schema.OpenAPIV3Schema.Raw
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
 
-	var schema map[string]interface{}
-	if err := json.Unmarshal(rawData, &schema); err != nil {
-		logger.Errorf("failed to unmarshal schema JSON: %v", err)
+// Example value of schema
+func example(props *extv1.JSONSchemaProps) *yaml.Node {
+	node := &yaml.Node{}
+	if props.Default != nil {
+		var value interface{}
+		if utiljson.Unmarshal(props.Default.Raw, &value) == nil && node.Encode(value) == nil {
+			return node
+		}
+	}
+	if len(props.Enum) > 0 {
+		var value interface{}
+		if utiljson.Unmarshal(props.Enum[0].Raw, &value) == nil && node.Encode(value) == nil {
+			return node
+		}
 	}
 
-	specProperties, ok := schema["properties"].(map[string]interface{})["spec"].(map[string]interface{})["properties"].(map[string]interface{})
-	if !ok {
-		logger.Error("invalid schema format")
+	switch {
+	case props.Type == "object" || len(props.Properties) > 0:
+		return object(props)
+	case props.Type == "array":
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		if props.Items == nil || props.Items.Schema == nil {
+			return node
+		}
+		for i := int64(0); i < max(1, ptrValue(props.MinItems)); i++ {
+			node.Content = append(node.Content, example(props.Items.Schema))
+		}
+		return node
+	case props.Type == "integer" || props.XIntOrString:
+		_ = node.Encode(int64(bounded(props, 1)))
+	case props.Type == "number":
+		_ = node.Encode(bounded(props, 1.5))
+	case props.Type == "boolean":
+		_ = node.Encode(false)
+	case props.Type == "string":
+		_ = node.Encode(exampleString(props))
+	default:
+		// Fields preserving unknown fields accept any value
+		return &yaml.Node{Kind: yaml.MappingNode, Style: yaml.FlowStyle}
 	}
+	return node
+}
 
-	for key, prop := range specProperties {
-		propMap, ok := prop.(map[string]interface{})
-		if !ok {
-			xrSpec[key] = nil
-			continue
+// Example object with all properties, required properties first
+func object(props *extv1.JSONSchemaProps) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	names := []string{}
+	for name := range props.Properties {
+		names = append(names, name)
+	}
+	required := map[string]bool{}
+	for _, name := range props.Required {
+		required[name] = true
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if required[names[i]] != required[names[j]] {
+			return required[names[i]]
 		}
-		xrSpec[key] = assignValues(propMap)
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		prop := props.Properties[name]
+		key := scalar(name)
+		key.HeadComment = comment(&prop, required[name])
+		node.Content = append(node.Content, key, example(&prop))
 	}
-	return xrSpec
+	if len(names) == 0 && props.AdditionalProperties != nil && props.AdditionalProperties.Schema != nil {
+		node.Content = append(node.Content, scalar("key"), example(props.AdditionalProperties.Schema))
+	}
+	if len(node.Content) == 0 {
+		node.Style = yaml.FlowStyle
+	}
+	return node
 }
 
-// asignValues checks each type of properties and assign example data for a given property schema.
-func assignValues(propMap map[string]interface{}) interface{} {
-	if types, ok := propMap["type"].(string); ok {
-		switch types {
-		case "string":
-			return "example"
-		case "integer":
-			return 123
-		case "boolean":
-			return false
-		case "object":
-			return processObjectTypes(propMap)
-		default:
-			return nil
-		}
-	}
-	return nil
+// Comment of field with its description and constraints
+func comment(props *extv1.JSONSchemaProps, required bool) string {
+	lines := []string{}
+	if description := strings.TrimSpace(props.Description); description != "" {
+		lines = append(lines, strings.Split(description, "\n")...)
+	}
+	constraints := []string{}
+	if required {
+		constraints = append(constraints, "Required.")
+	}
+	if len(props.Enum) > 1 {
+		values := []string{}
+		for _, v := range props.Enum {
+			values = append(values, strings.Trim(string(v.Raw), `"`))
+		}
+		constraints = append(constraints, "One of: "+strings.Join(values, ", ")+".")
+	}
+	if len(constraints) > 0 {
+		lines = append(lines, strings.Join(constraints, " "))
+	}
+	return strings.Join(lines, "\n")
 }
 
-// processObjectTypes recursively generates example data for object properties.
-func processObjectTypes(objMap map[string]interface{}) map[string]interface{} {
-	data := make(map[string]interface{})
+// Value within minimum and maximum of schema
+func bounded(props *extv1.JSONSchemaProps, value float64) float64 {
+	if props.Minimum != nil {
+		minimum := *props.Minimum
+		if props.ExclusiveMinimum {
+			minimum++
+		}
+		value = math.Max(value, minimum)
+	}
+	if props.Maximum != nil {
+		maximum := *props.Maximum
+		if props.ExclusiveMaximum {
+			maximum--
+		}
+		value = math.Min(value, maximum)
+	}
+	return value
+}
 
-	properties, ok := objMap["properties"].(map[string]interface{})
-	if !ok {
-		return data
+// Example string matching pattern, format and length of schema
+func exampleString(props *extv1.JSONSchemaProps) string {
+	value := "example"
+	if example, ok := formatExamples[props.Format]; ok {
+		value = example
+	}
+	if props.Pattern != "" {
+		// Values not matching pattern are replaced with shortest match
+		if re, err := regexp.Compile(props.Pattern); err == nil && !re.MatchString(value) {
+			if parsed, err := syntax.Parse(props.Pattern, syntax.Perl); err == nil {
+				value = matching(parsed.Simplify())
+			}
+		}
 	}
+	if minLength := int(ptrValue(props.MinLength)); len(value) < minLength && props.Pattern == "" {
+		value += strings.Repeat("x", minLength-len(value))
+	}
+	if props.MaxLength != nil && len(value) > int(*props.MaxLength) {
+		value = value[:*props.MaxLength]
+	}
+	return value
+}
 
-	for key, prop := range properties {
-		propMap, ok := prop.(map[string]interface{})
-		if !ok {
-			data[key] = nil
-			continue
+// Shortest string matching regular expression, letters are preferred
+func matching(re *syntax.Regexp) string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return string(re.Rune)
+	case syntax.OpCharClass:
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			if re.Rune[i] <= 'a' && 'a' <= re.Rune[i+1] {
+				return "a"
+			}
+		}
+		if len(re.Rune) > 0 {
+			return string(re.Rune[0])
 		}
-		data[key] = assignValues(propMap)
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return "a"
+	case syntax.OpCapture, syntax.OpPlus:
+		return matching(re.Sub[0])
+	case syntax.OpRepeat:
+		return strings.Repeat(matching(re.Sub[0]), re.Min)
+	case syntax.OpConcat:
+		out := ""
+		for _, sub := range re.Sub {
+			out += matching(sub)
+		}
+		return out
+	case syntax.OpAlternate:
+		return matching(re.Sub[0])
 	}
+	// Empty matches, anchors, boundaries, stars and optional expressions
+	return ""
+}
 
-	return data
+func ptrValue(v *int64) int64 {
+	if v == nil {
+		return 0
+	}
+	return *v
 }
@@ -0,0 +1,92 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	crossv1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/web-seven/overlock/internal/image"
+)
+
+func TestWriteExamples(t *testing.T) {
+	schema := `{
+	"type": "object",
+	"properties": {
+		"spec": {
+			"type": "object",
+			"required": ["region", "name"],
+			"properties": {
+				"region": {"type": "string", "description": "Region of bucket.", "enum": ["eu-central-1", "us-east-1"]},
+				"name": {"type": "string", "pattern": "^[0-9][a-z0-9-]{2,62}$"},
+				"size": {"type": "integer", "minimum": 10, "maximum": 100},
+				"ratio": {"type": "number", "maximum": 1},
+				"versioning": {"type": "boolean", "default": true},
+				"tags": {"type": "object", "additionalProperties": {"type": "string"}},
+				"rules": {"type": "array", "minItems": 2, "items": {"type": "object", "properties": {
+					"cidr": {"type": "string", "format": "ipv4"}
+				}}},
+				"extra": {"type": "object", "x-kubernetes-preserve-unknown-fields": true}
+			}
+		}
+	}
+}`
+	xrd := &crossv1.CompositeResourceDefinition{}
+	xrd.SetName("xbuckets.example.org")
+	xrd.Spec.Group = "example.org"
+	xrd.Spec.Names.Kind = "XBucket"
+	xrd.Spec.ClaimNames = &extv1.CustomResourceDefinitionNames{Kind: "Bucket"}
+	xrd.Spec.Versions = []crossv1.CompositeResourceDefinitionVersion{
+		{Name: "v1alpha1", Served: true},
+		{Name: "v1", Served: true, Referenceable: true, Schema: &crossv1.CompositeResourceValidation{
+			OpenAPIV3Schema: runtime.RawExtension{Raw: []byte(schema)},
+		}},
+	}
+
+	out := &bytes.Buffer{}
+	if err := WriteExamples(out, xrd, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"# Example XBucket generated from xbuckets.example.org",
+		"# Region of bucket.\n  # Required. One of: eu-central-1, us-east-1.\n  region: eu-central-1",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("expected %q in\n%s", line, out)
+		}
+	}
+	objects, err := image.ParseObjects(out.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 || objects[0].GetKind() != "XBucket" || objects[1].GetKind() != "Bucket" || objects[1].GetNamespace() != "default" {
+		t.Fatalf("unexpected examples %v", objects)
+	}
+	xr := objects[0]
+	if xr.GetAPIVersion() != "example.org/v1" || xr.GetName() != "example" {
+		t.Errorf("unexpected resource %v", xr.Object)
+	}
+	spec, _, _ := unstructured.NestedMap(xr.Object, "spec")
+	if !regexp.MustCompile("^[0-9][a-z0-9-]{2,62}$").MatchString(spec["name"].(string)) {
+		t.Errorf("name %q does not match pattern", spec["name"])
+	}
+	if fmt.Sprint(spec["size"], spec["ratio"]) != "10 1" || spec["versioning"] != true {
+		t.Errorf("unexpected spec %v", spec)
+	}
+	if rules := spec["rules"].([]interface{}); len(rules) != 2 || rules[0].(map[string]interface{})["cidr"] != "192.0.2.1" {
+		t.Errorf("unexpected rules %v", rules)
+	}
+	if tags, _, _ := unstructured.NestedStringMap(spec, "tags"); len(tags) != 1 {
+		t.Errorf("unexpected tags %v", spec["tags"])
+	}
+
+	if err := WriteExamples(out, xrd, Options{Version: "v2"}); err == nil || !strings.Contains(err.Error(), "versions: v1alpha1, v1") {
+		t.Errorf("expected error of unknown version, got %v", err)
+	}
+}
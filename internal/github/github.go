@@ -2,16 +2,19 @@ package github
 
 import (
 	"context"
-	"encoding/json"
 	"strings"
 
-	"github.com/pterm/pterm"
-	"github.com/web-seven/overlock/pkg/registry"
 	"go.uber.org/zap"
 
 	"github.com/google/go-github/v61/github"
 )
 
+// Repository is a container package of GitHub organization with its tags
+type Repository struct {
+	Name string
+	Tags []string
+}
+
 func getAllPackages(ctx context.Context, client *github.Client, org string, opts *github.PackageListOptions, allPkgs []*github.Package) ([]*github.Package, error) {
 	pkgs, resp, err := client.Organizations.ListPackages(ctx, org, opts)
 	if err != nil {
@@ -27,14 +30,9 @@ func getAllPackages(ctx context.Context, client *github.Client, org string, opts
 	return getAllPackages(ctx, client, org, opts, allPkgs)
 }
 
-// GetPackages list packages and their versions from Container Registry
-func GetPackages(ctx context.Context, query string, version bool, r *registry.Registry, registryUrl string, org string, logger *zap.SugaredLogger) (pterm.TableData, error) {
-	auth := registry.RegistryConfig{}
-	json.Unmarshal([]byte(r.Data[".dockerconfigjson"]), &auth)
-	clientgh := github.NewClient(nil).WithAuthToken(auth.Auths[registryUrl].Password)
-	tableRegs := pterm.TableData{
-		{"URL", "VERSION"},
-	}
+// GetPackages list container packages matching query and their tags from GitHub Container Registry
+func GetPackages(ctx context.Context, query string, token string, org string, logger *zap.SugaredLogger) ([]Repository, error) {
+	clientgh := github.NewClient(nil).WithAuthToken(token)
 	pkgType := "container"
 	var allPkgs []*github.Package
 	opts := &github.PackageListOptions{
@@ -43,41 +41,26 @@ func GetPackages(ctx context.Context, query string, version bool, r *registry.Re
 
 	allPkgs, err := getAllPackages(ctx, clientgh, org, opts, allPkgs)
 	if err != nil {
-		logger.Errorf("Cannot get packages from %s", registryUrl)
+		logger.Errorf("Cannot get packages from ghcr.io/%s", org)
 		return nil, err
 	}
 
-	pkgVersions := make(map[string][]*github.PackageVersion)
+	repositories := []Repository{}
 	for _, pkg := range allPkgs {
+		if !strings.Contains(pkg.GetName(), query) {
+			continue
+		}
 		versions, _, err := clientgh.Organizations.PackageGetAllVersions(ctx, org, pkgType, pkg.GetName(), nil)
 		if err != nil {
-			logger.Errorf("Cannot get package versions for %s/%s", org, *pkg.Name)
+			logger.Errorf("Cannot get package versions for %s/%s", org, pkg.GetName())
 			return nil, err
 		}
-		if !version {
-			if len(versions) > 0 {
-				pkgVersions[*pkg.Name] = []*github.PackageVersion{versions[0]}
-			}
-		} else {
-			pkgVersions[*pkg.Name] = versions
-		}
-	}
-
-	for _, pkg := range allPkgs {
-		if !strings.Contains(*pkg.Name, query) {
-			continue
-		}
-		versions := pkgVersions[*pkg.Name]
+		repository := Repository{Name: pkg.GetName()}
 		for _, v := range versions {
-			tags := v.GetMetadata().Container.Tags
-			if len(tags) > 0 {
-				tableRegs = append(tableRegs, []string{
-					"ghcr.io/" + org + "/" + *pkg.Name,
-					tags[0],
-				})
-			}
+			repository.Tags = append(repository.Tags, v.GetMetadata().GetContainer().Tags...)
 		}
+		repositories = append(repositories, repository)
 	}
 
-	return tableRegs, nil
+	return repositories, nil
 }
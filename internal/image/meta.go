@@ -0,0 +1,231 @@
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	kyaml "sigs.k8s.io/yaml"
+)
+
+const (
+	// Value of AnnotationKey set on the layer holding package.yaml
+	AnnotationBase string = "base"
+	// File name of package stream inside of package layer
+	PackageFileName string = "package.yaml"
+	// Annotation of package meta object with human readable description
+	DescriptionAnnotation string = "meta.crossplane.io/description"
+)
+
+// ErrNotPackage is returned when image has no layer annotated as Crossplane package
+var ErrNotPackage = errors.New("image is not a Crossplane package")
+
+// IsPackage reports if image has a layer annotated as Crossplane package
+func IsPackage(img v1.Image) (bool, error) {
+	_, err := packageLayerDigest(img)
+	if errors.Is(err, ErrNotPackage) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// PackageObjects returns all objects from package.yaml of Crossplane package image.
+// First object is the package meta (Configuration, Provider or Function).
+func PackageObjects(img v1.Image) ([]unstructured.Unstructured, error) {
+	digest, err := packageLayerDigest(img)
+	if err != nil {
+		return nil, err
+	}
+	layer, err := img.LayerByDigest(digest)
+	if err != nil {
+		return nil, err
+	}
+	return LayerObjects(layer)
+}
+
+// LayerObjects returns all objects from package.yaml of package layer
+func LayerObjects(layer v1.Layer) ([]unstructured.Unstructured, error) {
+	rc, err := layer.Uncompressed()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	tr := tar.NewReader(rc)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, ErrNotPackage
+		}
+		if err != nil {
+			return nil, err
+		}
+		if h.Name != PackageFileName {
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		return ParseObjects(content)
+	}
+}
+
+// PackageMeta returns package meta object of Crossplane package image
+func PackageMeta(img v1.Image) (*unstructured.Unstructured, error) {
+	objects, err := PackageObjects(img)
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, ErrNotPackage
+	}
+	return &objects[0], nil
+}
+
+// ParseObjects splits multi document YAML stream into objects
+func ParseObjects(content []byte) ([]unstructured.Unstructured, error) {
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), 4096)
+	objects := []unstructured.Unstructured{}
+	for {
+		obj := map[string]interface{}{}
+		err := decoder.Decode(&obj)
+		if errors.Is(err, io.EOF) {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(obj) == 0 {
+			continue
+		}
+		objects = append(objects, unstructured.Unstructured{Object: obj})
+	}
+}
+
+// WithPackageObjects returns copy of package image with package.yaml replaced
+// by objects, other files, layers, media types and annotations are kept
+func WithPackageObjects(img v1.Image, objects []unstructured.Unstructured) (v1.Image, error) {
+	digest, err := packageLayerDigest(img)
+	if err != nil {
+		return nil, err
+	}
+	documents := [][]byte{}
+	for _, obj := range objects {
+		document, err := kyaml.Marshal(obj.Object)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+	layer, err := img.LayerByDigest(digest)
+	if err != nil {
+		return nil, err
+	}
+	packageLayer, err := replaceFile(layer, PackageFileName, bytes.Join(documents, []byte("---\n")))
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	// Layers and their history are appended again below
+	cfg = cfg.DeepCopy()
+	cfg.RootFS.DiffIDs = nil
+	cfg.History = nil
+	base, err := mutate.ConfigFile(empty.Image, cfg)
+	if err != nil {
+		return nil, err
+	}
+	base = mutate.ConfigMediaType(mutate.MediaType(base, manifest.MediaType), manifest.Config.MediaType)
+
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, err
+	}
+	addenda := []mutate.Addendum{}
+	for i, layer := range layers {
+		desc := manifest.Layers[i]
+		if desc.Digest == digest {
+			layer = packageLayer
+		}
+		addenda = append(addenda, mutate.Addendum{Layer: layer, MediaType: desc.MediaType, Annotations: desc.Annotations})
+	}
+	return mutate.Append(base, addenda...)
+}
+
+// Copy of layer with content of file replaced
+func replaceFile(layer v1.Layer, fileName string, content []byte) (v1.Layer, error) {
+	rc, err := layer.Uncompressed()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	buf := new(bytes.Buffer)
+	tr := tar.NewReader(rc)
+	tw := tar.NewWriter(buf)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var data io.Reader = tr
+		if h.Name == fileName {
+			h.Size = int64(len(content))
+			data = bytes.NewReader(content)
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(tw, data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buf.Bytes())), nil
+	})
+}
+
+func packageLayerDigest(img v1.Image) (v1.Hash, error) {
+	manifest, err := img.Manifest()
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	for _, layer := range manifest.Layers {
+		if layer.Annotations[AnnotationKey] == AnnotationBase {
+			return layer.Digest, nil
+		}
+	}
+	// Layer annotations are not kept in image archives, Crossplane labels
+	// config with digest of package layer for them
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	for _, layer := range manifest.Layers {
+		if cfg.Config.Labels[AnnotationKey+":"+layer.Digest.String()] == AnnotationBase {
+			return layer.Digest, nil
+		}
+	}
+	return v1.Hash{}, ErrNotPackage
+}
@@ -164,3 +164,35 @@ func ApplyControlPlaneKubeconfig(mcpConf api.Config, existingFilePath string, wr
 
 	return clientcmd.ModifyConfig(po, *conf, true)
 }
+
+// WriteKubeconfig writes kubeconfig with single context for REST config, so
+// processes started by Overlock connect to the same cluster with the same credentials.
+func WriteKubeconfig(config *rest.Config, path string) error {
+	const name = "overlock"
+	conf := api.NewConfig()
+	conf.Clusters[name] = &api.Cluster{
+		Server:                   config.Host,
+		CertificateAuthority:     config.CAFile,
+		CertificateAuthorityData: config.CAData,
+		InsecureSkipTLSVerify:    config.Insecure,
+		TLSServerName:            config.ServerName,
+	}
+	conf.AuthInfos[name] = &api.AuthInfo{
+		ClientCertificate:     config.CertFile,
+		ClientCertificateData: config.CertData,
+		ClientKey:             config.KeyFile,
+		ClientKeyData:         config.KeyData,
+		Token:                 config.BearerToken,
+		TokenFile:             config.BearerTokenFile,
+		Username:              config.Username,
+		Password:              config.Password,
+		Exec:                  config.ExecProvider,
+		AuthProvider:          config.AuthProvider,
+	}
+	conf.Contexts[name] = &api.Context{
+		Cluster:  name,
+		AuthInfo: name,
+	}
+	conf.CurrentContext = name
+	return clientcmd.WriteToFile(*conf, path)
+}
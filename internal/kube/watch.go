@@ -26,6 +26,10 @@ import (
 
 const errClosedResults = "stopped watching before condition met"
 
+// ErrWatchClosed is returned by DynamicWatch if the watch is closed, e.g. by
+// the API server, before condition met
+var ErrWatchClosed = errors.New(errClosedResults)
+
 // DynamicWatch starts a watch on the given resource type. The done callback is
 // called on every received event until either timeout or context cancellation.
 func DynamicWatch(ctx context.Context, r dynamic.NamespaceableResourceInterface, timeout *int64, done func(u *unstructured.Unstructured) (bool, error)) (chan error, error) {
@@ -43,7 +47,7 @@ func DynamicWatch(ctx context.Context, r dynamic.NamespaceableResourceInterface,
 			case e, ok := <-w.ResultChan():
 				// If we are no longer watching return with error.
 				if !ok {
-					errChan <- errors.New(errClosedResults)
+					errChan <- ErrWatchClosed
 					return
 				}
 
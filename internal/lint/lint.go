@@ -0,0 +1,414 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	xpv1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	pkgmetav1 "github.com/crossplane/crossplane/apis/pkg/meta/v1"
+	pkgmetav1beta1 "github.com/crossplane/crossplane/apis/pkg/meta/v1beta1"
+	"go.uber.org/zap"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/web-seven/overlock/internal/image"
+	"github.com/web-seven/overlock/internal/packages"
+)
+
+const (
+	KindConfiguration = "Configuration"
+	KindProvider      = "Provider"
+	KindFunction      = "Function"
+
+	kindXRD         = "CompositeResourceDefinition"
+	kindComposition = "Composition"
+	kindCRD         = "CustomResourceDefinition"
+)
+
+// Kinds of objects packaged for package kind
+var PackageKinds = map[string][]string{
+	KindConfiguration: {KindConfiguration, kindXRD, kindComposition},
+	KindProvider:      {KindProvider, kindCRD},
+	KindFunction:      {KindFunction, kindCRD},
+}
+
+// Typed package meta objects, Crossplane package schema of package kind
+var metaTypes = map[string]func() interface{}{
+	KindConfiguration: func() interface{} { return &pkgmetav1.Configuration{} },
+	KindProvider:      func() interface{} { return &pkgmetav1.Provider{} },
+	KindFunction:      func() interface{} { return &pkgmetav1beta1.Function{} },
+}
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Issue found in package directory
+type Issue struct {
+	Severity Severity
+	File     string
+	Object   string
+	Message  string
+}
+
+func (i Issue) String() string {
+	location := i.File
+	if i.Object != "" {
+		location += " " + i.Object
+	}
+	return fmt.Sprintf("%s: %s", location, i.Message)
+}
+
+// Object of package with file it was read from
+type object struct {
+	unstructured.Unstructured
+	file string
+}
+
+func (o object) id() string {
+	return o.GetKind() + "/" + o.GetName()
+}
+
+type linter struct {
+	dir     string
+	kind    string
+	objects []object
+	issues  []Issue
+}
+
+// Lint package directory of kind, objects are selected the same way as
+// they are packaged: by kind of the first document of each YAML file
+func Lint(dir string, kind string) ([]Issue, error) {
+	kinds, ok := PackageKinds[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown package kind %s", kind)
+	}
+	l := &linter{dir: dir, kind: kind}
+	if err := l.read(kinds); err != nil {
+		return nil, err
+	}
+
+	meta := l.meta()
+	if meta != nil {
+		l.checkMeta(meta)
+	}
+	l.checkDuplicates()
+	xrds := l.checkXRDs()
+	l.checkCompositions(xrds, meta)
+	l.checkCRDs()
+
+	sort.SliceStable(l.issues, func(i, j int) bool { return l.issues[i].File < l.issues[j].File })
+	return l.issues, nil
+}
+
+// Check lints package directory of kind, logs found issues and fails if any of them is error
+func Check(dir string, kind string, logger *zap.SugaredLogger) error {
+	issues, err := Lint(dir, kind)
+	if err != nil {
+		return err
+	}
+	errs := 0
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			errs++
+			logger.Error(issue.String())
+		} else {
+			logger.Warn(issue.String())
+		}
+	}
+	if errs > 0 {
+		return fmt.Errorf("%d lint error(s) in %s", errs, dir)
+	}
+	return nil
+}
+
+func (l *linter) errorf(o *object, file string, format string, args ...interface{}) {
+	l.add(SeverityError, o, file, format, args...)
+}
+
+func (l *linter) warnf(o *object, file string, format string, args ...interface{}) {
+	l.add(SeverityWarning, o, file, format, args...)
+}
+
+func (l *linter) add(severity Severity, o *object, file string, format string, args ...interface{}) {
+	issue := Issue{Severity: severity, File: file, Message: fmt.Sprintf(format, args...)}
+	if o != nil {
+		issue.File = o.file
+		issue.Object = o.id()
+	}
+	l.issues = append(l.issues, issue)
+}
+
+// Read packaged objects of directory
+func (l *linter) read(kinds []string) error {
+	return filepath.WalkDir(l.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".yaml" {
+			return nil
+		}
+		file, _ := filepath.Rel(l.dir, path)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		objects, err := image.ParseObjects(content)
+		if err != nil {
+			l.errorf(nil, file, "invalid YAML: %v", err)
+			return nil
+		}
+		if len(objects) == 0 {
+			return nil
+		}
+		if !slices.Contains(kinds, objects[0].GetKind()) {
+			for _, o := range objects[1:] {
+				if slices.Contains(kinds, o.GetKind()) {
+					l.warnf(nil, file, "%s/%s is not packaged because the first document of file is %s", o.GetKind(), o.GetName(), objects[0].GetKind())
+				}
+			}
+			return nil
+		}
+		for _, o := range objects {
+			l.objects = append(l.objects, object{Unstructured: o, file: file})
+		}
+		return nil
+	})
+}
+
+func (l *linter) byKind(kind string) []*object {
+	objects := []*object{}
+	for i := range l.objects {
+		if l.objects[i].GetKind() == kind {
+			objects = append(objects, &l.objects[i])
+		}
+	}
+	return objects
+}
+
+// Find the single package meta object
+func (l *linter) meta() *object {
+	metas := l.byKind(l.kind)
+	switch len(metas) {
+	case 0:
+		l.errorf(nil, ".", "%s package meta not found", l.kind)
+		return nil
+	case 1:
+		return metas[0]
+	default:
+		for _, m := range metas[1:] {
+			l.errorf(m, "", "package must have exactly one %s, first one is in %s", l.kind, metas[0].file)
+		}
+		return metas[0]
+	}
+}
+
+func (l *linter) checkMeta(meta *object) {
+	group := strings.Split(meta.GetAPIVersion(), "/")[0]
+	if group != "meta.pkg.crossplane.io" {
+		l.errorf(meta, "", "apiVersion must be of group meta.pkg.crossplane.io, got %q", meta.GetAPIVersion())
+	}
+	if meta.GetName() == "" {
+		l.errorf(meta, "", "metadata.name is required")
+	}
+	if !l.decode(meta, metaTypes[l.kind]()) {
+		return
+	}
+
+	dependsOn, found, err := unstructured.NestedSlice(meta.Object, "spec", "dependsOn")
+	if err != nil {
+		l.errorf(meta, "", "spec.dependsOn must be a list: %v", err)
+		return
+	}
+	if !found {
+		return
+	}
+	deps := packages.Dependencies(&meta.Unstructured)
+	if len(deps) != len(dependsOn) {
+		l.errorf(meta, "", "every entry of spec.dependsOn must declare provider, configuration or function package")
+	}
+	for _, dep := range deps {
+		switch {
+		case dep.Constraint == "":
+			l.warnf(meta, "", "dependency %s has no version constraint, any version will be installed", dep.Package)
+		case strings.HasPrefix(dep.Constraint, "sha256:"):
+		default:
+			if _, err := semver.NewConstraint(dep.Constraint); err != nil {
+				l.errorf(meta, "", "dependency %s has invalid version constraint %q: %v", dep.Package, dep.Constraint, err)
+			}
+		}
+		if last := dep.Package[strings.LastIndex(dep.Package, "/")+1:]; strings.ContainsAny(last, ":@") {
+			l.warnf(meta, "", "dependency %s should not contain tag, use version constraint instead", dep.Package)
+		}
+	}
+}
+
+func (l *linter) checkDuplicates() {
+	seen := map[string]*object{}
+	for i := range l.objects {
+		o := &l.objects[i]
+		if o.GetKind() == l.kind {
+			continue
+		}
+		if first, ok := seen[o.id()]; ok {
+			l.errorf(o, "", "duplicate of object in %s", first.file)
+			continue
+		}
+		seen[o.id()] = o
+	}
+}
+
+// Decode object into typed value, unknown fields are reported as warnings
+func (l *linter) decode(o *object, into interface{}) bool {
+	content, err := json.Marshal(o.Object)
+	if err != nil {
+		l.errorf(o, "", "%v", err)
+		return false
+	}
+	if err := json.Unmarshal(content, into); err != nil {
+		l.errorf(o, "", "does not match %s schema: %v", o.GetKind(), err)
+		return false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(into); err != nil {
+		l.warnf(o, "", "%v", err)
+	}
+	return true
+}
+
+// Check XRDs and return them by group and kind of composite resource
+func (l *linter) checkXRDs() map[string]*xpv1.CompositeResourceDefinition {
+	xrds := map[string]*xpv1.CompositeResourceDefinition{}
+	for _, o := range l.byKind(kindXRD) {
+		xrd := &xpv1.CompositeResourceDefinition{}
+		if !l.decode(o, xrd) {
+			continue
+		}
+		if _, errs := xrd.Validate(); len(errs) > 0 {
+			for _, err := range errs {
+				l.errorf(o, "", "%v", err)
+			}
+		}
+		if xrd.Spec.Names.Plural != "" && xrd.Spec.Group != "" && xrd.GetName() != xrd.Spec.Names.Plural+"."+xrd.Spec.Group {
+			l.errorf(o, "", "name must be %s.%s", xrd.Spec.Names.Plural, xrd.Spec.Group)
+		}
+		if xrd.Spec.Names.Kind == "" {
+			l.errorf(o, "", "spec.names.kind is required")
+		}
+		if len(xrd.Spec.Versions) == 0 {
+			l.errorf(o, "", "spec.versions must have at least one version")
+		}
+		referenceable := 0
+		for _, v := range xrd.Spec.Versions {
+			if v.Referenceable {
+				referenceable++
+			}
+			if v.Schema == nil || len(v.Schema.OpenAPIV3Schema.Raw) == 0 {
+				l.warnf(o, "", "version %s has no openAPIV3Schema", v.Name)
+			}
+		}
+		if len(xrd.Spec.Versions) > 0 && referenceable != 1 {
+			l.errorf(o, "", "exactly one version must be referenceable, got %d", referenceable)
+		}
+		xrds[xrd.Spec.Group+"/"+xrd.Spec.Names.Kind] = xrd
+	}
+	return xrds
+}
+
+func (l *linter) checkCompositions(xrds map[string]*xpv1.CompositeResourceDefinition, meta *object) {
+	functions := []packages.Dependency{}
+	if meta != nil {
+		for _, dep := range packages.Dependencies(&meta.Unstructured) {
+			if dep.Kind == KindFunction {
+				functions = append(functions, dep)
+			}
+		}
+	}
+
+	for _, o := range l.byKind(kindComposition) {
+		comp := &xpv1.Composition{}
+		if !l.decode(o, comp) {
+			continue
+		}
+		if _, errs := comp.Validate(); len(errs) > 0 {
+			for _, err := range errs {
+				l.errorf(o, "", "%v", err)
+			}
+		}
+
+		ref := comp.Spec.CompositeTypeRef
+		group, version, _ := strings.Cut(ref.APIVersion, "/")
+		if xrd, ok := xrds[group+"/"+ref.Kind]; ok {
+			found := false
+			for _, v := range xrd.Spec.Versions {
+				found = found || v.Name == version
+			}
+			if !found {
+				l.errorf(o, "", "compositeTypeRef version %s is not defined by %s", version, xrd.GetName())
+			}
+		} else if len(xrds) > 0 {
+			l.warnf(o, "", "compositeTypeRef %s %s is not defined by any XRD of package", ref.APIVersion, ref.Kind)
+		}
+
+		for _, step := range comp.Spec.Pipeline {
+			if !declaresFunction(functions, step.FunctionRef.Name) {
+				l.errorf(o, "", "pipeline step %s references function %s which is not declared in spec.dependsOn", step.Step, step.FunctionRef.Name)
+			}
+		}
+	}
+}
+
+func (l *linter) checkCRDs() {
+	for _, o := range l.byKind(kindCRD) {
+		crd := &extv1.CustomResourceDefinition{}
+		if !l.decode(o, crd) {
+			continue
+		}
+		if crd.Spec.Group == "" || crd.Spec.Names.Plural == "" || crd.Spec.Names.Kind == "" {
+			l.errorf(o, "", "spec.group, spec.names.plural and spec.names.kind are required")
+			continue
+		}
+		if crd.GetName() != crd.Spec.Names.Plural+"."+crd.Spec.Group {
+			l.errorf(o, "", "name must be %s.%s", crd.Spec.Names.Plural, crd.Spec.Group)
+		}
+		if len(crd.Spec.Versions) == 0 {
+			l.errorf(o, "", "spec.versions must have at least one version")
+		}
+	}
+}
+
+// Check if function name matches any declared function dependency. Functions
+// installed as dependencies are named after their package repository, so
+// both the last path element and the dashed repository path are accepted.
+func declaresFunction(functions []packages.Dependency, name string) bool {
+	for _, dep := range functions {
+		repository := dep.Package
+		if i := strings.LastIndexAny(repository, ":@"); i > strings.LastIndex(repository, "/") {
+			repository = repository[:i]
+		}
+		elements := strings.Split(repository, "/")
+		if elements[len(elements)-1] == name {
+			return true
+		}
+		dashed := strings.Join(elements, "-")
+		if len(elements) > 1 && strings.Contains(elements[0], ".") {
+			dashed = strings.Join(elements[1:], "-")
+		}
+		if dashed == name || strings.HasSuffix(dashed, "-"+name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/web-seven/overlock/internal/packages"
+)
+
+const (
+	configurationMeta = `apiVersion: meta.pkg.crossplane.io/v1
+kind: Configuration
+metadata:
+  name: platform
+spec:
+  dependsOn:
+    - provider: xpkg.upbound.io/crossplane-contrib/provider-nop
+      version: ">=v0.2.0"
+    - function: xpkg.upbound.io/crossplane-contrib/function-patch-and-transform
+      version: "%s"
+`
+	xrd = `apiVersion: apiextensions.crossplane.io/v1
+kind: CompositeResourceDefinition
+metadata:
+  name: %s
+spec:
+  group: example.org
+  names:
+    kind: XNetwork
+    plural: xnetworks
+  versions:
+    - name: v1alpha1
+      served: true
+      referenceable: true
+      schema:
+        openAPIV3Schema:
+          type: object
+`
+	composition = `apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+metadata:
+  name: xnetworks
+spec:
+  compositeTypeRef:
+    apiVersion: example.org/%s
+    kind: XNetwork
+  mode: Pipeline
+  pipeline:
+    - step: patch
+      functionRef:
+        name: %s
+`
+)
+
+func writePackage(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLintConfiguration(t *testing.T) {
+	cases := map[string]struct {
+		files  map[string]string
+		errors []string
+	}{
+		"Valid": {
+			files: map[string]string{
+				"crossplane.yaml":       fmt.Sprintf(configurationMeta, ">=v0.1.0"),
+				"apis/definition.yaml":  fmt.Sprintf(xrd, "xnetworks.example.org"),
+				"apis/composition.yaml": fmt.Sprintf(composition, "v1alpha1", "function-patch-and-transform"),
+				"examples/network.yaml": "apiVersion: example.org/v1alpha1\nkind: XNetwork\nmetadata:\n  name: test\n",
+				"overlock.build.yaml":   "baseImage: scratch\n",
+			},
+		},
+		"Broken": {
+			files: map[string]string{
+				"crossplane.yaml":       fmt.Sprintf(configurationMeta, "not a version"),
+				"apis/definition.yaml":  fmt.Sprintf(xrd, "networks.example.org"),
+				"apis/composition.yaml": fmt.Sprintf(composition, "v1beta1", "function-go-templating"),
+				"apis/broken.yaml":      "kind: [",
+			},
+			errors: []string{
+				"invalid YAML",
+				"invalid version constraint",
+				"name must be xnetworks.example.org",
+				"compositeTypeRef version v1beta1",
+				"references function function-go-templating",
+			},
+		},
+		"MissingMeta": {
+			files:  map[string]string{"apis/definition.yaml": fmt.Sprintf(xrd, "xnetworks.example.org")},
+			errors: []string{"Configuration package meta not found"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			issues, err := Lint(writePackage(t, tc.files), KindConfiguration)
+			if err != nil {
+				t.Fatal(err)
+			}
+			errs := []string{}
+			for _, issue := range issues {
+				if issue.Severity == SeverityError {
+					errs = append(errs, issue.String())
+				}
+			}
+			if len(errs) != len(tc.errors) {
+				t.Fatalf("expected %d errors, got %d: %v", len(tc.errors), len(errs), errs)
+			}
+			for _, want := range tc.errors {
+				found := false
+				for _, e := range errs {
+					found = found || strings.Contains(e, want)
+				}
+				if !found {
+					t.Errorf("expected error containing %q, got %v", want, errs)
+				}
+			}
+		})
+	}
+}
+
+func TestDeclaresFunction(t *testing.T) {
+	deps := []string{"xpkg.upbound.io/crossplane-contrib/function-patch-and-transform", "registry.local/function-auto-ready:v0.1.0"}
+	cases := map[string]bool{
+		"function-patch-and-transform":                    true,
+		"crossplane-contrib-function-patch-and-transform": true,
+		"function-auto-ready":                             true,
+		"function-go-templating":                          false,
+	}
+	functions := []packages.Dependency{}
+	for _, d := range deps {
+		functions = append(functions, packages.Dependency{Package: d, Kind: KindFunction})
+	}
+	for name, want := range cases {
+		if got := declaresFunction(functions, name); got != want {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+}
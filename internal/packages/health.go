@@ -0,0 +1,141 @@
+package packages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/web-seven/overlock/internal/kube"
+)
+
+// Conditions of packages which all must be true for package to be healthy
+var packageConditions = []string{"Installed", "Healthy"}
+
+// Health reports if package is installed and healthy and describes failing
+// conditions otherwise. Objects without package conditions, such as
+// DeploymentRuntimeConfig, are healthy once they exist.
+func Health(u *unstructured.Unstructured) (bool, string) {
+	if u.GetKind() == "DeploymentRuntimeConfig" {
+		return true, ""
+	}
+	conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+	failing := []string{}
+	for _, t := range packageConditions {
+		status, message := "Unknown", "condition not reported yet"
+		for _, c := range conditions {
+			condition, ok := c.(map[string]interface{})
+			if !ok || condition["type"] != t {
+				continue
+			}
+			status, _ = condition["status"].(string)
+			message, _ = condition["message"].(string)
+			if message == "" {
+				message, _ = condition["reason"].(string)
+			}
+		}
+		if status != string(corev1.ConditionTrue) {
+			failing = append(failing, fmt.Sprintf("%s=%s: %s", t, status, message))
+		}
+	}
+	return len(failing) == 0, strings.Join(failing, "; ")
+}
+
+// WaitHealthy watches objects of resource until all of them are healthy.
+// Objects are referenced by name or by package source. Zero timeout waits
+// until ctx is cancelled. On timeout the failing conditions are returned.
+func WaitHealthy(ctx context.Context, dc dynamic.Interface, gvr schema.GroupVersionResource, keys []string, timeout time.Duration, logger *zap.SugaredLogger) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	pending := map[string]string{}
+	for _, key := range keys {
+		pending[key] = "not found"
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	update := func(u *unstructured.Unstructured) (bool, error) {
+		for key := range pending {
+			if !matchKey(u, key) {
+				continue
+			}
+			healthy, message := Health(u)
+			if healthy {
+				logger.Debugf("%s %s is healthy.", u.GetKind(), u.GetName())
+				delete(pending, key)
+			} else {
+				pending[key] = message
+			}
+		}
+		return len(pending) == 0, nil
+	}
+
+	return waitError(watchObjects(ctx, dc, gvr, update, logger), gvr, pending, timeout)
+}
+
+// Watch objects of resource, existing ones included, until done reports true
+// or fails. Watch closed by API server after its request timeout is started
+// again, objects are listed again as events could be missed in between.
+func watchObjects(ctx context.Context, dc dynamic.Interface, gvr schema.GroupVersionResource, done func(u *unstructured.Unstructured) (bool, error), logger *zap.SugaredLogger) error {
+	for {
+		list, err := dc.Resource(gvr).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		for i := range list.Items {
+			ok, err := done(&list.Items[i])
+			if err != nil {
+				return err
+			}
+			if ok {
+				return nil
+			}
+		}
+		errChan, err := kube.DynamicWatch(ctx, dc.Resource(gvr), nil, done)
+		if err != nil {
+			return err
+		}
+
+		err = <-errChan
+		if errors.Is(err, kube.ErrWatchClosed) {
+			logger.Debugf("Watch of %s closed, watching again.", gvr.Resource)
+			continue
+		}
+		return err
+	}
+}
+
+// Error of wait with failing conditions of pending objects on timeout
+func waitError(err error, gvr schema.GroupVersionResource, pending map[string]string, timeout time.Duration) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		failing := []string{}
+		for key, message := range pending {
+			failing = append(failing, key+" ("+message+")")
+		}
+		sort.Strings(failing)
+		return fmt.Errorf("%s not healthy after %s: %s", gvr.Resource, timeout, strings.Join(failing, ", "))
+	}
+	return err
+}
+
+// Match object by name or by package source
+func matchKey(u *unstructured.Unstructured, key string) bool {
+	if u.GetName() == key {
+		return true
+	}
+	source, _, _ := unstructured.NestedString(u.Object, "spec", "package")
+	return source != "" && (source == key || strings.HasSuffix(source, "/"+key))
+}
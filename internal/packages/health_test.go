@@ -0,0 +1,88 @@
+package packages
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestWaitHealthy(t *testing.T) {
+	provider := func(name string, source string, conditions ...interface{}) runtime.Object {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "pkg.crossplane.io/v1",
+			"kind":       "Provider",
+			"metadata":   map[string]interface{}{"name": name},
+			"spec":       map[string]interface{}{"package": source},
+			"status":     map[string]interface{}{"conditions": conditions},
+		}}
+	}
+	installed := map[string]interface{}{"type": "Installed", "status": "True"}
+	healthy := map[string]interface{}{"type": "Healthy", "status": "True"}
+	unhealthy := map[string]interface{}{"type": "Healthy", "status": "False", "message": "post establish runtime hook failed"}
+
+	dc := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{Providers: "ProviderList"},
+		provider("provider-nop", "xpkg.upbound.io/crossplane-contrib/provider-nop:v0.2.1", installed, healthy),
+		provider("provider-helm", "xpkg.upbound.io/crossplane-contrib/provider-helm:v0.19.0", installed, unhealthy),
+	)
+	logger := zap.NewNop().Sugar()
+
+	if err := WaitHealthy(context.Background(), dc, Providers, []string{"crossplane-contrib/provider-nop:v0.2.1"}, time.Second, logger); err != nil {
+		t.Errorf("expected healthy provider matched by source: %v", err)
+	}
+
+	err := WaitHealthy(context.Background(), dc, Providers, []string{"provider-nop", "provider-helm", "provider-missing"}, 100*time.Millisecond, logger)
+	if err == nil {
+		t.Fatal("expected timeout error")
+	}
+	for _, part := range []string{"provider-helm (Healthy=False: post establish runtime hook failed)", "provider-missing (not found)"} {
+		if !strings.Contains(err.Error(), part) {
+			t.Errorf("expected %q in %q", part, err.Error())
+		}
+	}
+	if strings.Contains(err.Error(), "provider-nop") {
+		t.Errorf("healthy provider reported as failing: %v", err)
+	}
+}
+
+func TestWaitHealthyRewatch(t *testing.T) {
+	provider := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "pkg.crossplane.io/v1",
+		"kind":       "Provider",
+		"metadata":   map[string]interface{}{"name": "provider-nop"},
+		"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Installed", "status": "True"},
+			map[string]interface{}{"type": "Healthy", "status": "True"},
+		}},
+	}}
+	dc := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{Providers: "ProviderList"},
+	)
+	watches := 0
+	dc.PrependWatchReactor("providers", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		watches++
+		w := watch.NewFake()
+		if watches == 1 {
+			w.Stop()
+		} else {
+			go w.Add(provider)
+		}
+		return true, w, nil
+	})
+
+	if err := WaitHealthy(context.Background(), dc, Providers, []string{"provider-nop"}, 0, zap.NewNop().Sugar()); err != nil {
+		t.Fatalf("expected healthy provider after watch closed: %v", err)
+	}
+	if watches != 2 {
+		t.Errorf("expected 2 watches, got %d", watches)
+	}
+}
@@ -0,0 +1,175 @@
+package packages
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// Label of package revisions referencing their package
+	PackageLabel = "pkg.crossplane.io/package"
+
+	ActiveRevision = "Active"
+
+	// Length of image digest prefix in names of revisions
+	revisionDigestLength = 12
+)
+
+// Revision of package from its revision history
+type Revision struct {
+	Name    string
+	Number  int64
+	Image   string
+	State   string
+	Healthy bool
+	Message string
+	Created time.Time
+}
+
+// History lists revisions of package ordered by revision number
+func History(ctx context.Context, dc dynamic.Interface, revisions schema.GroupVersionResource, pkgName string) ([]Revision, error) {
+	list, err := dc.Resource(revisions).List(ctx, metav1.ListOptions{LabelSelector: PackageLabel + "=" + pkgName})
+	if err != nil {
+		return nil, err
+	}
+	history := []Revision{}
+	for _, u := range list.Items {
+		history = append(history, revisionOf(&u))
+	}
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Number < history[j].Number
+	})
+	return history, nil
+}
+
+// RollbackTarget selects revision to roll back to, the latest revision
+// preceding the active one if number is zero
+func RollbackTarget(history []Revision, number int64) (*Revision, error) {
+	if number != 0 {
+		for i := range history {
+			if history[i].Number == number {
+				if history[i].State == ActiveRevision {
+					return nil, fmt.Errorf("revision %d is already active", number)
+				}
+				return &history[i], nil
+			}
+		}
+		return nil, fmt.Errorf("revision %d not found", number)
+	}
+
+	var active int64
+	for _, rev := range history {
+		if rev.State == ActiveRevision {
+			active = rev.Number
+		}
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Number < active {
+			return &history[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no previous revision found")
+}
+
+// DigestResolver resolves digest of package image in its registry
+type DigestResolver func(ctx context.Context, source string) (string, error)
+
+// PinnedImage returns image of revision pinned to its digest, so a moved tag
+// cannot roll package back to other content. Names of revisions end with
+// prefix of image digest, which is checked against digest resolved for tag.
+func PinnedImage(ctx context.Context, rev Revision, digest DigestResolver) (string, error) {
+	ref, err := name.ParseReference(rev.Image, name.WithDefaultRegistry(""))
+	if err != nil {
+		return "", err
+	}
+	if _, ok := ref.(name.Digest); ok {
+		return rev.Image, nil
+	}
+	resolved, err := digest(ctx, rev.Image)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve digest of %s: %w", rev.Image, err)
+	}
+	if prefix := revisionDigest(rev.Name); prefix != "" && !strings.HasPrefix(strings.TrimPrefix(resolved, "sha256:"), prefix) {
+		return "", fmt.Errorf("%s no longer refers to image of revision %d", rev.Image, rev.Number)
+	}
+	return ref.Context().String() + "@" + resolved, nil
+}
+
+// Digest prefix of revision name, empty if name has no digest suffix
+func revisionDigest(revision string) string {
+	i := strings.LastIndex(revision, "-")
+	if i < 0 {
+		return ""
+	}
+	suffix := revision[i+1:]
+	if len(suffix) != revisionDigestLength {
+		return ""
+	}
+	if _, err := hex.DecodeString(suffix); err != nil {
+		return ""
+	}
+	return suffix
+}
+
+// Rollback re-points package to digest of previous revision image and waits
+// until the revision is healthy. Revision activation policy is set to
+// Automatic, so the previous revision is activated by package manager.
+// Packages installed with release are rolled back in release.
+func Rollback(ctx context.Context, dc dynamic.Interface, client kubernetes.Interface, gvr schema.GroupVersionResource, revisions schema.GroupVersionResource, pkgName string, number int64, digest DigestResolver, release ReleaseUpdater, timeout time.Duration, logger *zap.SugaredLogger) error {
+	history, err := History(ctx, dc, revisions, pkgName)
+	if err != nil {
+		return err
+	}
+	target, err := RollbackTarget(history, number)
+	if err != nil {
+		return fmt.Errorf("cannot roll back %s: %w", pkgName, err)
+	}
+	pkg, err := dc.Resource(gvr).Get(ctx, pkgName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	source, _, _ := unstructured.NestedString(pkg.Object, "spec", "package")
+	image, err := PinnedImage(ctx, *target, digest)
+	if err != nil {
+		return fmt.Errorf("cannot roll back %s: %w", pkgName, err)
+	}
+
+	logger.Infof("Rolling back %s to revision %d (%s)", pkgName, target.Number, image)
+	if err := setSource(ctx, dc, gvr, pkgName, source, image, "Automatic", release); err != nil {
+		return err
+	}
+
+	if _, err := WaitRevision(ctx, dc, client, revisions, image, timeout, logger); err != nil {
+		return err
+	}
+	logger.Infof("%s rolled back, revision %s is healthy.", pkgName, target.Name)
+	return nil
+}
+
+func revisionOf(u *unstructured.Unstructured) Revision {
+	number, _, _ := unstructured.NestedInt64(u.Object, "spec", "revision")
+	image, _, _ := unstructured.NestedString(u.Object, "spec", "image")
+	state, _, _ := unstructured.NestedString(u.Object, "spec", "desiredState")
+	healthy, message := RevisionHealth(u)
+	return Revision{
+		Name:    u.GetName(),
+		Number:  number,
+		Image:   image,
+		State:   state,
+		Healthy: healthy,
+		Message: message,
+		Created: u.GetCreationTimestamp().Time,
+	}
+}
@@ -0,0 +1,100 @@
+package packages
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestHistory(t *testing.T) {
+	revision := func(name string, number int64, state string) runtime.Object {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "pkg.crossplane.io/v1",
+			"kind":       "ProviderRevision",
+			"metadata": map[string]interface{}{
+				"name":   name,
+				"labels": map[string]interface{}{PackageLabel: "provider-nop"},
+			},
+			"spec": map[string]interface{}{
+				"revision":     number,
+				"image":        "xpkg.upbound.io/crossplane-contrib/provider-nop:v0." + name[len(name)-1:] + ".0",
+				"desiredState": state,
+			},
+		}}
+	}
+	dc := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{ProviderRevisions: "ProviderRevisionList"},
+		revision("provider-nop-3", 3, "Active"),
+		revision("provider-nop-1", 1, "Inactive"),
+		revision("provider-nop-2", 2, "Inactive"),
+	)
+
+	history, err := History(context.Background(), dc, ProviderRevisions, "provider-nop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 || history[0].Number != 1 || history[2].State != ActiveRevision {
+		t.Fatalf("unexpected history %+v", history)
+	}
+
+	target, err := RollbackTarget(history, 0)
+	if err != nil || target.Number != 2 {
+		t.Errorf("expected rollback to revision 2, got %+v, %v", target, err)
+	}
+	if target, err := RollbackTarget(history, 1); err != nil || target.Image != "xpkg.upbound.io/crossplane-contrib/provider-nop:v0.1.0" {
+		t.Errorf("expected rollback to revision 1, got %+v, %v", target, err)
+	}
+	if _, err := RollbackTarget(history, 3); err == nil {
+		t.Error("expected error for active revision")
+	}
+	if _, err := RollbackTarget(history, 4); err == nil {
+		t.Error("expected error for unknown revision")
+	}
+}
+
+func TestPinnedImage(t *testing.T) {
+	digest := "sha256:0123456789ab" + strings.Repeat("c", 52)
+	resolve := func(ctx context.Context, source string) (string, error) {
+		return digest, nil
+	}
+	cases := map[string]struct {
+		rev     Revision
+		want    string
+		wantErr bool
+	}{
+		"Tag": {
+			rev:  Revision{Name: "provider-nop-0123456789ab", Image: "xpkg.upbound.io/crossplane-contrib/provider-nop:v0.1.0"},
+			want: "xpkg.upbound.io/crossplane-contrib/provider-nop@" + digest,
+		},
+		"Digest": {
+			rev:  Revision{Name: "provider-nop-0123456789ab", Image: "xpkg.upbound.io/crossplane-contrib/provider-nop@" + digest},
+			want: "xpkg.upbound.io/crossplane-contrib/provider-nop@" + digest,
+		},
+		"MovedTag": {
+			rev:     Revision{Name: "provider-nop-ba9876543210", Image: "xpkg.upbound.io/crossplane-contrib/provider-nop:v0.1.0"},
+			wantErr: true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := PinnedImage(context.Background(), tc.rev, resolve)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("PinnedImage() = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
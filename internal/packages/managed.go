@@ -0,0 +1,149 @@
+package packages
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+)
+
+// Category of CRDs of Crossplane managed resources
+const ManagedCategory = "managed"
+
+// Interval of checks whether deleted managed resources are gone
+const deletePollInterval = 2 * time.Second
+
+var CustomResourceDefinitions = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// ManagedResource of package with resource of its type
+type ManagedResource struct {
+	Resource schema.GroupVersionResource
+	unstructured.Unstructured
+}
+
+func (m ManagedResource) String() string {
+	return m.GetKind() + "/" + m.GetName()
+}
+
+// ManagedResources lists managed resources of CRDs owned by revisions of package
+func ManagedResources(ctx context.Context, dc dynamic.Interface, revisions schema.GroupVersionResource, pkgName string) ([]ManagedResource, error) {
+	history, err := History(ctx, dc, revisions, pkgName)
+	if err != nil {
+		return nil, err
+	}
+	owners := map[string]bool{}
+	for _, rev := range history {
+		owners[rev.Name] = true
+	}
+
+	crds, err := dc.Resource(CustomResourceDefinitions).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	managed := []ManagedResource{}
+	for _, crd := range crds.Items {
+		owned := false
+		for _, owner := range crd.GetOwnerReferences() {
+			owned = owned || owners[owner.Name]
+		}
+		categories, _, _ := unstructured.NestedStringSlice(crd.Object, "spec", "names", "categories")
+		if !owned || !slices.Contains(categories, ManagedCategory) {
+			continue
+		}
+		gvr, ok := StorageResource(&crd)
+		if !ok {
+			continue
+		}
+		list, err := dc.Resource(gvr).List(ctx, metav1.ListOptions{})
+		if kerrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot list %s: %w", gvr.GroupResource(), err)
+		}
+		for _, u := range list.Items {
+			managed = append(managed, ManagedResource{Resource: gvr, Unstructured: u})
+		}
+	}
+	sort.Slice(managed, func(i, j int) bool { return managed[i].String() < managed[j].String() })
+	return managed, nil
+}
+
+// Resource of CRD storage version
+func StorageResource(crd *unstructured.Unstructured) (schema.GroupVersionResource, bool) {
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	plural, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	for _, v := range versions {
+		version, ok := v.(map[string]interface{})
+		if !ok || version["storage"] != true {
+			continue
+		}
+		return schema.GroupVersionResource{Group: group, Version: fmt.Sprint(version["name"]), Resource: plural}, true
+	}
+	return schema.GroupVersionResource{}, false
+}
+
+// DeleteManaged deletes managed resources and waits until they are gone, so
+// provider can still remove external resources and finalizers. Zero timeout
+// waits without limit.
+func DeleteManaged(ctx context.Context, dc dynamic.Interface, managed []ManagedResource, timeout time.Duration, logger *zap.SugaredLogger) error {
+	for _, m := range managed {
+		err := dc.Resource(m.Resource).Namespace(m.GetNamespace()).Delete(ctx, m.GetName(), metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("cannot delete %s: %w", m, err)
+		}
+		logger.Debugf("Managed resource %s deleted.", m)
+	}
+	logger.Infof("Waiting for %d managed resource(s) to be removed.", len(managed))
+
+	remaining := managed
+	poll := func(ctx context.Context) (bool, error) {
+		left := []ManagedResource{}
+		for _, m := range remaining {
+			_, err := dc.Resource(m.Resource).Namespace(m.GetNamespace()).Get(ctx, m.GetName(), metav1.GetOptions{})
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return false, err
+			}
+			left = append(left, m)
+		}
+		remaining = left
+		return len(remaining) == 0, nil
+	}
+	var err error
+	if timeout > 0 {
+		err = wait.PollUntilContextTimeout(ctx, deletePollInterval, timeout, true, poll)
+	} else {
+		err = wait.PollUntilContextCancel(ctx, deletePollInterval, true, poll)
+	}
+	if err != nil && len(remaining) > 0 && ctx.Err() == nil {
+		return fmt.Errorf("managed resources not removed after %s: %s", timeout, ManagedSummary(remaining, 10))
+	}
+	return err
+}
+
+// ManagedSummary lists up to limit managed resources
+func ManagedSummary(managed []ManagedResource, limit int) string {
+	names := []string{}
+	for i, m := range managed {
+		if i == limit {
+			names = append(names, fmt.Sprintf("and %d more", len(managed)-limit))
+			break
+		}
+		names = append(names, m.String())
+	}
+	return strings.Join(names, ", ")
+}
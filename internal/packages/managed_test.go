@@ -0,0 +1,78 @@
+package packages
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestManagedResources(t *testing.T) {
+	crd := func(plural string, group string, owner string, categories ...interface{}) runtime.Object {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apiextensions.k8s.io/v1",
+			"kind":       "CustomResourceDefinition",
+			"metadata": map[string]interface{}{
+				"name":            plural + "." + group,
+				"ownerReferences": []interface{}{map[string]interface{}{"kind": "ProviderRevision", "name": owner}},
+			},
+			"spec": map[string]interface{}{
+				"group":    group,
+				"names":    map[string]interface{}{"plural": plural, "categories": categories},
+				"versions": []interface{}{map[string]interface{}{"name": "v1beta1", "storage": true}},
+			},
+		}}
+	}
+	object := func(apiVersion string, kind string, name string, labels map[string]interface{}) runtime.Object {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata":   map[string]interface{}{"name": name, "labels": labels},
+		}}
+	}
+	namespaced := object("s3.aws.upbound.io/v1beta1", "Bucket", "cache", nil).(*unstructured.Unstructured)
+	namespaced.SetNamespace("team")
+	buckets := schema.GroupVersionResource{Group: "s3.aws.upbound.io", Version: "v1beta1", Resource: "buckets"}
+	configs := schema.GroupVersionResource{Group: "aws.upbound.io", Version: "v1beta1", Resource: "providerconfigs"}
+	dc := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		ProviderRevisions:         "ProviderRevisionList",
+		CustomResourceDefinitions: "CustomResourceDefinitionList",
+		buckets:                   "BucketList",
+		configs:                   "ProviderConfigList",
+	},
+		object("pkg.crossplane.io/v1", "ProviderRevision", "provider-aws-s3-1a2b", map[string]interface{}{PackageLabel: "provider-aws-s3"}),
+		crd("buckets", "s3.aws.upbound.io", "provider-aws-s3-1a2b", "crossplane", "managed", "aws"),
+		crd("providerconfigs", "aws.upbound.io", "provider-aws-s3-1a2b", "crossplane", "providerconfig", "aws"),
+		object("s3.aws.upbound.io/v1beta1", "Bucket", "logs", nil),
+		object("s3.aws.upbound.io/v1beta1", "Bucket", "data", nil),
+		namespaced,
+		object("aws.upbound.io/v1beta1", "ProviderConfig", "default", nil),
+	)
+	ctx := context.Background()
+
+	managed, err := ManagedResources(ctx, dc, ProviderRevisions, "provider-aws-s3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary := ManagedSummary(managed, 1); summary != "Bucket/cache, and 2 more" {
+		t.Errorf("unexpected managed resources %q", summary)
+	}
+
+	if err := DeleteManaged(ctx, dc, managed, time.Second, zap.NewNop().Sugar()); err != nil {
+		t.Fatal(err)
+	}
+	managed, err = ManagedResources(ctx, dc, ProviderRevisions, "provider-aws-s3")
+	if err != nil || len(managed) != 0 {
+		t.Errorf("expected managed resources deleted, got %v %v", managed, err)
+	}
+
+	none, err := ManagedResources(ctx, dc, ProviderRevisions, "provider-helm")
+	if err != nil || len(none) != 0 {
+		t.Errorf("expected no managed resources of other provider, got %v %v", none, err)
+	}
+}
@@ -0,0 +1,33 @@
+package packages
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// ReadMeta finds YAML file with package meta of kind in directory and decodes it to obj
+func ReadMeta(dir string, kind string, obj interface{}) error {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range files {
+		if !e.Type().IsRegular() || filepath.Ext(e.Name()) != ".yaml" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return err
+		}
+		res := &metav1.TypeMeta{}
+		if err := yaml.Unmarshal(content, res); err != nil || res.Kind != kind {
+			continue
+		}
+		return yaml.Unmarshal(content, obj)
+	}
+	return fmt.Errorf("%s package meta not found in %s", kind, dir)
+}
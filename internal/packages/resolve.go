@@ -0,0 +1,360 @@
+package packages
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	semver "github.com/Masterminds/semver/v3"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/web-seven/overlock/internal/image"
+)
+
+// Dependency declared in spec.dependsOn of package meta
+type Dependency struct {
+	Package    string
+	Kind       string
+	Constraint string
+}
+
+// ResolvedPackage is a package pulled by resolver with its exact version
+type ResolvedPackage struct {
+	// Repository of package without tag or digest
+	Repository string
+	// Resolved tag of package, empty if package is referenced by digest
+	Tag    string
+	Digest v1.Hash
+	Kind   string
+	// Runtime images referenced by package meta (e.g. provider controller image)
+	RuntimeImages []string
+	Dependencies  []Dependency
+	Image         v1.Image
+}
+
+// Source returns fully qualified reference of resolved package
+func (p ResolvedPackage) Source() string {
+	if p.Tag != "" {
+		return p.Repository + tagDelim + p.Tag
+	}
+	return p.Repository + "@" + p.Digest.String()
+}
+
+// PackageRegistry lists tags of package repositories and reads packages
+type PackageRegistry interface {
+	Tags(ctx context.Context, repository name.Repository) ([]string, error)
+	// Package returns package meta and digest of package image, image is
+	// nil if it cannot be read after the call
+	Package(ctx context.Context, ref name.Reference) (v1.Image, *unstructured.Unstructured, v1.Hash, error)
+}
+
+// Resolver walks dependency tree of packages using package metadata
+type Resolver struct {
+	defaultRegistry string
+	registry        PackageRegistry
+	logger          *zap.SugaredLogger
+	// Packages loaded from archives by repository name
+	local map[string]localPackage
+}
+
+type localPackage struct {
+	tag   string
+	image v1.Image
+}
+
+// NewResolver creates resolver, package names without registry host are
+// resolved against defaultRegistry
+func NewResolver(defaultRegistry string, logger *zap.SugaredLogger, opts ...remote.Option) *Resolver {
+	return &Resolver{
+		defaultRegistry: defaultRegistry,
+		registry:        remoteRegistry{opts: opts},
+		logger:          logger,
+		local:           map[string]localPackage{},
+	}
+}
+
+// WithRegistry makes resolver read packages from registry instead of pulling
+// them with remote options
+func (r *Resolver) WithRegistry(registry PackageRegistry) {
+	r.registry = registry
+}
+
+// WithImage makes resolver use package image loaded from archive, instead of
+// pulling it, when ref or a dependency on its repository is resolved
+func (r *Resolver) WithImage(ref string, img v1.Image) error {
+	tag, err := name.NewTag(ref, name.WithDefaultRegistry(r.defaultRegistry))
+	if err != nil {
+		return err
+	}
+	r.local[tag.Context().Name()] = localPackage{tag: tag.TagStr(), image: img}
+	return nil
+}
+
+// Resolve pulls packages and all their transitive dependencies. Every
+// repository is resolved once, to the version requested first, other
+// requested versions not satisfied by it are reported as warnings.
+func (r *Resolver) Resolve(ctx context.Context, refs []string) ([]ResolvedPackage, error) {
+	resolved := []ResolvedPackage{}
+	seen := map[string]int{}
+	queue := []Dependency{}
+	for _, ref := range refs {
+		queue = append(queue, Dependency{Package: ref})
+	}
+
+	for len(queue) > 0 {
+		dep := queue[0]
+		queue = queue[1:]
+
+		repository, err := name.NewRepository(r.repositoryName(dep.Package), name.WithDefaultRegistry(r.defaultRegistry))
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := seen[repository.Name()]; ok {
+			if !satisfies(resolved[i].Tag, dep.Constraint) {
+				r.logger.Warnf("Package %s resolved to %s which does not satisfy constraint %s", repository.Name(), resolved[i].Source(), dep.Constraint)
+			}
+			continue
+		}
+
+		pkg, err := r.resolve(ctx, dep, repository)
+		if err != nil {
+			return nil, err
+		}
+		r.logger.Debugf("Resolved package %s", pkg.Source())
+
+		seen[repository.Name()] = len(resolved)
+		resolved = append(resolved, *pkg)
+		queue = append(queue, pkg.Dependencies...)
+	}
+	return resolved, nil
+}
+
+func (r *Resolver) resolve(ctx context.Context, dep Dependency, repository name.Repository) (*ResolvedPackage, error) {
+	img, meta, digest, tag, err := r.pull(ctx, dep, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &ResolvedPackage{
+		Repository:   repository.Name(),
+		Tag:          tag,
+		Digest:       digest,
+		Kind:         meta.GetKind(),
+		Dependencies: Dependencies(meta),
+		Image:        img,
+	}
+	if controllerImage, found, _ := unstructured.NestedString(meta.Object, "spec", "controller", "image"); found && controllerImage != "" {
+		pkg.RuntimeImages = append(pkg.RuntimeImages, controllerImage)
+	}
+	return pkg, nil
+}
+
+// Image of package with its meta, digest and resolved tag, tag is empty for
+// digest references
+func (r *Resolver) pull(ctx context.Context, dep Dependency, repository name.Repository) (v1.Image, *unstructured.Unstructured, v1.Hash, string, error) {
+	if local, ok := r.local[repository.Name()]; ok {
+		img, meta, digest, err := readPackage(local.image)
+		if err != nil {
+			return nil, nil, v1.Hash{}, "", fmt.Errorf("cannot read package metadata of %s: %w", repository.Name(), err)
+		}
+		return img, meta, digest, local.tag, nil
+	}
+	var ref name.Reference
+	identifier := r.identifier(dep)
+	tag := ""
+	switch {
+	case strings.HasPrefix(identifier, "sha256:"):
+		digest, err := name.NewDigest(repository.Name() + "@" + identifier)
+		if err != nil {
+			return nil, nil, v1.Hash{}, "", err
+		}
+		ref = digest
+	default:
+		tags, err := r.registry.Tags(ctx, repository)
+		if err != nil {
+			return nil, nil, v1.Hash{}, "", fmt.Errorf("cannot list tags of %s: %w", repository.Name(), err)
+		}
+		tag, err = ResolveVersion(tags, identifier)
+		if err != nil {
+			return nil, nil, v1.Hash{}, "", fmt.Errorf("cannot resolve version of %s: %w", repository.Name(), err)
+		}
+		ref = repository.Tag(tag)
+	}
+
+	img, meta, digest, err := r.registry.Package(ctx, ref)
+	if err != nil {
+		return nil, nil, v1.Hash{}, "", fmt.Errorf("cannot pull %s: %w", ref.String(), err)
+	}
+	return img, meta, digest, tag, nil
+}
+
+// Registry pulling packages with remote options
+type remoteRegistry struct {
+	opts []remote.Option
+}
+
+func (r remoteRegistry) Tags(ctx context.Context, repository name.Repository) ([]string, error) {
+	return remote.List(repository, append(r.opts, remote.WithContext(ctx))...)
+}
+
+func (r remoteRegistry) Package(ctx context.Context, ref name.Reference) (v1.Image, *unstructured.Unstructured, v1.Hash, error) {
+	img, err := remote.Image(ref, append(r.opts, remote.WithContext(ctx))...)
+	if err != nil {
+		return nil, nil, v1.Hash{}, err
+	}
+	return readPackage(img)
+}
+
+// Image of package with its meta and digest
+func readPackage(img v1.Image) (v1.Image, *unstructured.Unstructured, v1.Hash, error) {
+	digest, err := img.Digest()
+	if err != nil {
+		return nil, nil, v1.Hash{}, err
+	}
+	meta, err := image.PackageMeta(img)
+	if err != nil {
+		return nil, nil, v1.Hash{}, err
+	}
+	return img, meta, digest, nil
+}
+
+// Repository part of package name, tag and digest are cut
+func (r *Resolver) repositoryName(pkg string) string {
+	repo, _ := SplitVersion(pkg)
+	return repo
+}
+
+// Requested version of dependency, tag or digest of package name takes precedence over constraint
+func (r *Resolver) identifier(dep Dependency) string {
+	if _, identifier := SplitVersion(dep.Package); identifier != "" {
+		return identifier
+	}
+	return dep.Constraint
+}
+
+// SplitVersion splits package name to repository and tag or digest,
+// identifier is empty if package name has neither
+func SplitVersion(pkg string) (string, string) {
+	if repo, digest, found := strings.Cut(pkg, "@"); found {
+		return repo, digest
+	}
+	if i := strings.LastIndex(pkg, tagDelim); i > strings.LastIndex(pkg, "/") {
+		return pkg[:i], pkg[i+1:]
+	}
+	return pkg, ""
+}
+
+// Dependencies declared in package meta
+func Dependencies(meta *unstructured.Unstructured) []Dependency {
+	deps := []Dependency{}
+	dependsOn, _, _ := unstructured.NestedSlice(meta.Object, "spec", "dependsOn")
+	for _, d := range dependsOn {
+		entry, ok := d.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		dep := Dependency{}
+		dep.Constraint, _ = entry["version"].(string)
+		for _, kind := range []string{"provider", "configuration", "function"} {
+			if pkg, ok := entry[kind].(string); ok {
+				dep.Package = pkg
+				dep.Kind = strings.ToUpper(kind[:1]) + kind[1:]
+			}
+		}
+		if pkg, ok := entry["package"].(string); ok {
+			dep.Package = pkg
+			dep.Kind, _ = entry["kind"].(string)
+		}
+		if dep.Package != "" {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// RewriteDependencies replaces package names of dependencies and controller
+// image in package meta, rewrite returns new name and true for names to replace.
+// Reports if meta was changed.
+func RewriteDependencies(meta *unstructured.Unstructured, rewrite func(pkg string) (string, bool)) bool {
+	changed := false
+	dependsOn, _, _ := unstructured.NestedSlice(meta.Object, "spec", "dependsOn")
+	for _, d := range dependsOn {
+		entry, ok := d.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, key := range []string{"provider", "configuration", "function", "package"} {
+			if pkg, ok := entry[key].(string); ok {
+				if rewritten, ok := rewrite(pkg); ok {
+					entry[key] = rewritten
+					changed = true
+				}
+			}
+		}
+	}
+	if changed {
+		_ = unstructured.SetNestedSlice(meta.Object, dependsOn, "spec", "dependsOn")
+	}
+	if controllerImage, found, _ := unstructured.NestedString(meta.Object, "spec", "controller", "image"); found {
+		if rewritten, ok := rewrite(controllerImage); ok {
+			_ = unstructured.SetNestedField(meta.Object, rewritten, "spec", "controller", "image")
+			changed = true
+		}
+	}
+	return changed
+}
+
+// ResolveVersion returns highest tag satisfying semver constraint. Tag equal
+// to constraint is returned as is, empty constraint resolves to the highest version.
+func ResolveVersion(tags []string, constraint string) (string, error) {
+	for _, tag := range tags {
+		if tag == constraint {
+			return tag, nil
+		}
+	}
+	if constraint == "" {
+		constraint = "*"
+	}
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return "", err
+	}
+
+	versions := []*semver.Version{}
+	original := map[*semver.Version]string{}
+	for _, tag := range tags {
+		v, err := semver.NewVersion(tag)
+		if err != nil {
+			continue
+		}
+		if c.Check(v) {
+			versions = append(versions, v)
+			original[v] = tag
+		}
+	}
+	if len(versions) == 0 {
+		return "", fmt.Errorf("no version satisfies constraint %s", constraint)
+	}
+	sort.Sort(sort.Reverse(semver.Collection(versions)))
+	return original[versions[0]], nil
+}
+
+func satisfies(tag string, constraint string) bool {
+	if constraint == "" || tag == constraint {
+		return true
+	}
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return false
+	}
+	v, err := semver.NewVersion(tag)
+	if err != nil {
+		return false
+	}
+	return c.Check(v)
+}
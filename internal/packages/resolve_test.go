@@ -0,0 +1,151 @@
+package packages
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestResolveVersion(t *testing.T) {
+	tags := []string{"v0.9.0", "v1.0.0", "v1.2.0", "v1.10.1", "v2.0.0", "latest"}
+
+	cases := map[string]struct {
+		constraint string
+		want       string
+		err        bool
+	}{
+		"Exact":      {constraint: "v1.2.0", want: "v1.2.0"},
+		"NonSemver":  {constraint: "latest", want: "latest"},
+		"Range":      {constraint: ">=v1.0.0, <v2.0.0", want: "v1.10.1"},
+		"Empty":      {constraint: "", want: "v2.0.0"},
+		"NoMatch":    {constraint: ">=v3.0.0", err: true},
+		"Invalid":    {constraint: "not a constraint", err: true},
+		"CaretMinor": {constraint: "^v1.2", want: "v1.10.1"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ResolveVersion(tags, tc.constraint)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("ResolveVersion(%q) = %q, want %q", tc.constraint, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	meta := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"dependsOn": []interface{}{
+				map[string]interface{}{"provider": "xpkg.upbound.io/crossplane-contrib/provider-aws", "version": ">=v0.1.0"},
+				map[string]interface{}{"function": "xpkg.upbound.io/crossplane-contrib/function-patch-and-transform", "version": "v0.2.1"},
+				map[string]interface{}{"package": "xpkg.upbound.io/acme/configuration-base", "kind": "Configuration", "version": "v1.0.0"},
+			},
+		},
+	}}
+
+	deps := Dependencies(meta)
+	if len(deps) != 3 {
+		t.Fatalf("expected 3 dependencies, got %d", len(deps))
+	}
+	if deps[0].Kind != "Provider" || deps[0].Constraint != ">=v0.1.0" {
+		t.Errorf("unexpected provider dependency %+v", deps[0])
+	}
+	if deps[1].Kind != "Function" {
+		t.Errorf("unexpected function dependency %+v", deps[1])
+	}
+	if deps[2].Kind != "Configuration" || deps[2].Package != "xpkg.upbound.io/acme/configuration-base" {
+		t.Errorf("unexpected package dependency %+v", deps[2])
+	}
+}
+
+func TestRewriteDependencies(t *testing.T) {
+	meta := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"controller": map[string]interface{}{"image": "xpkg.upbound.io/acme/provider-aws-controller:v1.0.0"},
+			"dependsOn": []interface{}{
+				map[string]interface{}{"provider": "xpkg.upbound.io/acme/provider-aws", "version": ">=v0.1.0"},
+				map[string]interface{}{"function": "ghcr.io/acme/function-patch", "version": "v0.2.1"},
+			},
+		},
+	}}
+	rewrite := func(pkg string) (string, bool) {
+		repo, found := strings.CutPrefix(pkg, "xpkg.upbound.io/")
+		return "registry.local/" + repo, found
+	}
+
+	if !RewriteDependencies(meta, rewrite) {
+		t.Fatal("expected meta to be changed")
+	}
+	deps := Dependencies(meta)
+	if deps[0].Package != "registry.local/acme/provider-aws" || deps[0].Constraint != ">=v0.1.0" {
+		t.Errorf("unexpected provider dependency %+v", deps[0])
+	}
+	if deps[1].Package != "ghcr.io/acme/function-patch" {
+		t.Errorf("unexpected function dependency %+v", deps[1])
+	}
+	if image, _, _ := unstructured.NestedString(meta.Object, "spec", "controller", "image"); image != "registry.local/acme/provider-aws-controller:v1.0.0" {
+		t.Errorf("unexpected controller image %q", image)
+	}
+	if RewriteDependencies(meta, rewrite) {
+		t.Error("expected rewritten meta to be unchanged")
+	}
+}
+
+// Registry of package metas by repository, every repository has one tag
+type fakeRegistry map[string]*unstructured.Unstructured
+
+func (r fakeRegistry) Tags(ctx context.Context, repository name.Repository) ([]string, error) {
+	return []string{"v1.0.0"}, nil
+}
+
+func (r fakeRegistry) Package(ctx context.Context, ref name.Reference) (v1.Image, *unstructured.Unstructured, v1.Hash, error) {
+	return nil, r[ref.Context().Name()], v1.Hash{Algorithm: "sha256", Hex: strings.Repeat("a", 64)}, nil
+}
+
+func TestResolveWithRegistry(t *testing.T) {
+	meta := func(kind string, deps ...string) *unstructured.Unstructured {
+		dependsOn := []interface{}{}
+		for _, dep := range deps {
+			dependsOn = append(dependsOn, map[string]interface{}{"provider": dep, "version": ">=v1.0.0"})
+		}
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"kind": kind,
+			"spec": map[string]interface{}{"dependsOn": dependsOn},
+		}}
+	}
+	registry := fakeRegistry{
+		"xpkg.upbound.io/acme/platform":   meta("Configuration", "acme/provider-a", "acme/provider-b"),
+		"xpkg.upbound.io/acme/provider-a": meta("Provider", "acme/provider-b"),
+		"xpkg.upbound.io/acme/provider-b": meta("Provider"),
+	}
+	resolver := NewResolver("xpkg.upbound.io", zap.NewNop().Sugar())
+	resolver.WithRegistry(registry)
+
+	resolved, err := resolver.Resolve(context.Background(), []string{"acme/platform:v1.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resolved) != 3 {
+		t.Fatalf("expected 3 packages, got %d", len(resolved))
+	}
+	for _, pkg := range resolved {
+		if pkg.Tag != "v1.0.0" || registry[pkg.Repository].GetKind() != pkg.Kind {
+			t.Errorf("unexpected resolved package %+v", pkg)
+		}
+	}
+}
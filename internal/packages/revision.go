@@ -0,0 +1,203 @@
+package packages
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/web-seven/overlock/internal/namespace"
+)
+
+const (
+	// Label of package runtime pods referencing their revision
+	RevisionLabel = "pkg.crossplane.io/revision"
+
+	DefaultRevisionTimeout = 5 * time.Minute
+)
+
+// Interval of checking runtime pods of revision, pods may start crashlooping
+// without revision change
+var crashLoopInterval = 5 * time.Second
+
+var (
+	ProviderRevisions      = schema.GroupVersionResource{Group: "pkg.crossplane.io", Version: "v1", Resource: "providerrevisions"}
+	FunctionRevisions      = schema.GroupVersionResource{Group: "pkg.crossplane.io", Version: "v1beta1", Resource: "functionrevisions"}
+	ConfigurationRevisions = schema.GroupVersionResource{Group: "pkg.crossplane.io", Version: "v1", Resource: "configurationrevisions"}
+
+	// Health conditions of package revisions across Crossplane versions
+	healthConditions = []string{"Healthy", "RevisionHealthy", "RuntimeHealthy"}
+)
+
+// WaitRevision waits until revision of package image becomes healthy and
+// returns it. Unhealthy conditions are logged as they change, crashlooping
+// runtime pods fail the wait immediately.
+func WaitRevision(ctx context.Context, dc dynamic.Interface, client kubernetes.Interface, gvr schema.GroupVersionResource, image string, timeout time.Duration, logger *zap.SugaredLogger) (*unstructured.Unstructured, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var (
+		mu          sync.Mutex
+		revision    *unstructured.Unstructured
+		lastMessage string
+	)
+	update := func(u *unstructured.Unstructured) (bool, error) {
+		if !matchImage(u, image) {
+			return false, nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		revision = u
+		healthy, message := RevisionHealth(u)
+		if healthy {
+			return true, nil
+		}
+		if message != "" && message != lastMessage {
+			logger.Warnf("Revision %s is not healthy: %s", u.GetName(), message)
+			lastMessage = message
+		}
+		if client != nil {
+			return false, crashLoop(ctx, client, u.GetName())
+		}
+		return false, nil
+	}
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- watchObjects(ctx, dc, gvr, update, logger)
+	}()
+
+	ticker := time.NewTicker(crashLoopInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-errChan:
+			mu.Lock()
+			defer mu.Unlock()
+			if errors.Is(err, context.DeadlineExceeded) {
+				if revision == nil {
+					return nil, fmt.Errorf("revision of %s not found in %s", image, timeout)
+				}
+				return revision, fmt.Errorf("revision %s is not healthy after %s: %s", revision.GetName(), timeout, lastMessage)
+			}
+			return revision, err
+		case <-ticker.C:
+			mu.Lock()
+			current := revision
+			mu.Unlock()
+			if client == nil || current == nil {
+				continue
+			}
+			if err := crashLoop(ctx, client, current.GetName()); err != nil {
+				// Stop watch before returning
+				cancel()
+				<-errChan
+				return current, err
+			}
+		}
+	}
+}
+
+// RevisionHealth reports if all health conditions of revision are true and
+// message of the first failing condition otherwise
+func RevisionHealth(u *unstructured.Unstructured) (bool, string) {
+	conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+	found := false
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || !isHealthCondition(condition["type"]) {
+			continue
+		}
+		found = true
+		if condition["status"] != string(corev1.ConditionTrue) {
+			message, _ := condition["message"].(string)
+			reason, _ := condition["reason"].(string)
+			if message == "" {
+				message = reason
+			}
+			return false, message
+		}
+	}
+	return found, ""
+}
+
+// StreamRevisionLogs follows logs of runtime pods of revision until ctx is cancelled
+func StreamRevisionLogs(ctx context.Context, client kubernetes.Interface, revision string, out io.Writer) error {
+	pods, err := client.CoreV1().Pods(namespace.Namespace).List(ctx, metav1.ListOptions{LabelSelector: RevisionLabel + "=" + revision})
+	if err != nil {
+		return err
+	}
+	if len(pods.Items) == 0 {
+		return fmt.Errorf("no pods found for revision %s", revision)
+	}
+
+	errChan := make(chan error, len(pods.Items))
+	for _, pod := range pods.Items {
+		go func(pod string) {
+			stream, err := client.CoreV1().Pods(namespace.Namespace).GetLogs(pod, &corev1.PodLogOptions{Follow: true}).Stream(ctx)
+			if err != nil {
+				errChan <- err
+				return
+			}
+			defer stream.Close()
+			scanner := bufio.NewScanner(stream)
+			for scanner.Scan() {
+				fmt.Fprintf(out, "[%s] %s\n", pod, scanner.Text())
+			}
+			errChan <- scanner.Err()
+		}(pod.GetName())
+	}
+
+	for range pods.Items {
+		if err := <-errChan; err != nil && ctx.Err() == nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Fail if any runtime pod of revision is crashlooping
+func crashLoop(ctx context.Context, client kubernetes.Interface, revision string) error {
+	pods, err := client.CoreV1().Pods(namespace.Namespace).List(ctx, metav1.ListOptions{LabelSelector: RevisionLabel + "=" + revision})
+	if err != nil {
+		return nil
+	}
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Waiting == nil || status.State.Waiting.Reason != "CrashLoopBackOff" {
+				continue
+			}
+			message := status.State.Waiting.Message
+			if terminated := status.LastTerminationState.Terminated; terminated != nil {
+				message = strings.TrimSpace(fmt.Sprintf("exit code %d %s", terminated.ExitCode, terminated.Message))
+			}
+			return fmt.Errorf("pod %s of revision %s is crashlooping: %s", pod.GetName(), revision, message)
+		}
+	}
+	return nil
+}
+
+func matchImage(u *unstructured.Unstructured, image string) bool {
+	revImage, _, _ := unstructured.NestedString(u.Object, "spec", "image")
+	return revImage == image || strings.HasSuffix(revImage, "/"+image)
+}
+
+func isHealthCondition(t interface{}) bool {
+	for _, c := range healthConditions {
+		if t == c {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package packages
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/web-seven/overlock/internal/namespace"
+)
+
+func TestRevisionHealth(t *testing.T) {
+	revision := func(conditions ...interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"status": map[string]interface{}{"conditions": conditions},
+		}}
+	}
+
+	if healthy, _ := RevisionHealth(revision()); healthy {
+		t.Error("revision without conditions must not be healthy")
+	}
+	if healthy, _ := RevisionHealth(revision(map[string]interface{}{"type": "Healthy", "status": "True"})); !healthy {
+		t.Error("expected healthy revision")
+	}
+	healthy, message := RevisionHealth(revision(
+		map[string]interface{}{"type": "RevisionHealthy", "status": "True"},
+		map[string]interface{}{"type": "RuntimeHealthy", "status": "False", "reason": "UnhealthyRuntime", "message": "Deployment does not have minimum availability"},
+	))
+	if healthy || message != "Deployment does not have minimum availability" {
+		t.Errorf("unexpected health %v with message %q", healthy, message)
+	}
+}
+
+func TestWaitRevisionCrashLoop(t *testing.T) {
+	interval := crashLoopInterval
+	crashLoopInterval = 10 * time.Millisecond
+	defer func() { crashLoopInterval = interval }()
+	dc := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{ProviderRevisions: "ProviderRevisionList"})
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      "provider-nop-1234-abcd",
+		Namespace: namespace.Namespace,
+		Labels:    map[string]string{RevisionLabel: "provider-nop-1234"},
+	}}
+	client := kubefake.NewSimpleClientset(pod)
+	revision := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "pkg.crossplane.io/v1",
+		"kind":       "ProviderRevision",
+		"metadata":   map[string]interface{}{"name": "provider-nop-1234"},
+		"spec":       map[string]interface{}{"image": "crossplane-contrib/provider-nop:v0.2.1"},
+	}}
+
+	// Pod starts crashlooping after the last revision event
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_, _ = dc.Resource(ProviderRevisions).Create(context.Background(), revision, metav1.CreateOptions{})
+		time.Sleep(50 * time.Millisecond)
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 2}},
+		}}
+		_, _ = client.CoreV1().Pods(namespace.Namespace).Update(context.Background(), pod, metav1.UpdateOptions{})
+	}()
+
+	_, err := WaitRevision(context.Background(), dc, client, ProviderRevisions, "crossplane-contrib/provider-nop:v0.2.1", 5*time.Second, zap.NewNop().Sugar())
+	if err == nil || !strings.Contains(err.Error(), "is crashlooping: exit code 2") {
+		t.Errorf("expected crashloop error, got %v", err)
+	}
+}
+
+func TestWaitRevisionRewatch(t *testing.T) {
+	revision := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "pkg.crossplane.io/v1",
+		"kind":       "ProviderRevision",
+		"metadata":   map[string]interface{}{"name": "provider-nop-1234"},
+		"spec":       map[string]interface{}{"image": "xpkg.upbound.io/crossplane-contrib/provider-nop:v0.2.1"},
+		"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Healthy", "status": "True"},
+		}},
+	}}
+	dc := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{ProviderRevisions: "ProviderRevisionList"})
+	watches := 0
+	dc.PrependWatchReactor("providerrevisions", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		watches++
+		w := watch.NewFake()
+		if watches == 1 {
+			w.Stop()
+		} else {
+			go w.Add(revision)
+		}
+		return true, w, nil
+	})
+
+	got, err := WaitRevision(context.Background(), dc, nil, ProviderRevisions, "crossplane-contrib/provider-nop:v0.2.1", 5*time.Second, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatalf("expected healthy revision after watch closed: %v", err)
+	}
+	if got.GetName() != "provider-nop-1234" || watches != 2 {
+		t.Errorf("unexpected revision %s after %d watches", got.GetName(), watches)
+	}
+}
@@ -0,0 +1,191 @@
+package packages
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	semver "github.com/Masterminds/semver/v3"
+	"github.com/google/go-containerregistry/pkg/name"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// Version selector of the highest available version
+const LatestVersion = "latest"
+
+var (
+	Providers      = schema.GroupVersionResource{Group: "pkg.crossplane.io", Version: "v1", Resource: "providers"}
+	Configurations = schema.GroupVersionResource{Group: "pkg.crossplane.io", Version: "v1", Resource: "configurations"}
+	Functions      = schema.GroupVersionResource{Group: "pkg.crossplane.io", Version: "v1beta1", Resource: "functions"}
+	RuntimeConfigs = schema.GroupVersionResource{Group: "pkg.crossplane.io", Version: "v1beta1", Resource: "deploymentruntimeconfigs"}
+)
+
+// Versions of installed package available in its repository
+type Versions struct {
+	Current string
+	// Highest version with the same major and minor version as current
+	LatestPatch string
+	Latest      string
+}
+
+// Outdated reports if newer version than current is available
+func (v Versions) Outdated() bool {
+	return v.Latest != "" && v.Latest != v.Current
+}
+
+// TagLister lists tags of package repository
+type TagLister func(ctx context.Context, source string) ([]string, error)
+
+// LatestVersions finds latest patch and latest version for current tag.
+// Pre-releases are considered only if current version is a pre-release.
+func LatestVersions(current string, tags []string) Versions {
+	versions := Versions{Current: current}
+	cur, err := semver.NewVersion(current)
+	if err != nil {
+		cur = nil
+	}
+
+	var latest, latestPatch *semver.Version
+	for _, tag := range tags {
+		v, err := semver.NewVersion(tag)
+		if err != nil {
+			continue
+		}
+		if v.Prerelease() != "" && (cur == nil || cur.Prerelease() == "") {
+			continue
+		}
+		if latest == nil || v.GreaterThan(latest) {
+			latest = v
+			versions.Latest = tag
+		}
+		if cur != nil && v.Major() == cur.Major() && v.Minor() == cur.Minor() && !v.LessThan(cur) {
+			if latestPatch == nil || v.GreaterThan(latestPatch) {
+				latestPatch = v
+				versions.LatestPatch = tag
+			}
+		}
+	}
+	return versions
+}
+
+// SelectVersion returns tag selected by `latest` or by exact version, with or without `v` prefix
+func SelectVersion(tags []string, to string) (string, error) {
+	if to == LatestVersion {
+		latest := LatestVersions("", tags).Latest
+		if latest == "" {
+			return "", fmt.Errorf("no released versions found")
+		}
+		return latest, nil
+	}
+	for _, tag := range tags {
+		if tag == to || strings.TrimPrefix(tag, "v") == strings.TrimPrefix(to, "v") {
+			return tag, nil
+		}
+	}
+	return "", fmt.Errorf("version %s not found", to)
+}
+
+// SourceVersion returns tag of package source, empty if source is pinned to digest
+func SourceVersion(source string) string {
+	ref, err := name.ParseReference(source, name.WithDefaultRegistry(""))
+	if err != nil {
+		return ""
+	}
+	if tag, ok := ref.(name.Tag); ok {
+		return tag.TagStr()
+	}
+	return ""
+}
+
+// WithVersion replaces tag or digest of package source
+func WithVersion(source string, version string) (string, error) {
+	ref, err := name.ParseReference(source, name.WithDefaultRegistry(""))
+	if err != nil {
+		return "", err
+	}
+	return ref.Context().String() + tagDelim + version, nil
+}
+
+// ReleaseUpdater replaces package source in release which installed it,
+// reports false if package is not installed with release
+type ReleaseUpdater func(ctx context.Context, source string, target string) (bool, error)
+
+// Upgrade updates package of kind to selected version and waits until its revision is healthy.
+// Packages installed with release are updated in release, otherwise the
+// next install of release would revert them.
+func Upgrade(ctx context.Context, dc dynamic.Interface, client kubernetes.Interface, gvr schema.GroupVersionResource, revisions schema.GroupVersionResource, pkgName string, to string, tags TagLister, release ReleaseUpdater, timeout time.Duration, logger *zap.SugaredLogger) error {
+	pkg, err := dc.Resource(gvr).Get(ctx, pkgName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	source, _, _ := unstructured.NestedString(pkg.Object, "spec", "package")
+	available, err := tags(ctx, source)
+	if err != nil {
+		return fmt.Errorf("cannot list versions of %s: %w", source, err)
+	}
+	version, err := SelectVersion(available, to)
+	if err != nil {
+		return fmt.Errorf("cannot upgrade %s: %w", pkgName, err)
+	}
+	target, err := WithVersion(source, version)
+	if err != nil {
+		return err
+	}
+	if target == source {
+		logger.Infof("%s is already at %s.", pkgName, version)
+		return nil
+	}
+
+	logger.Infof("Upgrading %s from %s to %s", pkgName, source, target)
+	if err := setSource(ctx, dc, gvr, pkgName, source, target, "", release); err != nil {
+		return err
+	}
+
+	revision, err := WaitRevision(ctx, dc, client, revisions, target, timeout, logger)
+	if err != nil {
+		return err
+	}
+	logger.Infof("%s upgraded, revision %s is healthy.", pkgName, revision.GetName())
+	return nil
+}
+
+// Set source of package in release which installed it, or in package object.
+// Activation policy of package object is set if not empty, also when source
+// is set in release, as release values have no activation policy.
+func setSource(ctx context.Context, dc dynamic.Interface, gvr schema.GroupVersionResource, pkgName string, source string, target string, policy string, release ReleaseUpdater) error {
+	updated := false
+	if release != nil {
+		var err error
+		if updated, err = release(ctx, source, target); err != nil {
+			return err
+		}
+		if updated && policy == "" {
+			return nil
+		}
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pkg, err := dc.Resource(gvr).Get(ctx, pkgName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if !updated {
+			if err := unstructured.SetNestedField(pkg.Object, target, "spec", "package"); err != nil {
+				return err
+			}
+		}
+		if policy != "" {
+			if err := unstructured.SetNestedField(pkg.Object, policy, "spec", "revisionActivationPolicy"); err != nil {
+				return err
+			}
+		}
+		_, err = dc.Resource(gvr).Update(ctx, pkg, metav1.UpdateOptions{})
+		return err
+	})
+}
@@ -0,0 +1,101 @@
+package packages
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestLatestVersions(t *testing.T) {
+	tags := []string{"v0.19.0", "v0.19.2", "v0.19.1", "v0.20.0", "v0.21.0-rc.1", "latest", "main"}
+
+	versions := LatestVersions("v0.19.0", tags)
+	if versions.LatestPatch != "v0.19.2" || versions.Latest != "v0.20.0" {
+		t.Errorf("unexpected versions %+v", versions)
+	}
+	if !versions.Outdated() {
+		t.Error("expected outdated version")
+	}
+
+	versions = LatestVersions("v0.21.0-rc.0", tags)
+	if versions.Latest != "v0.21.0-rc.1" {
+		t.Errorf("expected pre-release for pre-release version, got %+v", versions)
+	}
+
+	if versions := LatestVersions("v0.20.0", tags); versions.Outdated() {
+		t.Errorf("latest version must not be outdated, got %+v", versions)
+	}
+}
+
+func TestSelectVersion(t *testing.T) {
+	tags := []string{"v1.0.0", "v1.1.0", "1.2.0-rc.1"}
+	tests := map[string]string{
+		"latest": "v1.1.0",
+		"1.0.0":  "v1.0.0",
+		"v1.1.0": "v1.1.0",
+	}
+	for to, want := range tests {
+		got, err := SelectVersion(tags, to)
+		if err != nil || got != want {
+			t.Errorf("SelectVersion(%s) = %s, %v, want %s", to, got, err, want)
+		}
+	}
+	if _, err := SelectVersion(tags, "v2.0.0"); err == nil {
+		t.Error("expected error for unknown version")
+	}
+}
+
+func TestWithVersion(t *testing.T) {
+	tests := map[string]string{
+		"xpkg.upbound.io/crossplane-contrib/provider-helm:v0.19.0":           "xpkg.upbound.io/crossplane-contrib/provider-helm:v0.20.0",
+		"crossplane-contrib/provider-helm@sha256:" + strings.Repeat("a", 64): "crossplane-contrib/provider-helm:v0.20.0",
+		"registry.local:5000/provider-helm:v0.19.0":                          "registry.local:5000/provider-helm:v0.20.0",
+	}
+	for source, want := range tests {
+		got, err := WithVersion(source, "v0.20.0")
+		if err != nil || got != want {
+			t.Errorf("WithVersion(%s) = %s, %v, want %s", source, got, err, want)
+		}
+	}
+	if version := SourceVersion("xpkg.upbound.io/crossplane-contrib/provider-helm:v0.19.0"); version != "v0.19.0" {
+		t.Errorf("unexpected source version %s", version)
+	}
+}
+
+func TestSetSourceInRelease(t *testing.T) {
+	provider := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "pkg.crossplane.io/v1",
+		"kind":       "Provider",
+		"metadata":   map[string]interface{}{"name": "provider-nop"},
+		"spec": map[string]interface{}{
+			"package":                  "xpkg.upbound.io/crossplane-contrib/provider-nop:v0.2.0",
+			"revisionActivationPolicy": "Manual",
+		},
+	}}
+	dc := fake.NewSimpleDynamicClient(runtime.NewScheme(), provider)
+	release := func(ctx context.Context, source string, target string) (bool, error) {
+		return true, nil
+	}
+
+	source := "xpkg.upbound.io/crossplane-contrib/provider-nop:v0.2.0"
+	target := "xpkg.upbound.io/crossplane-contrib/provider-nop:v0.1.0"
+	if err := setSource(context.Background(), dc, Providers, "provider-nop", source, target, "Automatic", release); err != nil {
+		t.Fatal(err)
+	}
+	got, err := dc.Resource(Providers).Get(context.Background(), "provider-nop", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Source is left to release, activation policy is set on package object
+	if got, _, _ := unstructured.NestedString(got.Object, "spec", "package"); got != source {
+		t.Errorf("expected source set by release only, got %s", got)
+	}
+	if policy, _, _ := unstructured.NestedString(got.Object, "spec", "revisionActivationPolicy"); policy != "Automatic" {
+		t.Errorf("expected Automatic activation policy, got %s", policy)
+	}
+}
@@ -3,6 +3,7 @@ package provider
 import (
 	"context"
 	"strings"
+	"time"
 
 	crossv1 "github.com/crossplane/crossplane/apis/pkg/v1"
 	"go.uber.org/zap"
@@ -10,14 +11,31 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/web-seven/overlock/internal/engine"
+	"github.com/web-seven/overlock/internal/packages"
+	"github.com/web-seven/overlock/pkg/registry"
 )
 
 const apiName = "providers.pkg.crossplane.io"
 
+// HealthCheck waits until providers applied for links are healthy, zero
+// timeout waits without limit
+func HealthCheck(ctx context.Context, dc dynamic.Interface, config *rest.Config, links []string, timeout time.Duration, logger *zap.SugaredLogger) error {
+	names, err := registry.PackageNames(ctx, config, links)
+	if err != nil {
+		return err
+	}
+	if err := packages.WaitHealthy(ctx, dc, packages.Providers, names, timeout, logger); err != nil {
+		return err
+	}
+	logger.Info("Provider(s) are healthy.")
+	return nil
+}
+
 func (p *Provider) ApplyProvider(ctx context.Context, links []string, config *rest.Config, logger *zap.SugaredLogger) error {
 	_, err := engine.VerifyApi(ctx, config, apiName)
 	if err != nil {
@@ -26,12 +44,29 @@ func (p *Provider) ApplyProvider(ctx context.Context, links []string, config *re
 		logger.Infoln("Provider not applied.")
 		return nil
 	}
+	routes, err := registry.PackageRoutes(ctx, config)
+	if err != nil {
+		return err
+	}
+	verifier, err := registry.NewPackageVerifier(config, logger)
+	if err != nil {
+		return err
+	}
+
 	scheme := runtime.NewScheme()
 	crossv1.AddToScheme(scheme)
 	if kube, err := client.New(config, client.Options{Scheme: scheme}); err == nil {
 		for _, link := range links {
 			cfg := &crossv1.Provider{}
-			engine.BuildPack(cfg, link, map[string]string{})
+			engine.BuildPack(cfg, link, map[string]string{}, routes...)
+			if p.RuntimeConfig != "" {
+				cfg.SetRuntimeConfigRef(&crossv1.RuntimeConfigReference{Name: p.RuntimeConfig})
+			}
+			source, err := verifier.VerifyPackage(ctx, cfg.GetSource())
+			if err != nil {
+				return err
+			}
+			cfg.SetSource(source)
 			pa := resource.NewAPIPatchingApplicator(kube)
 
 			if err := pa.Apply(ctx, cfg); err != nil {
@@ -55,13 +90,30 @@ func (p *Provider) ApplyPackage(ctx context.Context, config *rest.Config, logger
 		return nil
 	}
 
+	routes, err := registry.PackageRoutes(ctx, config)
+	if err != nil {
+		return err
+	}
+	verifier, err := registry.NewPackageVerifier(config, logger)
+	if err != nil {
+		return err
+	}
+
 	scheme := runtime.NewScheme()
 	crossv1.AddToScheme(scheme)
 	if kube, err := client.New(config, client.Options{Scheme: scheme}); err == nil {
 		for _, link := range strings.Split(p.Name, ",") {
 			cfg := &crossv1.Provider{}
 			logger.Debugf("Building package %s", link)
-			engine.BuildPack(cfg, link, map[string]string{})
+			engine.BuildPack(cfg, link, map[string]string{}, routes...)
+			if p.RuntimeConfig != "" {
+				cfg.SetRuntimeConfigRef(&crossv1.RuntimeConfigReference{Name: p.RuntimeConfig})
+			}
+			source, err := verifier.VerifyPackage(ctx, cfg.GetSource())
+			if err != nil {
+				return err
+			}
+			cfg.SetSource(source)
 			pa := resource.NewAPIPatchingApplicator(kube)
 
 			if err := pa.Apply(ctx, cfg); err != nil {
@@ -0,0 +1,478 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/google/go-containerregistry/pkg/name"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/web-seven/overlock/internal/engine"
+	"github.com/web-seven/overlock/internal/namespace"
+	"github.com/web-seven/overlock/internal/packages"
+	"github.com/web-seven/overlock/internal/resources"
+)
+
+const (
+	providerConfigKind = "ProviderConfig"
+	// Credentials source storing credentials in Secret
+	SecretSource = "Secret"
+	// Key of credentials in Secret by default
+	DefaultSecretKey = "credentials"
+
+	credentialsFile  = "File"
+	credentialsEnv   = "Environment variable"
+	credentialsPaste = "Paste"
+	// Credentials are set with flags
+	credentialsProvided = "Provided"
+)
+
+// ConfigOptions of ProviderConfig created by configure
+type ConfigOptions struct {
+	// Name of ProviderConfig, "default" if empty
+	Name string
+	// API group of ProviderConfig, required if provider installed several of them
+	Group string
+	// Credentials source, Secret by default if supported by ProviderConfig
+	Source string
+	// Secret with credentials, named after provider and ProviderConfig if empty
+	SecretName      string
+	SecretNamespace string
+	SecretKey       string
+	// Credentials are read from file, environment variable or stdin
+	CredentialsFile  string
+	CredentialsEnv   string
+	CredentialsStdin bool
+	Stdin            io.Reader
+	// Spec fields by path relative to spec, e.g. projectID
+	Values map[string]string
+	// Spec answered in form, values are set over it
+	Spec map[string]interface{}
+	// Ask for options not set with form generated from ProviderConfig schema
+	Interactive bool
+}
+
+// Field of ProviderConfig spec besides credentials
+type configField struct {
+	Path     string
+	Schema   extv1.JSONSchemaProps
+	Required bool
+}
+
+// Configure provider by creating Secret with credentials and ProviderConfig
+func Configure(ctx context.Context, dc dynamic.Interface, client kubernetes.Interface, pvdName string, options ConfigOptions, logger *zap.SugaredLogger) error {
+	pvdName, err := resolveProvider(ctx, dc, pvdName, logger)
+	if err != nil {
+		return err
+	}
+	crd, err := ProviderConfigCRD(ctx, dc, pvdName, options.Group)
+	if err != nil {
+		return err
+	}
+	version, spec, err := configSchema(crd)
+	if err != nil {
+		return err
+	}
+	sources := credentialSources(spec)
+	options.defaults(sources)
+
+	var credentials []byte
+	if options.Interactive {
+		xr := resources.XResource{}
+		form := xr.GetSchemaForm(
+			schema.GroupVersionKind{Group: crd.Spec.Group, Version: version, Kind: crd.Spec.Names.Kind},
+			formSchema(spec),
+			"Would you like to create "+crd.Spec.Names.Kind+"?",
+		)
+		if err := form.Run(); err != nil {
+			return err
+		}
+		if !form.GetBool("confirm") {
+			return errors.New("configuration cancelled")
+		}
+		xr.ResolveValues()
+		options.Name = xr.GetName()
+		options.Spec, _, _ = unstructured.NestedMap(xr.Object, "spec")
+
+		method, value := "", ""
+		// Credentials passed with flags are not asked for
+		if options.CredentialsFile != "" || options.CredentialsEnv != "" {
+			method = credentialsProvided
+		}
+		if err := credentialsForm(sources, &options, &method, &value).Run(); err != nil {
+			return err
+		}
+		switch method {
+		case credentialsFile:
+			options.CredentialsFile = value
+		case credentialsEnv:
+			options.CredentialsEnv = value
+		case credentialsPaste:
+			credentials = []byte(value)
+		}
+	}
+	if options.SecretName == "" {
+		options.SecretName = pvdName + "-" + options.Name
+	}
+	pc, err := ProviderConfigManifest(crd, version, spec, options)
+	if err != nil {
+		return err
+	}
+	if options.Source == SecretSource && credentials == nil {
+		if credentials, err = options.credentials(); err != nil {
+			return err
+		}
+	}
+	if options.Source == SecretSource {
+		if err := applySecret(ctx, client, options, credentials); err != nil {
+			return err
+		}
+		logger.Infof("Secret %s/%s with credentials applied.", options.SecretNamespace, options.SecretName)
+	}
+	if err := applyObject(ctx, dc, schema.GroupVersionResource{Group: crd.Spec.Group, Version: version, Resource: crd.Spec.Names.Plural}, pc); err != nil {
+		return fmt.Errorf("cannot apply %s %s: %w", crd.Spec.Names.Kind, pc.GetName(), err)
+	}
+	logger.Infof("%s %s of %s applied.", crd.Spec.Names.Kind, pc.GetName(), crd.Spec.Group)
+	return nil
+}
+
+// Name of installed provider object, provider can be referenced by its name
+// or package repository
+func resolveProvider(ctx context.Context, dc dynamic.Interface, pvdName string, logger *zap.SugaredLogger) (string, error) {
+	_, err := dc.Resource(packages.Providers).Get(ctx, pvdName, metav1.GetOptions{})
+	if err == nil {
+		return pvdName, nil
+	}
+	if !kerrors.IsNotFound(err) {
+		return "", err
+	}
+	ref, err := name.ParseReference(pvdName, name.WithDefaultRegistry(""))
+	if err != nil {
+		return "", fmt.Errorf("provider %s is not installed", pvdName)
+	}
+	if installed := installedProvider(ctx, dc, ref.Context().RepositoryStr(), logger); installed != "" {
+		return installed, nil
+	}
+	return "", fmt.Errorf("provider %s is not installed", pvdName)
+}
+
+// ProviderConfigCRD finds ProviderConfig CRD installed by revisions of
+// provider. Providers of a family share ProviderConfig of the family
+// provider, it is matched by parent API group of provider CRDs.
+func ProviderConfigCRD(ctx context.Context, dc dynamic.Interface, pvdName string, group string) (*extv1.CustomResourceDefinition, error) {
+	history, err := packages.History(ctx, dc, packages.ProviderRevisions, pvdName)
+	if err != nil {
+		return nil, err
+	}
+	revisions := map[string]bool{}
+	for _, rev := range history {
+		revisions[rev.Name] = true
+	}
+
+	list, err := dc.Resource(packages.CustomResourceDefinitions).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	owned := map[string]bool{}
+	configs := []extv1.CustomResourceDefinition{}
+	for _, u := range list.Items {
+		crd := extv1.CustomResourceDefinition{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &crd); err != nil {
+			return nil, err
+		}
+		for _, owner := range crd.GetOwnerReferences() {
+			if revisions[owner.Name] {
+				owned[crd.Spec.Group] = true
+			}
+		}
+		if crd.Spec.Names.Kind == providerConfigKind {
+			configs = append(configs, crd)
+		}
+	}
+
+	found := []extv1.CustomResourceDefinition{}
+	for _, crd := range configs {
+		if group != "" && crd.Spec.Group != group {
+			continue
+		}
+		for ownedGroup := range owned {
+			if ownedGroup == crd.Spec.Group || strings.HasSuffix(ownedGroup, "."+crd.Spec.Group) {
+				found = append(found, crd)
+				break
+			}
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("%s of provider %s not found, check that provider is healthy", providerConfigKind, pvdName)
+	case 1:
+		return &found[0], nil
+	}
+	groups := []string{}
+	for _, crd := range found {
+		groups = append(groups, crd.Spec.Group)
+	}
+	sort.Strings(groups)
+	return nil, fmt.Errorf("provider %s has %s in several groups, select one with --group: %s", pvdName, providerConfigKind, strings.Join(groups, ", "))
+}
+
+// Storage version of CRD and schema of its spec
+func configSchema(crd *extv1.CustomResourceDefinition) (string, *extv1.JSONSchemaProps, error) {
+	for _, version := range crd.Spec.Versions {
+		if !version.Storage {
+			continue
+		}
+		if version.Schema == nil || version.Schema.OpenAPIV3Schema == nil {
+			break
+		}
+		spec, ok := version.Schema.OpenAPIV3Schema.Properties["spec"]
+		if !ok {
+			break
+		}
+		return version.Name, &spec, nil
+	}
+	return "", nil, fmt.Errorf("%s has no schema of spec", crd.GetName())
+}
+
+// Scalar fields of spec schema ordered by path, credentials are set separately
+func configFields(props *extv1.JSONSchemaProps, parent string, required []string) []configField {
+	fields := []configField{}
+	for name, prop := range props.Properties {
+		path := name
+		if parent != "" {
+			path = parent + "." + name
+		}
+		if path == "credentials" {
+			continue
+		}
+		isRequired := slices.Contains(required, name)
+		switch prop.Type {
+		case "object":
+			// Nested fields are required only if their parent is
+			nested := []string{}
+			if isRequired {
+				nested = prop.Required
+			}
+			fields = append(fields, configFields(&prop, path, nested)...)
+		case "string", "integer", "number", "boolean":
+			fields = append(fields, configField{Path: path, Schema: prop, Required: isRequired})
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Path < fields[j].Path })
+	return fields
+}
+
+// Credential sources supported by ProviderConfig
+func credentialSources(spec *extv1.JSONSchemaProps) []string {
+	source := spec.Properties["credentials"].Properties["source"]
+	sources := []string{}
+	for _, value := range source.Enum {
+		sources = append(sources, strings.Trim(string(value.Raw), `"`))
+	}
+	return sources
+}
+
+func (o *ConfigOptions) defaults(sources []string) {
+	if o.Name == "" {
+		o.Name = "default"
+	}
+	if o.Source == "" && (len(sources) == 0 || slices.Contains(sources, SecretSource)) {
+		o.Source = SecretSource
+	}
+	if o.SecretNamespace == "" {
+		o.SecretNamespace = namespace.Namespace
+	}
+	if o.SecretKey == "" {
+		o.SecretKey = DefaultSecretKey
+	}
+	if o.Values == nil {
+		o.Values = map[string]string{}
+	}
+	if o.Stdin == nil {
+		o.Stdin = os.Stdin
+	}
+	if o.CredentialsStdin {
+		// Form cannot be used while stdin is read
+		o.Interactive = false
+	}
+}
+
+// Read credentials from configured source
+func (o *ConfigOptions) credentials() ([]byte, error) {
+	switch {
+	case o.CredentialsFile != "":
+		return os.ReadFile(o.CredentialsFile)
+	case o.CredentialsEnv != "":
+		value, ok := os.LookupEnv(o.CredentialsEnv)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %s is not set", o.CredentialsEnv)
+		}
+		return []byte(value), nil
+	case o.CredentialsStdin:
+		return io.ReadAll(o.Stdin)
+	}
+	return nil, errors.New("credentials are required for Secret source, use --credentials-file, --credentials-env or --credentials-stdin")
+}
+
+// Schema of form asking for ProviderConfig name and spec, credentials are
+// asked for separately
+func formSchema(spec *extv1.JSONSchemaProps) *extv1.JSONSchemaProps {
+	fields := spec.DeepCopy()
+	delete(fields.Properties, "credentials")
+	return &extv1.JSONSchemaProps{
+		Type:       "object",
+		Properties: map[string]extv1.JSONSchemaProps{"spec": *fields},
+	}
+}
+
+// Form asking for credentials source and where credentials are read from
+func credentialsForm(sources []string, options *ConfigOptions, method *string, value *string) *huh.Form {
+	groups := []*huh.Group{}
+	if len(sources) > 0 {
+		groups = append(groups, huh.NewGroup(
+			huh.NewSelect[string]().Title("Credentials source").
+				Options(huh.NewOptions(sources...)...).Value(&options.Source),
+		))
+	}
+	provided := *method == credentialsProvided
+	if !provided {
+		*method = credentialsFile
+	}
+	groups = append(groups, huh.NewGroup(
+		huh.NewSelect[string]().Title("Read credentials from").
+			Options(huh.NewOptions(credentialsFile, credentialsEnv, credentialsPaste)...).Value(method),
+	).WithHideFunc(func() bool { return options.Source != SecretSource || provided }))
+	titles := map[string]string{
+		credentialsFile: "Path to credentials file",
+		credentialsEnv:  "Environment variable with credentials",
+	}
+	for _, m := range []string{credentialsFile, credentialsEnv} {
+		m := m
+		groups = append(groups, huh.NewGroup(
+			huh.NewInput().Title(titles[m]).Value(value).Validate(func(s string) error {
+				if s == "" {
+					return errors.New("credentials are required")
+				}
+				return nil
+			}),
+		).WithHideFunc(func() bool { return options.Source != SecretSource || *method != m }))
+	}
+	groups = append(groups, huh.NewGroup(
+		huh.NewText().Title("Credentials").Lines(8).Value(value),
+	).WithHideFunc(func() bool { return options.Source != SecretSource || *method != credentialsPaste }))
+	return huh.NewForm(groups...)
+}
+
+// ProviderConfigManifest builds ProviderConfig from options, values are
+// converted to types of spec schema
+func ProviderConfigManifest(crd *extv1.CustomResourceDefinition, version string, spec *extv1.JSONSchemaProps, options ConfigOptions) (*unstructured.Unstructured, error) {
+	pc := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	pc.SetGroupVersionKind(schema.GroupVersionKind{Group: crd.Spec.Group, Version: version, Kind: crd.Spec.Names.Kind})
+	pc.SetName(options.Name)
+	pc.SetLabels(engine.ManagedLabels(nil))
+	if options.Spec != nil {
+		if err := unstructured.SetNestedMap(pc.Object, runtime.DeepCopyJSON(options.Spec), "spec"); err != nil {
+			return nil, err
+		}
+	}
+
+	fields := map[string]configField{}
+	for _, field := range configFields(spec, "", spec.Required) {
+		fields[field.Path] = field
+	}
+	paths := []string{}
+	for path := range options.Values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		raw := options.Values[path]
+		if raw == "" {
+			continue
+		}
+		field, ok := fields[path]
+		if !ok {
+			return nil, fmt.Errorf("%s has no field spec.%s", crd.Spec.Names.Kind, path)
+		}
+		value, err := resources.CoerceValue(raw, field.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("spec.%s: %w", path, err)
+		}
+		if err := unstructured.SetNestedField(pc.Object, value, append([]string{"spec"}, strings.Split(path, ".")...)...); err != nil {
+			return nil, err
+		}
+	}
+	for _, field := range fields {
+		if _, set, _ := unstructured.NestedFieldNoCopy(pc.Object, append([]string{"spec"}, strings.Split(field.Path, ".")...)...); field.Required && !set {
+			return nil, fmt.Errorf("spec.%s is required, set it with --set %s=<value>", field.Path, field.Path)
+		}
+	}
+
+	credentials, hasCredentials := spec.Properties["credentials"]
+	if hasCredentials && options.Source != "" {
+		if err := unstructured.SetNestedField(pc.Object, options.Source, "spec", "credentials", "source"); err != nil {
+			return nil, err
+		}
+		if _, ok := credentials.Properties["secretRef"]; ok && options.Source == SecretSource {
+			ref := map[string]interface{}{"name": options.SecretName, "namespace": options.SecretNamespace, "key": options.SecretKey}
+			if err := unstructured.SetNestedMap(pc.Object, ref, "spec", "credentials", "secretRef"); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return pc, nil
+}
+
+// Create or update Secret with credentials
+func applySecret(ctx context.Context, client kubernetes.Interface, options ConfigOptions, credentials []byte) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      options.SecretName,
+			Namespace: options.SecretNamespace,
+			Labels:    engine.ManagedLabels(nil),
+		},
+		Data: map[string][]byte{options.SecretKey: credentials},
+	}
+	secrets := client.CoreV1().Secrets(options.SecretNamespace)
+	existing, err := secrets.Get(ctx, secret.Name, metav1.GetOptions{})
+	switch {
+	case kerrors.IsNotFound(err):
+		_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+	case err == nil:
+		existing.Data = secret.Data
+		_, err = secrets.Update(ctx, existing, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("cannot apply secret %s: %w", secret.Name, err)
+	}
+	return nil
+}
+
+// Create or update cluster scoped object
+func applyObject(ctx context.Context, dc dynamic.Interface, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) error {
+	existing, err := dc.Resource(gvr).Get(ctx, obj.GetName(), metav1.GetOptions{})
+	switch {
+	case kerrors.IsNotFound(err):
+		_, err = dc.Resource(gvr).Create(ctx, obj, metav1.CreateOptions{})
+	case err == nil:
+		obj.SetResourceVersion(existing.GetResourceVersion())
+		_, err = dc.Resource(gvr).Update(ctx, obj, metav1.UpdateOptions{})
+	}
+	return err
+}
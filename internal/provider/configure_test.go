@@ -0,0 +1,122 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/web-seven/overlock/internal/packages"
+)
+
+func TestConfigure(t *testing.T) {
+	crd := func(group string, kind string, owner string, spec extv1.JSONSchemaProps) runtime.Object {
+		obj := &extv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            strings.ToLower(kind) + "s." + group,
+				OwnerReferences: []metav1.OwnerReference{{Kind: "ProviderRevision", Name: owner}},
+			},
+			Spec: extv1.CustomResourceDefinitionSpec{
+				Group: group,
+				Names: extv1.CustomResourceDefinitionNames{Kind: kind, Plural: strings.ToLower(kind) + "s"},
+				Versions: []extv1.CustomResourceDefinitionVersion{{
+					Name:    "v1beta1",
+					Storage: true,
+					Schema: &extv1.CustomResourceValidation{OpenAPIV3Schema: &extv1.JSONSchemaProps{
+						Properties: map[string]extv1.JSONSchemaProps{"spec": spec},
+					}},
+				}},
+			},
+		}
+		content, _ := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		u := &unstructured.Unstructured{Object: content}
+		u.SetAPIVersion("apiextensions.k8s.io/v1")
+		u.SetKind("CustomResourceDefinition")
+		return u
+	}
+	object := func(apiVersion string, kind string, name string, labels map[string]interface{}) runtime.Object {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata":   map[string]interface{}{"name": name, "labels": labels},
+			"spec":       map[string]interface{}{"package": "xpkg.upbound.io/upbound/" + name + ":v1.0.0"},
+		}}
+	}
+	enum := func(values ...string) []extv1.JSON {
+		result := []extv1.JSON{}
+		for _, v := range values {
+			result = append(result, extv1.JSON{Raw: []byte(`"` + v + `"`)})
+		}
+		return result
+	}
+	configSpec := extv1.JSONSchemaProps{
+		Type:     "object",
+		Required: []string{"credentials", "projectID"},
+		Properties: map[string]extv1.JSONSchemaProps{
+			"projectID": {Type: "string"},
+			"timeout":   {Type: "integer"},
+			"credentials": {Type: "object", Properties: map[string]extv1.JSONSchemaProps{
+				"source":    {Type: "string", Enum: enum("Secret", "InjectedIdentity")},
+				"secretRef": {Type: "object"},
+			}},
+		},
+	}
+
+	configs := schema.GroupVersionResource{Group: "gcp.upbound.io", Version: "v1beta1", Resource: "providerconfigs"}
+	dc := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		packages.Providers:                 "ProviderList",
+		packages.ProviderRevisions:         "ProviderRevisionList",
+		packages.CustomResourceDefinitions: "CustomResourceDefinitionList",
+		configs:                            "ProviderConfigList",
+	},
+		object("pkg.crossplane.io/v1", "Provider", "provider-gcp-storage", nil),
+		object("pkg.crossplane.io/v1", "ProviderRevision", "provider-gcp-storage-1a2b", map[string]interface{}{packages.PackageLabel: "provider-gcp-storage"}),
+		crd("storage.gcp.upbound.io", "Bucket", "provider-gcp-storage-1a2b", extv1.JSONSchemaProps{Type: "object"}),
+		crd("gcp.upbound.io", "ProviderConfig", "provider-family-gcp-3c4d", configSpec),
+		crd("kubernetes.crossplane.io", "ProviderConfig", "provider-kubernetes-5e6f", configSpec),
+	)
+	client := k8sfake.NewSimpleClientset()
+	ctx := context.Background()
+	logger := zap.NewNop().Sugar()
+
+	options := ConfigOptions{SecretNamespace: "crossplane-system", CredentialsStdin: true, Stdin: strings.NewReader(`{"type":"service_account"}`)}
+	err := Configure(ctx, dc, client, "xpkg.upbound.io/upbound/provider-gcp-storage:v1.0.0", options, logger)
+	if err == nil || !strings.Contains(err.Error(), "--set projectID=<value>") {
+		t.Fatalf("expected missing projectID error, got %v", err)
+	}
+
+	options.Values = map[string]string{"projectID": "demo", "timeout": "30"}
+	if err := Configure(ctx, dc, client, "xpkg.upbound.io/upbound/provider-gcp-storage:v1.0.0", options, logger); err != nil {
+		t.Fatal(err)
+	}
+	secret, err := client.CoreV1().Secrets("crossplane-system").Get(ctx, "provider-gcp-storage-default", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(secret.Data[DefaultSecretKey]) != `{"type":"service_account"}` {
+		t.Errorf("unexpected credentials %q", secret.Data[DefaultSecretKey])
+	}
+	pc, err := dc.Resource(configs).Get(ctx, "default", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timeout, _, _ := unstructured.NestedInt64(pc.Object, "spec", "timeout"); timeout != 30 {
+		t.Errorf("expected integer timeout, got %v", pc.Object["spec"])
+	}
+	if ref, _, _ := unstructured.NestedString(pc.Object, "spec", "credentials", "secretRef", "name"); ref != "provider-gcp-storage-default" {
+		t.Errorf("unexpected secret reference %q", ref)
+	}
+
+	options.Values["timeout"] = "soon"
+	if err := Configure(ctx, dc, client, "provider-gcp-storage", options, logger); err == nil {
+		t.Error("expected error of non integer timeout")
+	}
+}
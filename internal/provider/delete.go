@@ -3,16 +3,80 @@ package provider
 import (
 	"context"
 	"fmt"
-	"strings"
+	"time"
 
+	"github.com/google/go-containerregistry/pkg/name"
 	"go.uber.org/zap"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 
 	"github.com/web-seven/overlock/internal/engine"
+	"github.com/web-seven/overlock/internal/packages"
+	"github.com/web-seven/overlock/internal/runtimeconfig"
 )
 
-// DeleteProvider deletes a crossplane provider from current environment
-func DeleteProvider(ctx context.Context, configClient *rest.Config, url string, logger *zap.SugaredLogger) error {
+// DeleteOptions of provider deletion
+type DeleteOptions struct {
+	// Delete managed resources of provider and wait until they are removed
+	Cascade bool
+	// Time to wait for managed resources removal, zero waits without limit
+	Timeout time.Duration
+	// Delete runtime config of provider if no other package uses it
+	PruneRuntimeConfig bool
+}
+
+// DeleteProvider deletes a crossplane provider from current environment.
+// Provider with managed resources is not deleted unless cascade is set, as
+// they would be left with finalizers nobody removes.
+func DeleteProvider(ctx context.Context, configClient *rest.Config, dc dynamic.Interface, url string, options DeleteOptions, logger *zap.SugaredLogger) error {
+	pvdName, err := resolveProvider(ctx, dc, url, logger)
+	if err != nil {
+		return err
+	}
+	pvd, err := dc.Resource(packages.Providers).Get(ctx, pvdName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	managed, err := packages.ManagedResources(ctx, dc, packages.ProviderRevisions, pvdName)
+	if err != nil {
+		return err
+	}
+	if len(managed) > 0 {
+		if !options.Cascade {
+			return fmt.Errorf("provider %s has %d managed resource(s): %s; delete them first or use --cascade", pvdName, len(managed), packages.ManagedSummary(managed, 10))
+		}
+		if err := packages.DeleteManaged(ctx, dc, managed, options.Timeout, logger); err != nil {
+			return err
+		}
+	}
+
+	source, _, _ := unstructured.NestedString(pvd.Object, "spec", "package")
+	if err := removeFromRelease(ctx, configClient, url, source, logger); err != nil {
+		return err
+	}
+	// Provider is deleted also when it was installed with engine release, as
+	// release upgrade is not guaranteed to remove provider objects created
+	// from its packages, it is already gone if upgrade did remove it
+	err = dc.Resource(packages.Providers).Delete(ctx, pvdName, metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return err
+	}
+	logger.Infof("Provider %s deleted successfully", url)
+
+	if options.PruneRuntimeConfig {
+		rcName, _, _ := unstructured.NestedString(pvd.Object, "spec", "runtimeConfigRef", "name")
+		return runtimeconfig.Prune(ctx, dc, rcName, "Provider/"+pvdName, logger)
+	}
+	return nil
+}
+
+// Remove provider from packages of engine release, release is kept as is
+// if provider was not installed with it
+func removeFromRelease(ctx context.Context, configClient *rest.Config, url string, source string, logger *zap.SugaredLogger) error {
 	logger.Debug("Preparing engine")
 	installer, err := engine.GetEngine(configClient)
 	if err != nil {
@@ -27,11 +91,11 @@ func DeleteProvider(ctx context.Context, configClient *rest.Config, url string,
 
 	provider, ok := params["provider"].(map[string]any)
 	if !ok {
-		return fmt.Errorf("invalid provider configuration in params")
+		return nil
 	}
 	packages, ok := provider["packages"].([]any)
 	if !ok {
-		return fmt.Errorf("error extracting packages")
+		return nil
 	}
 	var newpackages []string
 	for _, p := range packages {
@@ -39,18 +103,33 @@ func DeleteProvider(ctx context.Context, configClient *rest.Config, url string,
 		if !ok {
 			continue // Skip non-string entries
 		}
-		if !strings.Contains(pstr, url) {
+		if !sameRepository(pstr, url) && !sameRepository(pstr, source) {
 			newpackages = append(newpackages, pstr)
 		}
 	}
+	if len(newpackages) == len(packages) {
+		return nil
+	}
 	provider["packages"] = newpackages
 	params["provider"] = provider
 
 	logger.Debug("Installing engine")
-	err = engine.InstallEngine(ctx, configClient, params, logger)
+	return engine.InstallEngine(ctx, configClient, params, logger)
+}
+
+// Whether package references are of the same repository, tag and digest are
+// ignored, registry is compared only if both references have it
+func sameRepository(pkg string, ref string) bool {
+	a, err := name.ParseReference(pkg, name.WithDefaultRegistry(""))
 	if err != nil {
-		return err
+		return false
 	}
-	logger.Infof("Provider %s deleted successfully", url)
-	return nil
+	b, err := name.ParseReference(ref, name.WithDefaultRegistry(""))
+	if err != nil {
+		return false
+	}
+	if a.Context().RegistryStr() == "" || b.Context().RegistryStr() == "" {
+		return a.Context().RepositoryStr() == b.Context().RepositoryStr()
+	}
+	return a.Context().Name() == b.Context().Name()
 }
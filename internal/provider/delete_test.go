@@ -0,0 +1,25 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSameRepository(t *testing.T) {
+	cases := []struct {
+		pkg  string
+		ref  string
+		want bool
+	}{
+		{"xpkg.upbound.io/upbound/provider-aws:v1.0.0", "xpkg.upbound.io/upbound/provider-aws:v1.1.0", true},
+		{"xpkg.upbound.io/upbound/provider-aws@sha256:" + strings.Repeat("0", 64), "upbound/provider-aws", true},
+		{"xpkg.upbound.io/upbound/provider-aws-s3:v1.0.0", "upbound/provider-aws", false},
+		{"xpkg.upbound.io/upbound/provider-aws:v1.0.0", "registry.local/upbound/provider-aws:v1.0.0", false},
+		{"xpkg.upbound.io/upbound/provider-aws:v1.0.0", "upbound-provider-aws", false},
+	}
+	for _, tc := range cases {
+		if got := sameRepository(tc.pkg, tc.ref); got != tc.want {
+			t.Errorf("sameRepository(%q, %q) = %v, want %v", tc.pkg, tc.ref, got, tc.want)
+		}
+	}
+}
@@ -1,24 +1,44 @@
 package provider
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/web-seven/overlock/internal/engine"
+	"github.com/web-seven/overlock/internal/packages"
+	"github.com/web-seven/overlock/pkg/registry"
 
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 )
 
-func InstallProvider(provider string, config *rest.Config, logger *zap.SugaredLogger) error {
+// InstallProvider adds provider to packages of Crossplane release and
+// returns its source as installed, pinned to digest if signature is verified
+func InstallProvider(ctx context.Context, provider string, config *rest.Config, logger *zap.SugaredLogger) (string, error) {
+	routes, err := registry.PackageRoutes(ctx, config)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := registry.NewPackageVerifier(config, logger)
+	if err != nil {
+		return "", err
+	}
+	provider, err = verifier.VerifyPackage(ctx, engine.ExpandPackage(provider, routes))
+	if err != nil {
+		return "", err
+	}
+
 	installer, err := engine.GetEngine(config)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	release, err := installer.GetRelease()
 	if err != nil {
-		return fmt.Errorf("crossplane release not found in namespace, set OVERLOCK_ENGINE_NAMESPACE or run `overlock environment create`: %w", err)
+		return "", fmt.Errorf("crossplane release not found in namespace, set OVERLOCK_ENGINE_NAMESPACE or run `overlock environment create`: %w", err)
 	}
 
 	if release.Config == nil {
@@ -34,11 +54,11 @@ func InstallProvider(provider string, config *rest.Config, logger *zap.SugaredLo
 	} else {
 		configs, ok := release.Config["provider"].(map[string]interface{})
 		if !ok {
-			return fmt.Errorf("invalid provider configuration")
+			return "", fmt.Errorf("invalid provider configuration")
 		}
 		packages, ok := configs["packages"].([]interface{})
 		if !ok {
-			return fmt.Errorf("invalid packages configuration")
+			return "", fmt.Errorf("invalid packages configuration")
 		}
 		configs["packages"] = append(packages, provider)
 		release.Config["provider"] = configs
@@ -46,14 +66,24 @@ func InstallProvider(provider string, config *rest.Config, logger *zap.SugaredLo
 
 	version, err := installer.GetCurrentVersion()
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	err = installer.Upgrade(version, release.Config)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	logger.Info("Overlock provider installed successfully.")
+	return provider, nil
+}
+
+// WaitInstalled waits until provider installed with Crossplane release is
+// healthy, such providers are matched by their package source
+func WaitInstalled(ctx context.Context, dc dynamic.Interface, source string, timeout time.Duration, logger *zap.SugaredLogger) error {
+	if err := packages.WaitHealthy(ctx, dc, packages.Providers, []string{source}, timeout, logger); err != nil {
+		return err
+	}
+	logger.Info("Provider is healthy.")
 	return nil
 }
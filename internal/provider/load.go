@@ -3,25 +3,24 @@ package provider
 import (
 	"context"
 	"fmt"
-	"os"
-	"os/exec"
+	"path/filepath"
 	"strings"
 
-	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"go.uber.org/zap"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 
+	"github.com/web-seven/overlock/internal/build"
 	"github.com/web-seven/overlock/internal/image"
 	"github.com/web-seven/overlock/internal/kube"
+	"github.com/web-seven/overlock/internal/lint"
 	"github.com/web-seven/overlock/internal/loader"
 	"github.com/web-seven/overlock/internal/packages"
 	"github.com/web-seven/overlock/pkg/registry"
 )
 
 const (
-	providerFileName             = "provider"
-	fileMode         os.FileMode = 0o777
+	providerFileName = "provider"
 )
 
 // Load Provider package from TAR archive path
@@ -69,6 +68,12 @@ func (p *Provider) LoadProvider(ctx context.Context, path string, config *rest.C
 		return err
 	}
 	logger.Infof("Image archive %s loaded to local registry.", p.Name)
+	if p.SignKey != "" {
+		if err := registry.SignLocalImage(ctx, p.Name, p.Image, p.SignKey, config, logger); err != nil {
+			return err
+		}
+		logger.Infof("Image %s signed.", p.Name)
+	}
 	if p.Apply {
 		logger.Debug("Apply provider")
 		return p.ApplyProvider(ctx, []string{p.Name}, config, logger)
@@ -78,81 +83,52 @@ func (p *Provider) LoadProvider(ctx context.Context, path string, config *rest.C
 
 // Load provider package from directory
 func (p *Provider) LoadDirectory(ctx context.Context, config *rest.Config, logger *zap.SugaredLogger, path string, mainPath string) error {
-	logger.Debug("Building provider...")
-	fileContent, err := p.build(fmt.Sprintf("%s/%s", strings.TrimRight(path, "/"), mainPath))
-	if err != nil {
-		logger.Infof("Error provider build: %v", err)
-	}
-
-	logger.Debug("Loading provider binaries...")
-	providerLayer, err := image.LoadBinaryLayer(fileContent, providerFileName, fileMode)
-	if err != nil {
+	if err := p.lint(path, logger); err != nil {
 		return err
 	}
-
-	logger.Debug("Loading provider package...")
-	packageLayer, err := image.LoadPackageLayerDirectory(ctx, config, fmt.Sprintf("%s/%s", strings.TrimRight(path, "/"), packages.PackagePath), []string{"Provider", "CustomResourceDefinition"})
-	if err != nil {
+	if err := p.buildDirectory(ctx, config, logger, path, mainPath); err != nil {
 		return err
 	}
-	logger.Debugf("Package layer created.")
-	logger.Debugf("Creating image configuration...")
+	return p.load(ctx, config, logger)
+}
 
-	cfg, err := p.Image.Image.ConfigFile()
+// Build provider image from directory
+func (p *Provider) buildDirectory(ctx context.Context, config *rest.Config, logger *zap.SugaredLogger, path string, mainPath string) error {
+	cfg, err := build.LoadConfig(path)
 	if err != nil {
 		return err
 	}
-	cfg = cfg.DeepCopy()
-	cfg.Config.WorkingDir = "/"
-	cfg.Config.ArgsEscaped = true
-	cfg.Config.Entrypoint = []string{
-		"/provider",
-	}
-	cfg.Config.ExposedPorts = map[string]struct{}{
-		"9443": {},
-	}
-	logger.Debugf("Image configuration created.")
-	logger.Debug("Update image configuration...")
-	p.Image.Image, err = mutate.ConfigFile(p.Image.Image, cfg)
+
+	logger.Debug("Loading provider package...")
+	packageLayer, err := image.LoadPackageLayerDirectory(ctx, config, fmt.Sprintf("%s/%s", strings.TrimRight(path, "/"), packages.PackagePath), lint.PackageKinds[lint.KindProvider])
 	if err != nil {
 		return err
 	}
+	logger.Debug("Provider package loaded.")
 
-	p.Image.Image, err = mutate.Append(p.Image.Image,
-		mutate.Addendum{
-			Layer: packageLayer,
-		},
-		mutate.Addendum{
-			Layer: providerLayer,
-		},
-	)
+	client, err := kube.Client(config)
 	if err != nil {
 		return err
 	}
-	return p.load(ctx, config, logger)
-}
 
-// Build Go module
-func (p *Provider) build(path string) ([]byte, error) {
-	args := []string{
-		"build", "-C", path, "-o", providerFileName,
-	}
-	cmd := exec.Command("go", args...)
-	cmd.Env = os.Environ()
-	cmd.Env = append(cmd.Env, "CGO_ENABLED=0")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	err := cmd.Run()
+	logger.Debugf("Building provider on %s...", cfg.BaseImage)
+	p.Image.Image, p.Index, err = cfg.GoImage(ctx, client, logger, fmt.Sprintf("%s/%s", strings.TrimRight(path, "/"), mainPath), providerFileName, build.Runtime{
+		PackageLayer: packageLayer,
+		Entrypoint:   []string{"/" + providerFileName},
+		Ports:        []string{"9443"},
+	})
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("provider build failed: %w", err)
 	}
+	return nil
+}
 
-	fileContent, err := os.ReadFile(fmt.Sprintf("%s/%s", strings.TrimRight(path, "/"), providerFileName))
-	if err != nil {
-		return nil, err
+// Lint provider package directory unless linting is skipped
+func (p *Provider) lint(path string, logger *zap.SugaredLogger) error {
+	if p.SkipLint {
+		return nil
 	}
-	return fileContent, nil
+	return lint.Check(filepath.Join(path, packages.PackagePath), lint.KindProvider, logger)
 }
 
 // Load provider to registry
@@ -171,7 +147,11 @@ func (p *Provider) load(ctx context.Context, config *rest.Config, logger *zap.Su
 		}
 	}
 
-	err = registry.PushLocalRegistry(ctx, p.Name, p.Image, config, logger)
+	if p.Index != nil {
+		err = registry.PushLocalRegistryIndex(ctx, p.Name, p.Index, config, logger)
+	} else {
+		err = registry.PushLocalRegistry(ctx, p.Name, p.Image, config, logger)
+	}
 	if err != nil {
 		return err
 	}
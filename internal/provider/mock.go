@@ -0,0 +1,440 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+
+	"github.com/web-seven/overlock/internal/engine"
+	"github.com/web-seven/overlock/internal/image"
+	"github.com/web-seven/overlock/internal/loader"
+	"github.com/web-seven/overlock/internal/packages"
+	"github.com/web-seven/overlock/pkg/registry"
+)
+
+// Annotation with name of external resource of managed resource
+const annotationExternalName = "crossplane.io/external-name"
+
+// Time given to applied CRDs to be established
+const establishTimeout = time.Minute
+
+// Fixture of status reported by mock provider for managed resources of kind
+type Fixture struct {
+	// API version of managed resources, any version if empty
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind"`
+	// Fields of status.atProvider, string values are Go templates executed
+	// with managed resource, e.g. arn:aws:s3:::{{ .metadata.name }}
+	AtProvider map[string]interface{} `json:"atProvider,omitempty"`
+	// Keys of connection secret, values are templates as in atProvider
+	ConnectionDetails map[string]string `json:"connectionDetails,omitempty"`
+}
+
+// LoadFixtures reads list of fixtures from YAML file
+func LoadFixtures(path string) ([]Fixture, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	content, err = yaml.YAMLToJSON(content)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+	// Numbers are decoded as int64 where possible, as in objects read from cluster
+	fixtures := []Fixture{}
+	if err := utiljson.Unmarshal(content, &fixtures); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+	for _, f := range fixtures {
+		if f.Kind == "" {
+			return nil, fmt.Errorf("%s: kind of fixture is required", path)
+		}
+		details := map[string]interface{}{}
+		for k, v := range f.ConnectionDetails {
+			details[k] = v
+		}
+		for _, values := range []map[string]interface{}{f.AtProvider, details} {
+			// Templates are parsed to report syntax errors early
+			if _, err := renderFixture(values, nil); err != nil {
+				return nil, fmt.Errorf("%s: invalid template in fixture of %s: %w", path, f.Kind, err)
+			}
+		}
+	}
+	return fixtures, nil
+}
+
+// Mock installs CRDs of provider package without its controller and
+// reconciles its managed resources with fake controller until context is
+// cancelled. Managed resources are reported Ready and Synced, their
+// status.atProvider and connection secrets are filled from fixtures. CRDs are
+// read from package archive or provider project directory if path is set,
+// otherwise package is pulled.
+func Mock(ctx context.Context, dc dynamic.Interface, client kubernetes.Interface, config *rest.Config, provider string, path string, fixtures []Fixture, logger *zap.SugaredLogger) error {
+	routes, err := registry.PackageRoutes(ctx, config)
+	if err != nil {
+		return err
+	}
+	source := engine.ExpandPackage(provider, routes)
+	ref, err := name.ParseReference(source, name.WithDefaultRegistry(""))
+	if err != nil {
+		return err
+	}
+	if installed := installedProvider(ctx, dc, ref.Context().RepositoryStr(), logger); installed != "" {
+		return fmt.Errorf("provider %s is installed from %s, delete it before running mock", installed, ref.Context().RepositoryStr())
+	}
+
+	var objects []unstructured.Unstructured
+	if path != "" {
+		if objects, err = pathObjects(ctx, config, path); err != nil {
+			return fmt.Errorf("cannot read package objects of %s: %w", path, err)
+		}
+	} else if objects, _, err = registry.PackageObjects(ctx, source, config, logger); err != nil {
+		return err
+	}
+	crds, err := applyCRDObjects(ctx, dc, objects, logger)
+	if err != nil {
+		return err
+	}
+	if err := waitEstablished(ctx, dc, crds); err != nil {
+		return err
+	}
+
+	resources := []schema.GroupVersionResource{}
+	for i := range crds {
+		categories, _, _ := unstructured.NestedStringSlice(crds[i].Object, "spec", "names", "categories")
+		if gvr, ok := packages.StorageResource(&crds[i]); ok && slices.Contains(categories, packages.ManagedCategory) {
+			resources = append(resources, gvr)
+		}
+	}
+	if len(resources) == 0 {
+		return fmt.Errorf("package %s has no managed resource CRDs", source)
+	}
+	logger.Infof("Mock of %s reconciles %d managed resource type(s), press Ctrl+C to stop.", source, len(resources))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	m := &mockController{dc: dc, client: client, fixtures: fixtures, logger: logger}
+	errs := make(chan error, len(resources))
+	for _, gvr := range resources {
+		go func(gvr schema.GroupVersionResource) {
+			errs <- m.watch(ctx, gvr)
+		}(gvr)
+	}
+	for range resources {
+		if err := <-errs; err != nil {
+			return err
+		}
+	}
+	logger.Info("Mock provider stopped, its CRDs are left in environment.")
+	return nil
+}
+
+// Objects of package archive or of package directory of provider project
+func pathObjects(ctx context.Context, config *rest.Config, path string) ([]unstructured.Unstructured, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return packageDirObjects(ctx, config, filepath.Join(path, packages.PackagePath))
+	}
+	img, err := loader.LoadPathArchive(path)
+	if err != nil {
+		return nil, err
+	}
+	return image.PackageObjects(img)
+}
+
+// Wait until API server serves applied CRDs
+func waitEstablished(ctx context.Context, dc dynamic.Interface, crds []unstructured.Unstructured) error {
+	for _, crd := range crds {
+		err := wait.PollUntilContextTimeout(ctx, time.Second, establishTimeout, true, func(ctx context.Context) (bool, error) {
+			current, err := dc.Resource(packages.CustomResourceDefinitions).Get(ctx, crd.GetName(), metav1.GetOptions{})
+			if err != nil {
+				return false, err
+			}
+			conditions, _, _ := unstructured.NestedSlice(current.Object, "status", "conditions")
+			for _, c := range conditions {
+				if condition, ok := c.(map[string]interface{}); ok && condition["type"] == "Established" && condition["status"] == "True" {
+					return true, nil
+				}
+			}
+			return false, nil
+		})
+		if wait.Interrupted(err) {
+			return fmt.Errorf("CRD %s not established after %s", crd.GetName(), establishTimeout)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type mockController struct {
+	dc       dynamic.Interface
+	client   kubernetes.Interface
+	fixtures []Fixture
+	logger   *zap.SugaredLogger
+}
+
+// Reconcile existing resources and watch for changes, watch is restarted
+// when API server closes it
+func (m *mockController) watch(ctx context.Context, gvr schema.GroupVersionResource) error {
+	for ctx.Err() == nil {
+		list, err := m.dc.Resource(gvr).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("cannot list %s: %w", gvr.GroupResource(), err)
+		}
+		for i := range list.Items {
+			m.sync(ctx, gvr, &list.Items[i])
+		}
+		w, err := m.dc.Resource(gvr).Watch(ctx, metav1.ListOptions{ResourceVersion: list.GetResourceVersion()})
+		if err != nil {
+			return fmt.Errorf("cannot watch %s: %w", gvr.GroupResource(), err)
+		}
+		m.events(ctx, gvr, w)
+	}
+	return nil
+}
+
+func (m *mockController) events(ctx context.Context, gvr schema.GroupVersionResource, w watch.Interface) {
+	defer w.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-w.ResultChan():
+			if !ok {
+				return
+			}
+			obj, ok := e.Object.(*unstructured.Unstructured)
+			if !ok || e.Type == watch.Deleted {
+				continue
+			}
+			m.sync(ctx, gvr, obj)
+		}
+	}
+}
+
+func (m *mockController) sync(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) {
+	err := m.reconcile(ctx, gvr, obj)
+	switch {
+	case err == nil, ctx.Err() != nil:
+	case kerrors.IsConflict(err), kerrors.IsNotFound(err):
+		// Newer version of resource is reconciled on its event
+		m.logger.Debugf("Skipped %s %s: %v", obj.GetKind(), obj.GetName(), err)
+	default:
+		m.logger.Warnf("Cannot reconcile %s %s: %v", obj.GetKind(), obj.GetName(), err)
+	}
+}
+
+// Reconcile managed resource as its provider would do when external
+// resource is available
+func (m *mockController) reconcile(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) error {
+	resource := m.dc.Resource(gvr).Namespace(obj.GetNamespace())
+	if obj.GetDeletionTimestamp() != nil {
+		if len(obj.GetFinalizers()) == 0 {
+			return nil
+		}
+		obj.SetFinalizers(nil)
+		_, err := resource.Update(ctx, obj, metav1.UpdateOptions{})
+		return err
+	}
+
+	externalName := obj.GetAnnotations()[annotationExternalName]
+	if externalName == "" {
+		externalName = obj.GetName()
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[annotationExternalName] = externalName
+		obj.SetAnnotations(annotations)
+		updated, err := resource.Update(ctx, obj, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		obj = updated
+	}
+
+	atProvider := map[string]interface{}{"id": externalName}
+	details := map[string]interface{}{}
+	if fixture := m.fixture(obj); fixture != nil {
+		rendered, err := renderFixture(fixture.AtProvider, obj.Object)
+		if err != nil {
+			return fmt.Errorf("cannot render atProvider fixture: %w", err)
+		}
+		for k, v := range rendered {
+			atProvider[k] = v
+		}
+		for k, v := range fixture.ConnectionDetails {
+			details[k] = v
+		}
+		if details, err = renderFixture(details, obj.Object); err != nil {
+			return fmt.Errorf("cannot render connection details fixture: %w", err)
+		}
+	}
+	if err := m.writeConnectionSecret(ctx, obj, details); err != nil {
+		return err
+	}
+
+	status, _, _ := unstructured.NestedMap(obj.Object, "status")
+	if status == nil {
+		status = map[string]interface{}{}
+	}
+	desired := map[string]interface{}{}
+	for k, v := range status {
+		desired[k] = v
+	}
+	desired["atProvider"] = atProvider
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	desired["conditions"] = mockConditions(conditions, metav1.Now().UTC().Format(time.RFC3339))
+	if equality.Semantic.DeepEqual(status, desired) {
+		return nil
+	}
+	obj.Object["status"] = desired
+	if _, err := resource.UpdateStatus(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	m.logger.Debugf("%s %s reported ready.", obj.GetKind(), obj.GetName())
+	return nil
+}
+
+// First fixture of resource kind
+func (m *mockController) fixture(obj *unstructured.Unstructured) *Fixture {
+	for i, f := range m.fixtures {
+		if f.Kind == obj.GetKind() && (f.APIVersion == "" || f.APIVersion == obj.GetAPIVersion()) {
+			return &m.fixtures[i]
+		}
+	}
+	return nil
+}
+
+// Create or update connection secret referenced by managed resource
+func (m *mockController) writeConnectionSecret(ctx context.Context, obj *unstructured.Unstructured, details map[string]interface{}) error {
+	ref, found, _ := unstructured.NestedStringMap(obj.Object, "spec", "writeConnectionSecretToRef")
+	if !found || ref["name"] == "" {
+		return nil
+	}
+	data := map[string][]byte{}
+	for k, v := range details {
+		data[k] = []byte(fmt.Sprint(v))
+	}
+	secrets := m.client.CoreV1().Secrets(ref["namespace"])
+	existing, err := secrets.Get(ctx, ref["name"], metav1.GetOptions{})
+	switch {
+	case kerrors.IsNotFound(err):
+		_, err = secrets.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ref["name"],
+				Namespace: ref["namespace"],
+				Labels:    engine.ManagedLabels(nil),
+			},
+			Type: "connection.crossplane.io/v1alpha1",
+			Data: data,
+		}, metav1.CreateOptions{})
+	case err == nil && !equality.Semantic.DeepEqual(existing.Data, data):
+		existing.Data = data
+		_, err = secrets.Update(ctx, existing, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("cannot write connection secret %s: %w", ref["name"], err)
+	}
+	return nil
+}
+
+// Ready and Synced conditions of available resource, other conditions and
+// transition time of unchanged conditions are kept
+func mockConditions(existing []interface{}, now string) []interface{} {
+	reasons := map[string]string{"Ready": "Available", "Synced": "ReconcileSuccess"}
+	conditions := []interface{}{}
+	for _, c := range existing {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		t := fmt.Sprint(condition["type"])
+		if reason, ok := reasons[t]; ok {
+			if condition["status"] != "True" || condition["reason"] != reason {
+				continue
+			}
+			delete(reasons, t)
+		}
+		conditions = append(conditions, condition)
+	}
+	for _, t := range []string{"Ready", "Synced"} {
+		if reason, ok := reasons[t]; ok {
+			conditions = append(conditions, map[string]interface{}{
+				"type":               t,
+				"status":             "True",
+				"reason":             reason,
+				"lastTransitionTime": now,
+			})
+		}
+	}
+	return conditions
+}
+
+// Execute string values of fixture as templates with data, templates are
+// only parsed if data is nil
+func renderFixture(values map[string]interface{}, data map[string]interface{}) (map[string]interface{}, error) {
+	rendered := map[string]interface{}{}
+	for k, v := range values {
+		value, err := renderValue(v, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		rendered[k] = value
+	}
+	return rendered, nil
+}
+
+func renderValue(value interface{}, data map[string]interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		tmpl, err := template.New("fixture").Option("missingkey=error").Parse(v)
+		if err != nil || data == nil {
+			return v, err
+		}
+		out := &strings.Builder{}
+		if err := tmpl.Execute(out, data); err != nil {
+			return nil, err
+		}
+		return out.String(), nil
+	case map[string]interface{}:
+		return renderFixture(v, data)
+	case []interface{}:
+		rendered := make([]interface{}, len(v))
+		for i := range v {
+			item, err := renderValue(v[i], data)
+			if err != nil {
+				return nil, err
+			}
+			rendered[i] = item
+		}
+		return rendered, nil
+	}
+	return value, nil
+}
@@ -0,0 +1,171 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	kfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/web-seven/overlock/internal/image"
+	"github.com/web-seven/overlock/internal/packages"
+)
+
+func TestMockReconcile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures.yaml")
+	err := os.WriteFile(path, []byte(`- kind: Bucket
+  atProvider:
+    arn: 'arn:aws:s3:::{{ index .metadata.annotations "crossplane.io/external-name" }}'
+    versioning: [{enabled: true}]
+    objects: 3
+  connectionDetails:
+    endpoint: 'https://{{ .metadata.name }}.s3.amazonaws.com'
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtures, err := LoadFixtures(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buckets := schema.GroupVersionResource{Group: "s3.aws.upbound.io", Version: "v1beta1", Resource: "buckets"}
+	bucket := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "s3.aws.upbound.io/v1beta1",
+		"kind":       "Bucket",
+		"metadata":   map[string]interface{}{"name": "demo"},
+		"spec": map[string]interface{}{
+			"forProvider":                map[string]interface{}{"region": "eu-central-1"},
+			"writeConnectionSecretToRef": map[string]interface{}{"name": "demo-conn", "namespace": "default"},
+		},
+		"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Synced", "status": "False", "reason": "ReconcileError"},
+			map[string]interface{}{"type": "Custom", "status": "True"},
+		}},
+	}}
+	dc := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		buckets: "BucketList",
+	}, bucket)
+	client := kfake.NewSimpleClientset()
+	m := &mockController{dc: dc, client: client, fixtures: fixtures, logger: zap.NewNop().Sugar()}
+	ctx := context.Background()
+
+	if err := m.reconcile(ctx, buckets, bucket.DeepCopy()); err != nil {
+		t.Fatal(err)
+	}
+	reconciled, err := dc.Resource(buckets).Get(ctx, "demo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := reconciled.GetAnnotations()[annotationExternalName]; name != "demo" {
+		t.Errorf("expected external name demo, got %q", name)
+	}
+	atProvider, _, _ := unstructured.NestedMap(reconciled.Object, "status", "atProvider")
+	if atProvider["id"] != "demo" || atProvider["arn"] != "arn:aws:s3:::demo" || atProvider["objects"] != int64(3) {
+		t.Errorf("unexpected atProvider %v", atProvider)
+	}
+	conditions, _, _ := unstructured.NestedSlice(reconciled.Object, "status", "conditions")
+	if failures := conditionStatus(conditions); failures != "Custom=True Ready=True Synced=True " {
+		t.Errorf("unexpected conditions %s", failures)
+	}
+	secret, err := client.CoreV1().Secrets("default").Get(ctx, "demo-conn", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if endpoint := string(secret.Data["endpoint"]); endpoint != "https://demo.s3.amazonaws.com" {
+		t.Errorf("unexpected endpoint %q", endpoint)
+	}
+
+	// Reconciled resource is not updated again
+	actions := len(dc.Actions())
+	if err := m.reconcile(ctx, buckets, reconciled.DeepCopy()); err != nil {
+		t.Fatal(err)
+	}
+	for _, action := range dc.Actions()[actions:] {
+		if action.GetVerb() == "update" {
+			t.Errorf("unexpected update of reconciled resource %v", action)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("- kind: Bucket\n  atProvider: {arn: '{{ .metadata.name'}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFixtures(path); err == nil {
+		t.Error("expected error of invalid template")
+	}
+}
+
+func TestPathObjects(t *testing.T) {
+	content := []byte(`apiVersion: meta.pkg.crossplane.io/v1
+kind: Provider
+metadata:
+  name: provider-nop
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: nopresources.nop.crossplane.io
+`)
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, packages.PackagePath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, packages.PackagePath, "crossplane.yaml"), content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Archive built by Crossplane CLI labels config with package layer
+	layer, err := crane.Layer(map[string][]byte{image.PackageFileName: content})
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, _ := layer.Digest()
+	img, err := mutate.AppendLayers(empty.Image, layer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err = mutate.Config(img, v1.Config{Labels: map[string]string{image.AnnotationKey + ":" + digest.String(): image.AnnotationBase}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(dir, "provider-nop.xpkg")
+	tag, _ := name.NewTag("provider-nop:v0.1.0")
+	if err := tarball.WriteToFile(archive, tag, img); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{dir, archive} {
+		objects, err := pathObjects(context.Background(), nil, path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if len(objects) != 2 || objects[1].GetName() != "nopresources.nop.crossplane.io" {
+			t.Errorf("%s: unexpected objects %v", path, objects)
+		}
+	}
+	if _, err := pathObjects(context.Background(), nil, filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected error of missing path")
+	}
+}
+
+func conditionStatus(conditions []interface{}) string {
+	out := ""
+	for _, c := range conditions {
+		condition := c.(map[string]interface{})
+		out += condition["type"].(string) + "=" + condition["status"].(string) + " "
+	}
+	return out
+}
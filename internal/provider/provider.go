@@ -6,6 +6,7 @@ import (
 	"go.uber.org/zap"
 
 	provider "github.com/crossplane/crossplane/apis/pkg/v1"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/empty"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -20,8 +21,14 @@ import (
 type Provider struct {
 	Name    string
 	Image   image.Image
+	Index   v1.ImageIndex
 	Upgrade bool
 	Apply   bool
+	SignKey string
+	// DeploymentRuntimeConfig referenced by applied provider
+	RuntimeConfig string
+	// Load directory without linting it
+	SkipLint bool
 	packages.Package
 }
 
@@ -43,6 +50,16 @@ func (p *Provider) WithApply(apply bool) *Provider {
 	return p
 }
 
+func (p *Provider) WithSignKey(keyPath string) *Provider {
+	p.SignKey = keyPath
+	return p
+}
+
+func (p *Provider) WithRuntimeConfig(name string) *Provider {
+	p.RuntimeConfig = name
+	return p
+}
+
 // Get list of providers from k8s context
 func ListProviders(ctx context.Context, dynamicClient dynamic.Interface, logger *zap.SugaredLogger) []provider.Provider {
 	destConf, _ := kube.GetKubeResources(kube.ResourceParams{
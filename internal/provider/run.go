@@ -0,0 +1,312 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	cmv1 "github.com/crossplane/crossplane/apis/pkg/meta/v1"
+	"github.com/google/go-containerregistry/pkg/name"
+	"go.uber.org/zap"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+
+	"github.com/web-seven/overlock/internal/build"
+	"github.com/web-seven/overlock/internal/image"
+	"github.com/web-seven/overlock/internal/kube"
+	"github.com/web-seven/overlock/internal/lint"
+	"github.com/web-seven/overlock/internal/packages"
+	"github.com/web-seven/overlock/internal/runtimeconfig"
+	"github.com/web-seven/overlock/internal/watcher"
+)
+
+const (
+	// Prefix of runtime config scaling in-cluster provider to zero while it runs locally
+	runConfigPrefix = "overlock-run-"
+
+	automaticActivation = "Automatic"
+	manualActivation    = "Manual"
+	inactiveRevision    = "Inactive"
+)
+
+// RunOptions of provider running out of cluster
+type RunOptions struct {
+	// Name of installed provider scaled to zero, detected from package meta if empty
+	Provider string
+	// Arguments of provider process
+	Args []string
+	// Run provider with delve listening on address
+	Delve string
+}
+
+// Run provider from source directory out of cluster against current
+// environment. CRDs of package are installed, active revision of installed
+// provider is deactivated and in-cluster provider runtime is scaled to zero
+// until run is stopped. Provider is rebuilt and restarted
+// on changes.
+func Run(ctx context.Context, dc dynamic.Interface, config *rest.Config, logger *zap.SugaredLogger, path string, mainPath string, options RunOptions, watchOptions watcher.Options) error {
+	meta := &cmv1.Provider{}
+	if err := packages.ReadMeta(filepath.Join(path, packages.PackagePath), "Provider", meta); err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "overlock-run-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	kubeconfig := filepath.Join(dir, "kubeconfig")
+	if err := kube.WriteKubeconfig(config, kubeconfig); err != nil {
+		return err
+	}
+
+	pvdName := options.Provider
+	if pvdName == "" {
+		pvdName = installedProvider(ctx, dc, meta.GetName(), logger)
+	}
+	if pvdName != "" {
+		reactivate, err := deactivate(ctx, dc, pvdName, logger)
+		if err != nil {
+			return err
+		}
+		defer reactivate()
+		restore, err := scaleDown(ctx, dc, pvdName, logger)
+		if err != nil {
+			return err
+		}
+		defer restore()
+	} else {
+		logger.Infof("Provider %s is not installed in environment, only CRDs are installed.", meta.GetName())
+	}
+
+	cfg, err := build.LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	binary := filepath.Join(dir, providerFileName)
+
+	watchOptions.Extensions = []string{".yaml", ".go"}
+	w := watcher.New(path, logger, watchOptions)
+	return w.Run(ctx, func(ctx context.Context, cycle *watcher.Cycle) error {
+		if err := cycle.Step("crds", func() error { return applyCRDs(ctx, dc, config, filepath.Join(path, packages.PackagePath), logger) }); err != nil {
+			return err
+		}
+		err := cycle.Step("build", func() error {
+			return cfg.LocalBinary(ctx, filepath.Join(path, mainPath), binary, options.Delve != "")
+		})
+		if err != nil {
+			return err
+		}
+		cycle.Follow(func(ctx context.Context) error {
+			fmt.Printf("──── cycle #%d: %s ────\n", cycle.Number, meta.GetName())
+			process := &build.LocalProcess{Binary: binary, Args: options.Args, Env: []string{"KUBECONFIG=" + kubeconfig}, Delve: options.Delve}
+			return process.Run(ctx)
+		})
+		return nil
+	})
+}
+
+// Find installed provider object of package by repository name
+func installedProvider(ctx context.Context, dc dynamic.Interface, pkgName string, logger *zap.SugaredLogger) string {
+	for _, pvd := range ListProviders(ctx, dc, logger) {
+		ref, err := name.ParseReference(pvd.Spec.Package, name.WithDefaultRegistry(""))
+		if err != nil {
+			continue
+		}
+		repository := ref.Context().RepositoryStr()
+		if repository == pkgName || strings.HasSuffix(repository, "/"+pkgName) {
+			return pvd.Name
+		}
+	}
+	return ""
+}
+
+// Deactivate active revision of provider, so package manager does not revert
+// CRDs applied from source directory. Returned function activates revision
+// and restores revision activation policy of provider.
+func deactivate(ctx context.Context, dc dynamic.Interface, pvdName string, logger *zap.SugaredLogger) (func(), error) {
+	pvd, err := dc.Resource(packages.Providers).Get(ctx, pvdName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	policy, _, _ := unstructured.NestedString(pvd.Object, "spec", "revisionActivationPolicy")
+	if policy == "" {
+		policy = automaticActivation
+	}
+	history, err := packages.History(ctx, dc, packages.ProviderRevisions, pvdName)
+	if err != nil {
+		return nil, err
+	}
+	revision := ""
+	for _, rev := range history {
+		if rev.State == packages.ActiveRevision {
+			revision = rev.Name
+		}
+	}
+	if revision == "" {
+		return func() {}, nil
+	}
+
+	if err := patchSpec(ctx, dc, packages.Providers, pvdName, "revisionActivationPolicy", manualActivation); err != nil {
+		return nil, err
+	}
+	if err := patchSpec(ctx, dc, packages.ProviderRevisions, revision, "desiredState", inactiveRevision); err != nil {
+		return nil, err
+	}
+	logger.Infof("Revision %s of %s deactivated.", revision, pvdName)
+
+	return func() {
+		// Run context is cancelled at this point
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := patchSpec(ctx, dc, packages.ProviderRevisions, revision, "desiredState", packages.ActiveRevision); err != nil {
+			logger.Warnf("Cannot activate revision %s: %v", revision, err)
+			return
+		}
+		if err := patchSpec(ctx, dc, packages.Providers, pvdName, "revisionActivationPolicy", policy); err != nil {
+			logger.Warnf("Cannot restore revision activation policy of %s: %v", pvdName, err)
+			return
+		}
+		logger.Infof("Revision %s of %s activated.", revision, pvdName)
+	}, nil
+}
+
+// Set spec field of object with merge patch
+func patchSpec(ctx context.Context, dc dynamic.Interface, gvr schema.GroupVersionResource, objName string, field string, value string) error {
+	patch := fmt.Sprintf(`{"spec":{%q:%q}}`, field, value)
+	_, err := dc.Resource(gvr).Patch(ctx, objName, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+	return err
+}
+
+// Scale in-cluster provider runtime to zero with runtime config, returned
+// function restores previous runtime config of provider
+func scaleDown(ctx context.Context, dc dynamic.Interface, pvdName string, logger *zap.SugaredLogger) (func(), error) {
+	pvd, err := dc.Resource(packages.Providers).Get(ctx, pvdName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	previous, _, _ := unstructured.NestedMap(pvd.Object, "spec", "runtimeConfigRef")
+	if previous != nil && strings.HasPrefix(fmt.Sprint(previous["name"]), runConfigPrefix) {
+		// Previous run was not stopped cleanly, restore to default runtime config
+		previous = map[string]interface{}{"name": runtimeconfig.DefaultName}
+	}
+
+	rc, err := runConfig(ctx, dc, pvdName, previous)
+	if err != nil {
+		return nil, err
+	}
+	if err := runtimeconfig.Apply(ctx, dc, rc, logger); err != nil {
+		return nil, err
+	}
+	if err := patchRuntimeConfigRef(ctx, dc, pvdName, map[string]interface{}{"name": rc.GetName()}); err != nil {
+		return nil, err
+	}
+	logger.Infof("In-cluster runtime of %s scaled to zero.", pvdName)
+
+	return func() {
+		// Run context is cancelled at this point
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := patchRuntimeConfigRef(ctx, dc, pvdName, previous); err != nil {
+			logger.Warnf("Cannot restore runtime config of %s: %v", pvdName, err)
+			return
+		}
+		if err := dc.Resource(packages.RuntimeConfigs).Delete(ctx, rc.GetName(), metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+			logger.Warnf("Cannot delete runtime config %s: %v", rc.GetName(), err)
+		}
+		logger.Infof("In-cluster runtime of %s restored.", pvdName)
+	}, nil
+}
+
+// Runtime config with zero replicas, settings of runtime config currently
+// used by provider, or of the default runtime config, are kept
+func runConfig(ctx context.Context, dc dynamic.Interface, pvdName string, previous map[string]interface{}) (*unstructured.Unstructured, error) {
+	rcName := runConfigPrefix + pvdName
+	if previous != nil {
+		current, err := dc.Resource(packages.RuntimeConfigs).Get(ctx, fmt.Sprint(previous["name"]), metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		spec, _, _ := unstructured.NestedMap(current.Object, "spec")
+		rc := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+		rc.SetGroupVersionKind(current.GroupVersionKind())
+		rc.SetName(rcName)
+		return rc, unstructured.SetNestedField(rc.Object, int64(0), "spec", "deploymentTemplate", "spec", "replicas")
+	}
+	template, err := runtimeconfig.DefaultTemplate(ctx, dc)
+	if err != nil {
+		return nil, err
+	}
+	replicas := int64(0)
+	opts := runtimeconfig.Options{Name: rcName, Replicas: &replicas, Template: template}
+	return opts.Manifest()
+}
+
+// Set runtime config reference of provider, nil reference removes it
+func patchRuntimeConfigRef(ctx context.Context, dc dynamic.Interface, pvdName string, ref map[string]interface{}) error {
+	var patch string
+	if ref == nil {
+		patch = `{"spec":{"runtimeConfigRef":null}}`
+	} else {
+		patch = fmt.Sprintf(`{"spec":{"runtimeConfigRef":{"name":%q}}}`, ref["name"])
+	}
+	_, err := dc.Resource(packages.Providers).Patch(ctx, pvdName, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+	return err
+}
+
+// Create or update CRDs from package directory
+func applyCRDs(ctx context.Context, dc dynamic.Interface, config *rest.Config, dir string, logger *zap.SugaredLogger) error {
+	objects, err := packageDirObjects(ctx, config, dir)
+	if err != nil {
+		return err
+	}
+	_, err = applyCRDObjects(ctx, dc, objects, logger)
+	return err
+}
+
+// Objects of provider package directory
+func packageDirObjects(ctx context.Context, config *rest.Config, dir string) ([]unstructured.Unstructured, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	layer, err := image.LoadPackageLayerDirectory(ctx, config, dir, lint.PackageKinds[lint.KindProvider])
+	if err != nil {
+		return nil, err
+	}
+	return image.LayerObjects(layer)
+}
+
+// Create or update CRDs among objects, returns applied CRDs
+func applyCRDObjects(ctx context.Context, dc dynamic.Interface, objects []unstructured.Unstructured, logger *zap.SugaredLogger) ([]unstructured.Unstructured, error) {
+	applied := []unstructured.Unstructured{}
+	for i := range objects {
+		crd := objects[i].DeepCopy()
+		if crd.GetKind() != "CustomResourceDefinition" {
+			continue
+		}
+		existing, err := dc.Resource(packages.CustomResourceDefinitions).Get(ctx, crd.GetName(), metav1.GetOptions{})
+		switch {
+		case kerrors.IsNotFound(err):
+			_, err = dc.Resource(packages.CustomResourceDefinitions).Create(ctx, crd, metav1.CreateOptions{})
+		case err == nil:
+			// Keep package revisions owning CRD
+			crd.SetResourceVersion(existing.GetResourceVersion())
+			crd.SetOwnerReferences(existing.GetOwnerReferences())
+			_, err = dc.Resource(packages.CustomResourceDefinitions).Update(ctx, crd, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot apply CRD %s: %w", crd.GetName(), err)
+		}
+		applied = append(applied, *crd)
+	}
+	logger.Debugf("%d CRD(s) applied.", len(applied))
+	return applied, nil
+}
@@ -0,0 +1,171 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/web-seven/overlock/internal/packages"
+)
+
+func TestScaleDown(t *testing.T) {
+	provider := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "pkg.crossplane.io/v1",
+		"kind":       "Provider",
+		"metadata":   map[string]interface{}{"name": "provider-nop"},
+		"spec": map[string]interface{}{
+			"package":          "xpkg.upbound.io/crossplane-contrib/provider-nop:v0.2.1",
+			"runtimeConfigRef": map[string]interface{}{"name": "default"},
+		},
+	}}
+	defaultConfig := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "pkg.crossplane.io/v1beta1",
+		"kind":       "DeploymentRuntimeConfig",
+		"metadata":   map[string]interface{}{"name": "default"},
+		"spec": map[string]interface{}{
+			"deploymentTemplate": map[string]interface{}{
+				"spec": map[string]interface{}{"selector": map[string]interface{}{}, "replicas": int64(1)},
+			},
+		},
+	}}
+	dc := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		packages.Providers:      "ProviderList",
+		packages.RuntimeConfigs: "DeploymentRuntimeConfigList",
+	}, provider, defaultConfig)
+	ctx := context.Background()
+	logger := zap.NewNop().Sugar()
+
+	if name := installedProvider(ctx, dc, "provider-nop", logger); name != "provider-nop" {
+		t.Fatalf("expected installed provider-nop, got %q", name)
+	}
+
+	restore, err := scaleDown(ctx, dc, "provider-nop", logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, err := dc.Resource(packages.RuntimeConfigs).Get(ctx, runConfigPrefix+"provider-nop", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replicas, _, _ := unstructured.NestedInt64(rc.Object, "spec", "deploymentTemplate", "spec", "replicas"); replicas != 0 {
+		t.Errorf("expected zero replicas, got %d", replicas)
+	}
+	pvd, _ := dc.Resource(packages.Providers).Get(ctx, "provider-nop", metav1.GetOptions{})
+	if ref, _, _ := unstructured.NestedString(pvd.Object, "spec", "runtimeConfigRef", "name"); ref != runConfigPrefix+"provider-nop" {
+		t.Errorf("provider references runtime config %q", ref)
+	}
+
+	restore()
+	pvd, _ = dc.Resource(packages.Providers).Get(ctx, "provider-nop", metav1.GetOptions{})
+	if ref, _, _ := unstructured.NestedString(pvd.Object, "spec", "runtimeConfigRef", "name"); ref != "default" {
+		t.Errorf("runtime config of provider not restored, got %q", ref)
+	}
+	if _, err := dc.Resource(packages.RuntimeConfigs).Get(ctx, runConfigPrefix+"provider-nop", metav1.GetOptions{}); err == nil {
+		t.Error("runtime config of run not deleted")
+	}
+}
+
+func TestApplyCRDs(t *testing.T) {
+	dir := t.TempDir()
+	meta := `apiVersion: meta.pkg.crossplane.io/v1
+kind: Provider
+metadata:
+  name: provider-nop
+`
+	crd := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: nopresources.nop.crossplane.io
+`
+	if err := os.WriteFile(filepath.Join(dir, "crossplane.yaml"), []byte(meta), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "nopresources.yaml"), []byte(crd), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	owned := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata": map[string]interface{}{
+			"name": "nopresources.nop.crossplane.io",
+			"ownerReferences": []interface{}{map[string]interface{}{
+				"apiVersion": "pkg.crossplane.io/v1",
+				"kind":       "ProviderRevision",
+				"name":       "provider-nop-0123456789ab",
+				"uid":        "1",
+			}},
+		},
+	}}
+	dc := fake.NewSimpleDynamicClient(runtime.NewScheme(), owned)
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := applyCRDs(ctx, dc, nil, dir, zap.NewNop().Sugar()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	applied, err := dc.Resource(packages.CustomResourceDefinitions).Get(ctx, "nopresources.nop.crossplane.io", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("CRD not applied: %v", err)
+	}
+	if refs := applied.GetOwnerReferences(); len(refs) != 1 || refs[0].Kind != "ProviderRevision" {
+		t.Errorf("owner references of revision not kept, got %v", refs)
+	}
+}
+
+func TestDeactivate(t *testing.T) {
+	provider := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "pkg.crossplane.io/v1",
+		"kind":       "Provider",
+		"metadata":   map[string]interface{}{"name": "provider-nop"},
+		"spec":       map[string]interface{}{"package": "xpkg.upbound.io/crossplane-contrib/provider-nop:v0.2.1"},
+	}}
+	revision := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "pkg.crossplane.io/v1",
+		"kind":       "ProviderRevision",
+		"metadata": map[string]interface{}{
+			"name":   "provider-nop-0123456789ab",
+			"labels": map[string]interface{}{packages.PackageLabel: "provider-nop"},
+		},
+		"spec": map[string]interface{}{"revision": int64(1), "desiredState": "Active"},
+	}}
+	dc := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		packages.Providers:         "ProviderList",
+		packages.ProviderRevisions: "ProviderRevisionList",
+	}, provider, revision)
+	ctx := context.Background()
+	field := func(gvr schema.GroupVersionResource, name string, fields ...string) string {
+		u, err := dc.Resource(gvr).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		value, _, _ := unstructured.NestedString(u.Object, fields...)
+		return value
+	}
+
+	reactivate, err := deactivate(ctx, dc, "provider-nop", zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state := field(packages.ProviderRevisions, "provider-nop-0123456789ab", "spec", "desiredState"); state != "Inactive" {
+		t.Errorf("expected inactive revision, got %q", state)
+	}
+	if policy := field(packages.Providers, "provider-nop", "spec", "revisionActivationPolicy"); policy != "Manual" {
+		t.Errorf("expected manual activation policy, got %q", policy)
+	}
+
+	reactivate()
+	if state := field(packages.ProviderRevisions, "provider-nop-0123456789ab", "spec", "desiredState"); state != "Active" {
+		t.Errorf("expected active revision, got %q", state)
+	}
+	if policy := field(packages.Providers, "provider-nop", "spec", "revisionActivationPolicy"); policy != "Automatic" {
+		t.Errorf("expected automatic activation policy, got %q", policy)
+	}
+}
@@ -4,104 +4,74 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"path/filepath"
 
 	cmv1 "github.com/crossplane/crossplane/apis/pkg/meta/v1"
-	"github.com/rjeczalik/notify"
 	"go.uber.org/zap"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
-	"sigs.k8s.io/yaml"
 
+	"github.com/web-seven/overlock/internal/kube"
 	"github.com/web-seven/overlock/internal/packages"
+	"github.com/web-seven/overlock/internal/watcher"
 )
 
-func Serve(ctx context.Context, dc *dynamic.DynamicClient, config *rest.Config, logger *zap.SugaredLogger, path string, mainPath string) error {
+// Serve provider directory, served holds load options of provider
+func Serve(ctx context.Context, dc *dynamic.DynamicClient, config *rest.Config, logger *zap.SugaredLogger, path string, mainPath string, served *Provider, options watcher.Options) error {
 	logger.Infof("Started serve path: %s", path)
 
-	loadServed(ctx, dc, config, logger, path, mainPath)
+	options.Extensions = []string{".yaml", ".go"}
+	w := watcher.New(path, logger, options)
+	return w.Run(ctx, func(ctx context.Context, cycle *watcher.Cycle) error {
+		return loadServed(ctx, cycle, dc, config, logger, path, mainPath, served)
+	})
+}
 
-	c := make(chan notify.EventInfo, 1)
+// Build and load served provider into k8s context
+func loadServed(ctx context.Context, cycle *watcher.Cycle, dc *dynamic.DynamicClient, config *rest.Config, logger *zap.SugaredLogger, path string, mainPath string, served *Provider) error {
+	cpvd := &cmv1.Provider{}
+	if err := packages.ReadMeta(fmt.Sprintf("%s/%s", path, packages.PackagePath), "Provider", cpvd); err != nil {
+		return err
+	}
+	pvd := New(fmt.Sprintf("%s:0.0.0", cpvd.GetName()))
+	pvd.SkipLint = served.SkipLint
 
-	if err := notify.Watch(fmt.Sprintf("%s/%s", path, "..."), c, notify.Create, notify.Write, notify.Rename, notify.Remove); err != nil {
+	if err := cycle.Step("lint", func() error { return pvd.lint(path, logger) }); err != nil {
 		return err
 	}
-	defer notify.Stop(c)
 
-	go func() {
-		for {
-			select {
-			case ev := <-c:
-				fileExt := filepath.Ext(ev.Path())
-				if fileExt == ".yaml" || fileExt == ".go" {
-					logger.Debugf("Changed file: %s", ev)
-					loadServed(ctx, dc, config, logger, path, mainPath)
-				}
-			case <-ctx.Done():
-				return
-			}
-		}
-	}()
+	logger.Debugf("Upgrade provider: %s", pvd.Name)
+	if err := cycle.Step("version", func() error { return pvd.UpgradeProvider(ctx, config, dc, logger) }); err != nil {
+		return err
+	}
 
-	<-make(chan struct{})
-	return nil
-}
+	logger.Infof("Apply provider: %s", pvd.Name)
+	if err := cycle.Step("build", func() error { return pvd.buildDirectory(ctx, config, logger, path, mainPath) }); err != nil {
+		return err
+	}
+	if err := cycle.Step("push", func() error { return pvd.load(ctx, config, logger) }); err != nil {
+		return err
+	}
+	if err := cycle.Step("apply", func() error { return pvd.ApplyPackage(ctx, config, logger) }); err != nil {
+		return err
+	}
 
-// Build and load served provider into k8s context
-func loadServed(ctx context.Context, dc *dynamic.DynamicClient, config *rest.Config, logger *zap.SugaredLogger, path string, mainPath string) {
-	packagePath := fmt.Sprintf("%s/%s", path, packages.PackagePath)
-	packFiles, err := os.ReadDir(packagePath)
+	client, err := kube.Client(config)
 	if err != nil {
-		logger.Error(err)
+		return err
 	}
-
-	for _, e := range packFiles {
-		if e.Type().IsRegular() {
-			if filepath.Ext(e.Name()) != ".yaml" {
-				continue
-			}
-
-			res := &metav1.TypeMeta{}
-			yamlFile, err := os.ReadFile(fmt.Sprintf("%s/%s", packagePath, e.Name()))
-			if err != nil {
-				logger.Error(err)
-			}
-			err = yaml.Unmarshal(yamlFile, res)
-			if err != nil {
-				logger.Infof("Found non YAML file in package directory: %s. Please move it out before build package.", e.Name())
-				continue
-			}
-			logger.Debugf("Package found with kind: %s", res.Kind)
-			if res.Kind == "Provider" {
-				cpvd := &cmv1.Provider{}
-				err = yaml.Unmarshal(yamlFile, cpvd)
-				if err != nil {
-					logger.Error(err)
-				}
-				pvdName := fmt.Sprintf("%s:0.0.0", cpvd.GetName())
-				pvd := New(pvdName)
-
-				logger.Debugf("Upgrade provider: %s", pvd)
-				err := pvd.UpgradeProvider(ctx, config, dc, logger)
-
-				logger.Infof("Changes detected, apply provider: %s", cpvd.GetName())
-				if err != nil {
-					logger.Error(err)
-				} else {
-					logger.Debugf("Loading provider: %s", pvd)
-					err = pvd.LoadDirectory(ctx, config, logger, path, mainPath)
-					if err != nil {
-						logger.Error(err)
-					} else {
-						logger.Debugf("Loading provider: %s", pvd)
-						err = pvd.ApplyPackage(ctx, config, logger)
-						if err != nil {
-							logger.Error(err)
-						}
-					}
-				}
-			}
-		}
+	var revision *unstructured.Unstructured
+	err = cycle.Step("healthy", func() (err error) {
+		revision, err = packages.WaitRevision(ctx, dc, client, packages.ProviderRevisions, pvd.Name, packages.DefaultRevisionTimeout, logger)
+		return err
+	})
+	if err != nil {
+		return err
 	}
+
+	cycle.Follow(func(ctx context.Context) error {
+		fmt.Printf("──── cycle #%d: logs of %s ────\n", cycle.Number, revision.GetName())
+		return packages.StreamRevisionLogs(ctx, client, revision.GetName(), os.Stdout)
+	})
+	return nil
 }
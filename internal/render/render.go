@@ -0,0 +1,322 @@
+package render
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	fnv1beta1 "github.com/crossplane/crossplane/apis/apiextensions/fn/proto/v1beta1"
+	xpv1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	pkgv1beta1 "github.com/crossplane/crossplane/apis/pkg/v1beta1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/structpb"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/web-seven/overlock/internal/engine"
+	"github.com/web-seven/overlock/internal/image"
+	"github.com/web-seven/overlock/internal/resources"
+)
+
+// Label of composed resources referencing composite resource
+const LabelComposite = "crossplane.io/composite"
+
+// Time given to single pipeline step, includes start of function runtime
+const stepTimeout = time.Minute
+
+// Inputs of composition pipeline rendering
+type Inputs struct {
+	Composite   *unstructured.Unstructured
+	Composition *xpv1.Composition
+	Functions   []pkgv1beta1.Function
+	// Definition of composite resource, its schema defaults are applied and
+	// required fields are validated
+	XRD *xpv1.CompositeResourceDefinition
+	// Package routes expanding packages of functions run in Docker
+	Routes []engine.Route
+	// Observed composed resources, annotated with composition resource name
+	Observed []unstructured.Unstructured
+}
+
+// Outputs of composition pipeline rendering
+type Outputs struct {
+	Composite *unstructured.Unstructured
+	Composed  []unstructured.Unstructured
+	Results   []*fnv1beta1.Result
+}
+
+// Render composite resource by running composition pipeline steps against
+// functions, desired state of each step is passed to the next one
+func Render(ctx context.Context, in Inputs, logger *zap.SugaredLogger) (*Outputs, error) {
+	if in.Composition.Spec.Mode == nil || *in.Composition.Spec.Mode != xpv1.CompositionModePipeline {
+		return nil, fmt.Errorf("composition %s does not use %s mode", in.Composition.GetName(), xpv1.CompositionModePipeline)
+	}
+	xr := in.Composite.DeepCopy()
+	if in.XRD != nil {
+		if err := completeComposite(xr, *in.XRD); err != nil {
+			return nil, err
+		}
+	}
+
+	// Later functions override earlier ones of the same name
+	functions := map[string]pkgv1beta1.Function{}
+	for _, fn := range in.Functions {
+		functions[fn.GetName()] = fn
+	}
+	addresses := map[string]string{}
+	for _, step := range in.Composition.Spec.Pipeline {
+		name := step.FunctionRef.Name
+		if _, ok := addresses[name]; ok {
+			continue
+		}
+		fn, ok := functions[name]
+		if !ok {
+			return nil, fmt.Errorf("function %s of step %s is not provided, pass it with --functions or --function %s=<address>", name, step.Step, name)
+		}
+		rt, err := NewRuntime(fn, in.Routes)
+		if err != nil {
+			return nil, err
+		}
+		address, stop, err := rt.Start(ctx, logger)
+		if err != nil {
+			return nil, fmt.Errorf("cannot start function %s: %w", name, err)
+		}
+		defer stop()
+		addresses[name] = address
+	}
+
+	observed, err := observedState(xr, in.Observed)
+	if err != nil {
+		return nil, err
+	}
+	desired := &fnv1beta1.State{}
+	fnctx := &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	results := []*fnv1beta1.Result{}
+
+	for _, step := range in.Composition.Spec.Pipeline {
+		req := &fnv1beta1.RunFunctionRequest{
+			Meta:     &fnv1beta1.RequestMeta{Tag: step.Step},
+			Observed: observed,
+			Desired:  desired,
+			Context:  fnctx,
+		}
+		if step.Input != nil {
+			if req.Input, err = rawToStruct(step.Input); err != nil {
+				return nil, fmt.Errorf("cannot parse input of step %s: %w", step.Step, err)
+			}
+		}
+		logger.Debugf("Running step %s with function %s at %s.", step.Step, step.FunctionRef.Name, addresses[step.FunctionRef.Name])
+		rsp, err := runFunction(ctx, addresses[step.FunctionRef.Name], req)
+		if err != nil {
+			return nil, fmt.Errorf("cannot run step %s: %w", step.Step, err)
+		}
+		for _, result := range rsp.GetResults() {
+			results = append(results, result)
+			switch result.GetSeverity() {
+			case fnv1beta1.Severity_SEVERITY_FATAL:
+				return nil, fmt.Errorf("step %s returned fatal result: %s", step.Step, result.GetMessage())
+			case fnv1beta1.Severity_SEVERITY_WARNING:
+				logger.Warnf("%s: %s", step.Step, result.GetMessage())
+			default:
+				logger.Infof("%s: %s", step.Step, result.GetMessage())
+			}
+		}
+		desired = rsp.GetDesired()
+		if rsp.GetContext() != nil {
+			fnctx = rsp.GetContext()
+		}
+	}
+
+	return outputs(xr, desired, results), nil
+}
+
+// Apply defaults of XRD schema to composite resource and validate its
+// required fields, as API server does for composite resources
+func completeComposite(xr *unstructured.Unstructured, xrd xpv1.CompositeResourceDefinition) error {
+	gvk := xr.GroupVersionKind()
+	if gvk.Group != xrd.Spec.Group || gvk.Kind != xrd.Spec.Names.Kind {
+		return fmt.Errorf("%s is not defined by %s", gvk.GroupKind(), xrd.GetName())
+	}
+	props, err := resources.XRDSchema(xrd, gvk.Version)
+	if err != nil {
+		return err
+	}
+	return resources.CompleteXResource(xr, props)
+}
+
+// Run single function request over insecure gRPC connection, function is
+// waited to be ready until step timeout
+func runFunction(ctx context.Context, address string, req *fnv1beta1.RunFunctionRequest) (*fnv1beta1.RunFunctionResponse, error) {
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(ctx, stepTimeout)
+	defer cancel()
+	return fnv1beta1.NewFunctionRunnerServiceClient(conn).RunFunction(ctx, req, grpc.WaitForReady(true))
+}
+
+// Observed state of composite and composed resources
+func observedState(xr *unstructured.Unstructured, observed []unstructured.Unstructured) (*fnv1beta1.State, error) {
+	composite, err := structpb.NewStruct(xr.Object)
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert composite resource: %w", err)
+	}
+	state := &fnv1beta1.State{
+		Composite: &fnv1beta1.Resource{Resource: composite},
+		Resources: map[string]*fnv1beta1.Resource{},
+	}
+	for _, obj := range observed {
+		name := obj.GetAnnotations()[resources.AnnotationCompositionResourceName]
+		if name == "" {
+			return nil, fmt.Errorf("observed resource %s has no %s annotation", obj.GetName(), resources.AnnotationCompositionResourceName)
+		}
+		resource, err := structpb.NewStruct(obj.Object)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert observed resource %s: %w", name, err)
+		}
+		state.Resources[name] = &fnv1beta1.Resource{Resource: resource}
+	}
+	return state, nil
+}
+
+// Composite resource with desired status and composed resources labeled as
+// Crossplane does, sorted by composition resource name
+func outputs(xr *unstructured.Unstructured, desired *fnv1beta1.State, results []*fnv1beta1.Result) *Outputs {
+	out := &Outputs{Composite: xr.DeepCopy(), Results: results}
+	if status, ok := desired.GetComposite().GetResource().AsMap()["status"].(map[string]interface{}); ok {
+		current, _, _ := unstructured.NestedMap(out.Composite.Object, "status")
+		if current == nil {
+			current = map[string]interface{}{}
+		}
+		for k, v := range status {
+			current[k] = v
+		}
+		_ = unstructured.SetNestedMap(out.Composite.Object, current, "status")
+	}
+
+	names := []string{}
+	for name := range desired.GetResources() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		obj := unstructured.Unstructured{Object: desired.GetResources()[name].GetResource().AsMap()}
+		if obj.GetName() == "" && obj.GetGenerateName() == "" {
+			obj.SetGenerateName(xr.GetName() + "-")
+		}
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[LabelComposite] = xr.GetName()
+		obj.SetLabels(labels)
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[resources.AnnotationCompositionResourceName] = name
+		obj.SetAnnotations(annotations)
+		out.Composed = append(out.Composed, obj)
+	}
+	return out
+}
+
+func rawToStruct(raw *runtime.RawExtension) (*structpb.Struct, error) {
+	input := &structpb.Struct{}
+	return input, input.UnmarshalJSON(raw.Raw)
+}
+
+// LoadObjects reads objects from YAML file
+func LoadObjects(path string) ([]unstructured.Unstructured, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := image.ParseObjects(content)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+	return objects, nil
+}
+
+// LoadComposite reads single composite resource from YAML file
+func LoadComposite(path string) (*unstructured.Unstructured, error) {
+	objects, err := LoadObjects(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) != 1 {
+		return nil, fmt.Errorf("%s must contain single composite resource, found %d objects", path, len(objects))
+	}
+	return &objects[0], nil
+}
+
+// LoadComposition reads composition from YAML file
+func LoadComposition(path string) (*xpv1.Composition, error) {
+	objects, err := LoadObjects(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objects {
+		if obj.GetKind() == xpv1.CompositionKind {
+			comp := &xpv1.Composition{}
+			return comp, fromUnstructured(obj, comp)
+		}
+	}
+	return nil, fmt.Errorf("%s does not contain %s", path, xpv1.CompositionKind)
+}
+
+// LoadXRD reads composite resource definition from YAML file
+func LoadXRD(path string) (*xpv1.CompositeResourceDefinition, error) {
+	objects, err := LoadObjects(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objects {
+		if obj.GetKind() == xpv1.CompositeResourceDefinitionKind {
+			xrd := &xpv1.CompositeResourceDefinition{}
+			return xrd, fromUnstructured(obj, xrd)
+		}
+	}
+	return nil, fmt.Errorf("%s does not contain %s", path, xpv1.CompositeResourceDefinitionKind)
+}
+
+// LoadFunctions reads functions from YAML file, other objects are skipped
+func LoadFunctions(path string) ([]pkgv1beta1.Function, error) {
+	objects, err := LoadObjects(path)
+	if err != nil {
+		return nil, err
+	}
+	functions := []pkgv1beta1.Function{}
+	for _, obj := range objects {
+		if obj.GetKind() != pkgv1beta1.FunctionKind {
+			continue
+		}
+		fn := pkgv1beta1.Function{}
+		if err := fromUnstructured(obj, &fn); err != nil {
+			return nil, err
+		}
+		functions = append(functions, fn)
+	}
+	return functions, nil
+}
+
+// Convert through JSON, typed Crossplane objects contain fields which
+// unstructured converter does not handle, e.g. raw extensions
+func fromUnstructured(obj unstructured.Unstructured, into interface{}) error {
+	content, err := json.Marshal(obj.Object)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(content, into); err != nil {
+		return fmt.Errorf("cannot decode %s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package render
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	fnv1beta1 "github.com/crossplane/crossplane/apis/apiextensions/fn/proto/v1beta1"
+	xpv1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	pkgv1beta1 "github.com/crossplane/crossplane/apis/pkg/v1beta1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/web-seven/overlock/internal/engine"
+	"github.com/web-seven/overlock/internal/function"
+	"github.com/web-seven/overlock/internal/resources"
+)
+
+// Function adding bucket named by input and counting calls in context
+type testFunction struct {
+	fnv1beta1.UnimplementedFunctionRunnerServiceServer
+}
+
+func (f *testFunction) RunFunction(ctx context.Context, req *fnv1beta1.RunFunctionRequest) (*fnv1beta1.RunFunctionResponse, error) {
+	name := req.GetInput().GetFields()["name"].GetStringValue()
+	if name == "fail" {
+		return &fnv1beta1.RunFunctionResponse{Results: []*fnv1beta1.Result{{Severity: fnv1beta1.Severity_SEVERITY_FATAL, Message: "input rejected"}}}, nil
+	}
+	desired := req.GetDesired()
+	if desired == nil {
+		desired = &fnv1beta1.State{}
+	}
+	if desired.GetResources() == nil {
+		desired.Resources = map[string]*fnv1beta1.Resource{}
+	}
+	bucket, _ := structpb.NewStruct(map[string]interface{}{
+		"apiVersion": "s3.aws.upbound.io/v1beta1",
+		"kind":       "Bucket",
+		"spec":       map[string]interface{}{"forProvider": map[string]interface{}{"region": req.GetObserved().GetComposite().GetResource().AsMap()["spec"].(map[string]interface{})["region"]}},
+	})
+	desired.Resources[name] = &fnv1beta1.Resource{Resource: bucket}
+	calls := req.GetContext().GetFields()["calls"].GetNumberValue() + 1
+	desired.Composite = &fnv1beta1.Resource{Resource: &structpb.Struct{Fields: map[string]*structpb.Value{
+		"status": structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{"calls": structpb.NewNumberValue(calls)}}),
+	}}}
+	fnctx := &structpb.Struct{Fields: map[string]*structpb.Value{"calls": structpb.NewNumberValue(calls)}}
+	return &fnv1beta1.RunFunctionResponse{
+		Desired: desired,
+		Context: fnctx,
+		Results: []*fnv1beta1.Result{{Severity: fnv1beta1.Severity_SEVERITY_NORMAL, Message: "added " + name}},
+	}, nil
+}
+
+func TestRender(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	fnv1beta1.RegisterFunctionRunnerServiceServer(server, &testFunction{})
+	go server.Serve(listener) //nolint:errcheck
+	defer server.Stop()
+
+	xr := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.org/v1",
+		"kind":       "XBucket",
+		"metadata":   map[string]interface{}{"name": "demo"},
+		"spec":       map[string]interface{}{"region": "eu-central-1"},
+	}}
+	step := func(name string, input string) xpv1.PipelineStep {
+		return xpv1.PipelineStep{
+			Step:        name,
+			FunctionRef: xpv1.FunctionReference{Name: "function-test"},
+			Input:       &runtime.RawExtension{Raw: []byte(`{"name":"` + input + `"}`)},
+		}
+	}
+	mode := xpv1.CompositionModePipeline
+	composition := &xpv1.Composition{Spec: xpv1.CompositionSpec{Mode: &mode, Pipeline: []xpv1.PipelineStep{step("first", "logs"), step("second", "data")}}}
+	functions := []pkgv1beta1.Function{DevelopmentFunction("function-test", listener.Addr().String())}
+
+	out, err := Render(context.Background(), Inputs{Composite: xr, Composition: composition, Functions: functions}, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Composed) != 2 || len(out.Results) != 2 {
+		t.Fatalf("expected 2 composed resources and results, got %d and %d", len(out.Composed), len(out.Results))
+	}
+	data := out.Composed[0]
+	if data.GetAnnotations()[resources.AnnotationCompositionResourceName] != "data" || data.GetLabels()[LabelComposite] != "demo" || data.GetGenerateName() != "demo-" {
+		t.Errorf("composed resource metadata not set: %v", data.Object["metadata"])
+	}
+	if region, _, _ := unstructured.NestedString(data.Object, "spec", "forProvider", "region"); region != "eu-central-1" {
+		t.Errorf("expected region from observed composite, got %q", region)
+	}
+	if calls, _, _ := unstructured.NestedFloat64(out.Composite.Object, "status", "calls"); calls != 2 {
+		t.Errorf("expected context passed between steps, got %v calls", calls)
+	}
+
+	composition.Spec.Pipeline = append(composition.Spec.Pipeline, step("third", "fail"))
+	_, err = Render(context.Background(), Inputs{Composite: xr, Composition: composition, Functions: functions}, zap.NewNop().Sugar())
+	if err == nil || !strings.Contains(err.Error(), "input rejected") {
+		t.Errorf("expected fatal result error, got %v", err)
+	}
+
+	_, err = Render(context.Background(), Inputs{Composite: xr, Composition: composition}, zap.NewNop().Sugar())
+	if err == nil || !strings.Contains(err.Error(), "--function function-test=<address>") {
+		t.Errorf("expected missing function error, got %v", err)
+	}
+}
+
+func TestCompleteComposite(t *testing.T) {
+	xrd := xpv1.CompositeResourceDefinition{
+		Spec: xpv1.CompositeResourceDefinitionSpec{
+			Group: "example.org",
+			Names: extv1.CustomResourceDefinitionNames{Kind: "XBucket"},
+			Versions: []xpv1.CompositeResourceDefinitionVersion{{
+				Name:          "v1",
+				Referenceable: true,
+				Schema: &xpv1.CompositeResourceValidation{OpenAPIV3Schema: runtime.RawExtension{Raw: []byte(`{
+					"type": "object",
+					"properties": {"spec": {"type": "object", "required": ["region"], "properties": {
+						"region": {"type": "string"},
+						"acl": {"type": "string", "default": "private"}
+					}}}
+				}`)}},
+			}},
+		},
+	}
+	xrd.SetName("xbuckets.example.org")
+	xr := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.org/v1",
+		"kind":       "XBucket",
+		"metadata":   map[string]interface{}{"name": "demo"},
+		"spec":       map[string]interface{}{"region": "eu-central-1"},
+	}}
+
+	if err := completeComposite(xr, xrd); err != nil {
+		t.Fatal(err)
+	}
+	if acl, _, _ := unstructured.NestedString(xr.Object, "spec", "acl"); acl != "private" {
+		t.Errorf("expected default of schema applied, got %q", acl)
+	}
+
+	unstructured.RemoveNestedField(xr.Object, "spec", "region")
+	if err := completeComposite(xr, xrd); err == nil || !strings.Contains(err.Error(), "spec.region") {
+		t.Errorf("expected missing required field error, got %v", err)
+	}
+
+	xr.SetKind("XDatabase")
+	if err := completeComposite(xr, xrd); err == nil {
+		t.Error("expected error for composite resource of other kind")
+	}
+}
+
+func TestNewRuntime(t *testing.T) {
+	fn := pkgv1beta1.Function{}
+	fn.Spec.Package = "acme/function-test:v1.0.0"
+	rt, err := NewRuntime(fn, []engine.Route{{Pattern: "acme/*", Target: "ghcr.io/acme"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docker, ok := rt.(*DockerRuntime); !ok || docker.Image != "ghcr.io/acme/function-test:v1.0.0" {
+		t.Errorf("expected Docker runtime of routed package, got %+v", rt)
+	}
+
+	rt, _ = NewRuntime(DevelopmentFunction("function-test", ""), nil)
+	if dev, ok := rt.(*DevelopmentRuntime); !ok || dev.Target != function.DefaultRunAddress {
+		t.Errorf("expected Development runtime at default address, got %+v", rt)
+	}
+}
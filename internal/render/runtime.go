@@ -0,0 +1,112 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	pkgv1beta1 "github.com/crossplane/crossplane/apis/pkg/v1beta1"
+	"go.uber.org/zap"
+
+	"github.com/web-seven/overlock/internal/engine"
+	"github.com/web-seven/overlock/internal/function"
+)
+
+const (
+	// Annotation of function selecting its render runtime
+	AnnotationRuntime = "render.crossplane.io/runtime"
+	// Annotation of function with address of Development runtime
+	AnnotationRuntimeTarget = "render.crossplane.io/runtime-development-target"
+
+	// Function is run from package image in Docker
+	RuntimeDocker = "Docker"
+	// Function is already running, e.g. with overlock function run
+	RuntimeDevelopment = "Development"
+
+	functionPort = "9443/tcp"
+)
+
+// Runtime provides address of running function
+type Runtime interface {
+	// Start function, returned function stops it
+	Start(ctx context.Context, logger *zap.SugaredLogger) (string, func(), error)
+}
+
+// NewRuntime of function by render runtime annotation, Docker by default.
+// Package of function run in Docker is expanded by package routes.
+func NewRuntime(fn pkgv1beta1.Function, routes []engine.Route) (Runtime, error) {
+	annotations := fn.GetAnnotations()
+	switch annotations[AnnotationRuntime] {
+	case RuntimeDevelopment:
+		target := annotations[AnnotationRuntimeTarget]
+		if target == "" {
+			target = function.DefaultRunAddress
+		}
+		return &DevelopmentRuntime{Target: target}, nil
+	case RuntimeDocker, "":
+		return &DockerRuntime{Image: engine.ExpandPackage(fn.Spec.Package, routes)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported runtime %q of function %s", annotations[AnnotationRuntime], fn.GetName())
+	}
+}
+
+// DevelopmentFunction returns function served on address by Development runtime
+func DevelopmentFunction(name string, address string) pkgv1beta1.Function {
+	fn := pkgv1beta1.Function{}
+	fn.SetName(name)
+	fn.SetAnnotations(map[string]string{
+		AnnotationRuntime:       RuntimeDevelopment,
+		AnnotationRuntimeTarget: address,
+	})
+	return fn
+}
+
+// DevelopmentRuntime of function started separately
+type DevelopmentRuntime struct {
+	Target string
+}
+
+func (r *DevelopmentRuntime) Start(ctx context.Context, logger *zap.SugaredLogger) (string, func(), error) {
+	return r.Target, func() {}, nil
+}
+
+// DockerRuntime of function started from package image, gRPC port is
+// published on random local port
+type DockerRuntime struct {
+	Image string
+}
+
+func (r *DockerRuntime) Start(ctx context.Context, logger *zap.SugaredLogger) (string, func(), error) {
+	if r.Image == "" {
+		return "", nil, fmt.Errorf("function package is not specified")
+	}
+	out, err := exec.CommandContext(ctx, "docker", "run", "--detach", "--rm", "--publish", "127.0.0.1::"+functionPort, r.Image, "--insecure").Output()
+	if err != nil {
+		return "", nil, fmt.Errorf("cannot run %s: %w", r.Image, commandError(err))
+	}
+	id := strings.TrimSpace(string(out))
+	stop := func() {
+		// Render context can be cancelled at this point
+		if err := exec.Command("docker", "rm", "--force", id).Run(); err != nil {
+			logger.Warnf("Cannot remove container %s: %v", id, err)
+		}
+	}
+	out, err = exec.CommandContext(ctx, "docker", "port", id, functionPort).Output()
+	if err != nil {
+		stop()
+		return "", nil, fmt.Errorf("cannot get port of %s: %w", r.Image, commandError(err))
+	}
+	// Port may be published for several addresses, one per line
+	address := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+	logger.Debugf("Function %s started in container %s at %s.", r.Image, id, address)
+	return address, stop, nil
+}
+
+// Error of command with its stderr output
+func commandError(err error) error {
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
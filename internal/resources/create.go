@@ -0,0 +1,347 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	crossv1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+
+	"github.com/web-seven/overlock/internal/engine"
+	"github.com/web-seven/overlock/internal/image"
+)
+
+var (
+	customResourceDefinitions    = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+	compositeResourceDefinitions = schema.GroupVersionResource{Group: "apiextensions.crossplane.io", Version: "v1", Resource: "compositeresourcedefinitions"}
+)
+
+// CreateOptions of composite resource built without form
+type CreateOptions struct {
+	// Name of resource, taken from example if empty
+	Name string
+	// Manifest file with resource used as base of created resource
+	Example string
+	// Values as path=value, converted to types of fields in schema
+	Values []string
+	// Values as path=file, content of file is used as value
+	Files []string
+	// Namespace of claim, taken from example or default namespace if empty
+	Namespace string
+}
+
+// XResourceCRD reads CRD of composite resource type, CRD is named as its XRD
+func XResourceCRD(ctx context.Context, dc dynamic.Interface, name string) (*extv1.CustomResourceDefinition, error) {
+	u, err := dc.Resource(customResourceDefinitions).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("cannot get resource type %s: %w", name, err)
+	}
+	crd := &extv1.CustomResourceDefinition{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, crd); err != nil {
+		return nil, err
+	}
+	return crd, nil
+}
+
+// ClaimCRD reads CRD of claims offered by XRD
+func ClaimCRD(ctx context.Context, dc dynamic.Interface, xrdName string) (*extv1.CustomResourceDefinition, error) {
+	u, err := dc.Resource(compositeResourceDefinitions).Get(ctx, xrdName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("cannot get resource type %s: %w", xrdName, err)
+	}
+	xrd := crossv1.CompositeResourceDefinition{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &xrd); err != nil {
+		return nil, err
+	}
+	if xrd.Spec.ClaimNames == nil {
+		return nil, fmt.Errorf("%s does not offer claims", xrdName)
+	}
+	return XResourceCRD(ctx, dc, xrd.Spec.ClaimNames.Plural+"."+xrd.Spec.Group)
+}
+
+// BuildXResource builds resource of CRD from example and values. Values are
+// converted to types of fields in schema, defaults of schema are applied and
+// required fields are validated.
+func BuildXResource(crd *extv1.CustomResourceDefinition, options CreateOptions) (*unstructured.Unstructured, error) {
+	xr := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	if options.Example != "" {
+		content, err := os.ReadFile(options.Example)
+		if err != nil {
+			return nil, err
+		}
+		objects, err := image.ParseObjects(content)
+		if err != nil || len(objects) == 0 {
+			return nil, fmt.Errorf("%s has no resource: %v", options.Example, err)
+		}
+		xr = &objects[0]
+		if xr.GetKind() != "" && xr.GetKind() != crd.Spec.Names.Kind {
+			return nil, fmt.Errorf("%s is %s, not %s", options.Example, xr.GetKind(), crd.Spec.Names.Kind)
+		}
+	}
+
+	version, err := crdVersion(crd, schema.FromAPIVersionAndKind(xr.GetAPIVersion(), xr.GetKind()).Version)
+	if err != nil {
+		return nil, err
+	}
+	xr.SetGroupVersionKind(schema.GroupVersionKind{Group: crd.Spec.Group, Version: version.Name, Kind: crd.Spec.Names.Kind})
+	if options.Name != "" {
+		xr.SetName(options.Name)
+	}
+	if crd.Spec.Scope == extv1.NamespaceScoped {
+		if options.Namespace != "" {
+			xr.SetNamespace(options.Namespace)
+		} else if xr.GetNamespace() == "" {
+			xr.SetNamespace("default")
+		}
+	} else {
+		xr.SetNamespace("")
+	}
+	labels := xr.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for k, v := range XResourceLabels() {
+		labels[k] = v
+	}
+	xr.SetLabels(labels)
+	// Resource in example can belong to other environment
+	xr.SetResourceVersion("")
+	xr.SetUID("")
+	delete(xr.Object, "status")
+
+	props := &extv1.JSONSchemaProps{}
+	if version.Schema != nil && version.Schema.OpenAPIV3Schema != nil {
+		props = version.Schema.OpenAPIV3Schema
+	}
+	values := append([]string{}, options.Values...)
+	files := map[string]bool{}
+	for _, value := range options.Files {
+		values = append(values, value)
+		files[value] = true
+	}
+	for _, value := range values {
+		path, raw, ok := strings.Cut(value, "=")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("%q is not in path=value format", value)
+		}
+		fields := strings.Split(path, ".")
+		field, ok := fieldSchema(props, fields)
+		if !ok {
+			return nil, fmt.Errorf("%s has no field %s", crd.Spec.Names.Kind, path)
+		}
+		if files[value] {
+			content, err := os.ReadFile(raw)
+			if err != nil {
+				return nil, err
+			}
+			raw = string(content)
+			switch field.Type {
+			case "object", "array":
+				data, err := yaml.YAMLToJSON(content)
+				if err != nil {
+					return nil, fmt.Errorf("%s: cannot parse %s: %w", path, raw, err)
+				}
+				raw = string(data)
+			default:
+				// Newline ending file is not part of value
+				raw = strings.TrimSuffix(strings.TrimSuffix(raw, "\n"), "\r")
+			}
+		}
+		converted, err := CoerceValue(raw, *field)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if err := unstructured.SetNestedField(xr.Object, converted, fields...); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	if err := CompleteXResource(xr, props); err != nil {
+		return nil, err
+	}
+	return xr, nil
+}
+
+// CompleteXResource applies defaults of schema to resource and validates
+// its name and required fields
+func CompleteXResource(xr *unstructured.Unstructured, props *extv1.JSONSchemaProps) error {
+	if xr.GetName() == "" {
+		return fmt.Errorf("name of %s is required, set it with --name", xr.GetKind())
+	}
+	// Metadata is validated by API server, status is set by Crossplane
+	fields := map[string]interface{}{}
+	for name, value := range xr.Object {
+		if name != "metadata" && name != "status" {
+			fields[name] = value
+		}
+	}
+	if err := applyDefaults(fields, props); err != nil {
+		return err
+	}
+	for name, value := range fields {
+		xr.Object[name] = value
+	}
+	if missing := missingFields(fields, props, ""); len(missing) > 0 {
+		return fmt.Errorf("required field(s) %s not set, set them with --set %s=<value>", strings.Join(missing, ", "), missing[0])
+	}
+	return nil
+}
+
+// XResourceLabels returns labels of resources created by overlock
+func XResourceLabels() map[string]string {
+	now := time.Now().UTC().Format("20060102T150405Z")
+	return engine.ManagedLabels(map[string]string{
+		"creation-date": now,
+		"update-date":   now,
+	})
+}
+
+// CoerceValue converts flag value to type of schema, values of fields
+// without type are parsed as JSON or taken as string
+func CoerceValue(value string, props extv1.JSONSchemaProps) (interface{}, error) {
+	var converted interface{}
+	var err error
+	switch props.Type {
+	case "integer":
+		converted, err = strconv.ParseInt(value, 10, 64)
+	case "number":
+		converted, err = strconv.ParseFloat(value, 64)
+	case "boolean":
+		converted, err = strconv.ParseBool(value)
+	case "string":
+		converted = value
+	case "":
+		if utiljson.Unmarshal([]byte(value), &converted) != nil {
+			converted = value
+		}
+	default:
+		err = utiljson.Unmarshal([]byte(value), &converted)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a valid %s", value, props.Type)
+	}
+	if len(props.Enum) > 0 {
+		enum := []string{}
+		for _, v := range props.Enum {
+			enum = append(enum, strings.Trim(string(v.Raw), `"`))
+		}
+		if !slices.Contains(enum, value) {
+			return nil, fmt.Errorf("supported values: %s", strings.Join(enum, ", "))
+		}
+	}
+	return converted, nil
+}
+
+// Version of CRD by name, storage version by default
+func crdVersion(crd *extv1.CustomResourceDefinition, name string) (*extv1.CustomResourceDefinitionVersion, error) {
+	for i, version := range crd.Spec.Versions {
+		if version.Served && (version.Name == name || name == "" && version.Storage) {
+			return &crd.Spec.Versions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%s has no served version %s", crd.GetName(), name)
+}
+
+// Schema of field by path, fields of metadata are strings
+func fieldSchema(props *extv1.JSONSchemaProps, path []string) (*extv1.JSONSchemaProps, bool) {
+	if len(path) > 1 && path[0] == "metadata" {
+		return &extv1.JSONSchemaProps{Type: "string"}, true
+	}
+	for _, name := range path {
+		if prop, ok := props.Properties[name]; ok {
+			props = &prop
+			continue
+		}
+		switch {
+		case props.AdditionalProperties != nil && props.AdditionalProperties.Schema != nil:
+			props = props.AdditionalProperties.Schema
+		case props.XPreserveUnknownFields != nil && *props.XPreserveUnknownFields:
+			return &extv1.JSONSchemaProps{}, true
+		default:
+			return nil, false
+		}
+	}
+	return props, true
+}
+
+// Set defaults of schema on absent fields of present objects, as API server does
+func applyDefaults(obj map[string]interface{}, props *extv1.JSONSchemaProps) error {
+	for name, prop := range props.Properties {
+		if _, ok := obj[name]; !ok && prop.Default != nil {
+			var value interface{}
+			if err := utiljson.Unmarshal(prop.Default.Raw, &value); err != nil {
+				return fmt.Errorf("invalid default of %s: %w", name, err)
+			}
+			obj[name] = value
+		}
+		switch value := obj[name].(type) {
+		case map[string]interface{}:
+			if err := applyDefaults(value, &prop); err != nil {
+				return err
+			}
+		case []interface{}:
+			if prop.Items == nil || prop.Items.Schema == nil {
+				continue
+			}
+			for _, item := range value {
+				if item, ok := item.(map[string]interface{}); ok {
+					if err := applyDefaults(item, prop.Items.Schema); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Paths of required fields absent in present objects, required fields of
+// absent required objects are reported instead of the objects
+func missingFields(obj map[string]interface{}, props *extv1.JSONSchemaProps, parent string) []string {
+	missing := []string{}
+	for _, name := range props.Required {
+		if _, ok := obj[name]; ok {
+			continue
+		}
+		prop := props.Properties[name]
+		if prop.Type == "object" && len(prop.Required) > 0 {
+			missing = append(missing, missingFields(map[string]interface{}{}, &prop, parent+name+".")...)
+		} else {
+			missing = append(missing, parent+name)
+		}
+	}
+	for name, value := range obj {
+		prop, ok := props.Properties[name]
+		if !ok {
+			continue
+		}
+		switch value := value.(type) {
+		case map[string]interface{}:
+			missing = append(missing, missingFields(value, &prop, parent+name+".")...)
+		case []interface{}:
+			if prop.Items == nil || prop.Items.Schema == nil {
+				continue
+			}
+			for i, item := range value {
+				if item, ok := item.(map[string]interface{}); ok {
+					missing = append(missing, missingFields(item, prop.Items.Schema, fmt.Sprintf("%s%s[%d].", parent, name, i))...)
+				}
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
@@ -0,0 +1,185 @@
+package resources
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestBuildXResource(t *testing.T) {
+	schemaJSON := `{
+	"type": "object",
+	"required": ["spec"],
+	"properties": {
+		"apiVersion": {"type": "string"},
+		"kind": {"type": "string"},
+		"metadata": {"type": "object"},
+		"spec": {
+			"type": "object",
+			"required": ["region", "size"],
+			"properties": {
+				"region": {"type": "string", "enum": ["eu-central-1", "us-east-1"]},
+				"size": {"type": "integer"},
+				"public": {"type": "boolean", "default": false},
+				"tags": {"type": "object", "additionalProperties": {"type": "string"}},
+				"rules": {"type": "array", "items": {"type": "object", "required": ["port"], "properties": {
+					"port": {"type": "integer"},
+					"protocol": {"type": "string", "default": "TCP"}
+				}}}
+			}
+		},
+		"status": {"type": "object"}
+	}
+}`
+	props := &extv1.JSONSchemaProps{}
+	if err := json.Unmarshal([]byte(schemaJSON), props); err != nil {
+		t.Fatal(err)
+	}
+	crd := &extv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "xbuckets.example.org"},
+		Spec: extv1.CustomResourceDefinitionSpec{
+			Group: "example.org",
+			Names: extv1.CustomResourceDefinitionNames{Kind: "XBucket", Plural: "xbuckets"},
+			Versions: []extv1.CustomResourceDefinitionVersion{
+				{Name: "v1alpha1", Served: true},
+				{Name: "v1", Served: true, Storage: true, Schema: &extv1.CustomResourceValidation{OpenAPIV3Schema: props}},
+			},
+		},
+	}
+
+	dir := t.TempDir()
+	example := filepath.Join(dir, "example.yaml")
+	rules := filepath.Join(dir, "rules.yaml")
+	region := filepath.Join(dir, "region")
+	files := map[string]string{
+		region:  "eu-central-1\n",
+		example: "apiVersion: example.org/v1\nkind: XBucket\nmetadata:\n  name: example\nspec:\n  region: us-east-1\nstatus:\n  ready: true\n",
+		rules:   "- port: 443\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	xr, err := BuildXResource(crd, CreateOptions{
+		Name:    "demo",
+		Example: example,
+		Values:  []string{"spec.size=3", "spec.tags.team=platform", "metadata.annotations.owner=platform"},
+		Files:   []string{"spec.rules=" + rules, "spec.region=" + region},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if xr.GetAPIVersion() != "example.org/v1" || xr.GetName() != "demo" || xr.GetLabels()["app.kubernetes.io/managed-by"] != "overlock" {
+		t.Errorf("unexpected resource %v", xr.Object)
+	}
+	spec, _, _ := unstructured.NestedMap(xr.Object, "spec")
+	if spec["region"] != "eu-central-1" || spec["size"] != int64(3) || spec["public"] != false {
+		t.Errorf("unexpected spec %v", spec)
+	}
+	if team, _, _ := unstructured.NestedString(spec, "tags", "team"); team != "platform" {
+		t.Errorf("unexpected tags %v", spec["tags"])
+	}
+	rule := spec["rules"].([]interface{})[0].(map[string]interface{})
+	if rule["port"] != int64(443) || rule["protocol"] != "TCP" {
+		t.Errorf("unexpected rules %v", spec["rules"])
+	}
+	if _, found := xr.Object["status"]; found {
+		t.Error("status of example is not dropped")
+	}
+
+	if xr.GetNamespace() != "" {
+		t.Errorf("composite resource has namespace %q", xr.GetNamespace())
+	}
+
+	// Claims are namespaced
+	claimCRD := crd.DeepCopy()
+	claimCRD.Spec.Scope = extv1.NamespaceScoped
+	for namespace, expected := range map[string]string{"": "default", "team-a": "team-a"} {
+		claim, err := BuildXResource(claimCRD, CreateOptions{Name: "demo", Example: example, Values: []string{"spec.size=1"}, Namespace: namespace})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if claim.GetNamespace() != expected {
+			t.Errorf("expected namespace %q, got %q", expected, claim.GetNamespace())
+		}
+	}
+
+	for _, c := range []struct {
+		options CreateOptions
+		err     string
+	}{
+		{CreateOptions{Name: "demo"}, "required field(s) spec.region, spec.size not set"},
+		{CreateOptions{Values: []string{"spec.region=eu-central-1", "spec.size=1"}}, "name of XBucket is required"},
+		{CreateOptions{Name: "demo", Values: []string{"spec.region=eu-west-1"}}, "spec.region: supported values: eu-central-1, us-east-1"},
+		{CreateOptions{Name: "demo", Values: []string{"spec.size=large"}}, `spec.size: "large" is not a valid integer`},
+		{CreateOptions{Name: "demo", Values: []string{"spec.color=red"}}, "XBucket has no field spec.color"},
+		{CreateOptions{Name: "demo", Values: []string{"spec.size=1", "spec.region=us-east-1", "spec.rules=[{}]"}}, "spec.rules[0].port"},
+	} {
+		if _, err := BuildXResource(crd, c.options); err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("expected error %q, got %v", c.err, err)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	name := "demo"
+	region := ""
+	size := json.Number("2")
+	tags := []string{"a"}
+	nested := map[string]interface{}{"region": &region}
+	xr := XResource{schema: &extv1.JSONSchemaProps{}}
+	xr.Object = map[string]interface{}{
+		"apiVersion": "example.org/v1",
+		"kind":       "XBucket",
+		"metadata":   &metav1.ObjectMeta{Name: name, Labels: XResourceLabels()},
+		"spec": &map[string]interface{}{
+			"size":     &size,
+			"tags":     &tags,
+			"location": &nested,
+		},
+	}
+	if err := xr.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+	// Resolved object must be valid JSON object of unstructured
+	_ = xr.DeepCopy()
+	if xr.GetName() != "demo" {
+		t.Errorf("unexpected name %q", xr.GetName())
+	}
+	spec, _, _ := unstructured.NestedMap(xr.Object, "spec")
+	if spec["size"] != int64(2) || len(spec["tags"].([]interface{})) != 1 {
+		t.Errorf("unexpected spec %v", spec)
+	}
+	if _, found := spec["location"]; found {
+		t.Errorf("empty object is not dropped %v", spec)
+	}
+}
+
+func TestGetSchemaFormName(t *testing.T) {
+	props := &extv1.JSONSchemaProps{Type: "object", Properties: map[string]extv1.JSONSchemaProps{
+		"spec": {Type: "object", Properties: map[string]extv1.JSONSchemaProps{"size": {Type: "integer"}}},
+	}}
+	xr := XResource{}
+	xr.GetSchemaForm(schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "XBucket"}, props, "Would you like to create resource?")
+	// Name is asked for even if schema has no metadata
+	metadata, ok := xr.Object["metadata"].(*metav1.ObjectMeta)
+	if !ok {
+		t.Fatalf("name field is not bound, got %v", xr.Object)
+	}
+	metadata.Name = "demo"
+	if err := xr.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+	if xr.GetName() != "demo" || xr.GetKind() != "XBucket" {
+		t.Errorf("unexpected resource %v", xr.Object)
+	}
+}
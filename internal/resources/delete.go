@@ -0,0 +1,28 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// DeleteResource deletes resource of kind, namespace is used only by
+// namespaced kinds. Composite resource bound to deleted claim is deleted by
+// Crossplane.
+func DeleteResource(ctx context.Context, dc dynamic.Interface, mapper meta.RESTMapper, gvk schema.GroupVersionKind, namespace string, name string) error {
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return err
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		namespace = ""
+	}
+	if err := dc.Resource(mapping.Resource).Namespace(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+		return fmt.Errorf("cannot delete %s %s: %w", gvk.Kind, name, err)
+	}
+	return nil
+}
@@ -2,58 +2,97 @@ package resources
 
 import (
 	"context"
+	"fmt"
 
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
 	"go.uber.org/zap"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 
 	"github.com/web-seven/overlock/internal/kube"
 )
 
 func GetXResources(ctx context.Context, dynamicClient *dynamic.DynamicClient, logger *zap.SugaredLogger) []unstructured.Unstructured {
-	paramsXRDs := kube.ResourceParams{
-		Dynamic:   dynamicClient,
-		Ctx:       ctx,
-		Group:     "apiextensions.crossplane.io",
-		Version:   "v1",
-		Resource:  "compositeresourcedefinitions",
-		Namespace: "",
-	}
-	XRDs, err := kube.GetKubeResources(paramsXRDs)
+	return getXRDResources(ctx, dynamicClient, false, "", logger)
+}
+
+// GetClaims lists claims in namespace, in all namespaces if namespace is
+// empty. Claims are listed whoever created them, as they are usually created
+// by applications of namespace, not by overlock.
+func GetClaims(ctx context.Context, dynamicClient dynamic.Interface, namespace string, logger *zap.SugaredLogger) []unstructured.Unstructured {
+	return getXRDResources(ctx, dynamicClient, true, namespace, logger)
+}
+
+func getXRDResources(ctx context.Context, dynamicClient dynamic.Interface, claims bool, namespace string, logger *zap.SugaredLogger) []unstructured.Unstructured {
+	XRDs, err := getXRDs(ctx, dynamicClient)
 	if err != nil {
 		logger.Error(err)
 	}
 	var XRs []unstructured.Unstructured
-
 	for _, xrd := range XRDs {
-		var paramsXRs v1.CompositeResourceDefinition
-		err := runtime.DefaultUnstructuredConverter.FromUnstructured(xrd.UnstructuredContent(), &paramsXRs)
+		if claims && xrd.Spec.ClaimNames == nil {
+			continue
+		}
+		_, xrList, err := listXRDResources(ctx, dynamicClient, xrd, claims, namespace)
 		if err != nil {
 			logger.Error(err)
 		}
-		for _, version := range paramsXRs.Spec.Versions {
-			xrList, err := kube.GetKubeResources(kube.ResourceParams{
-				Dynamic:   dynamicClient,
-				Ctx:       ctx,
-				Group:     paramsXRs.Spec.Group,
-				Version:   version.Name,
-				Resource:  paramsXRs.Spec.Names.Plural,
-				Namespace: "",
-				ListOption: metav1.ListOptions{
-					LabelSelector: "app.kubernetes.io/managed-by=overlock",
-				},
-			})
-
-			if err != nil {
-				logger.Error(err)
-			}
-
-			XRs = append(XRs, xrList...)
+		XRs = append(XRs, xrList...)
+	}
+	return XRs
+}
+
+func getXRDs(ctx context.Context, dynamicClient dynamic.Interface) ([]v1.CompositeResourceDefinition, error) {
+	list, err := kube.GetKubeResources(kube.ResourceParams{
+		Dynamic:  dynamicClient,
+		Ctx:      ctx,
+		Group:    compositeResourceDefinitions.Group,
+		Version:  compositeResourceDefinitions.Version,
+		Resource: compositeResourceDefinitions.Resource,
+	})
+	if err != nil {
+		return nil, err
+	}
+	XRDs := []v1.CompositeResourceDefinition{}
+	for _, item := range list {
+		xrd := v1.CompositeResourceDefinition{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.UnstructuredContent(), &xrd); err != nil {
+			return nil, fmt.Errorf("cannot convert %s: %w", item.GetName(), err)
 		}
+		XRDs = append(XRDs, xrd)
 	}
+	return XRDs, nil
+}
 
-	return XRs
+// Composite resources of XRD managed by overlock or all claims of XRD, read
+// in referenceable version to list each resource once
+func listXRDResources(ctx context.Context, dynamicClient dynamic.Interface, xrd v1.CompositeResourceDefinition, claims bool, namespace string) (schema.GroupVersionResource, []unstructured.Unstructured, error) {
+	resourceId := schema.GroupVersionResource{Group: xrd.Spec.Group, Resource: xrd.Spec.Names.Plural}
+	if claims {
+		resourceId.Resource = xrd.Spec.ClaimNames.Plural
+	} else {
+		namespace = ""
+	}
+	for _, version := range xrd.Spec.Versions {
+		if version.Referenceable || resourceId.Version == "" {
+			resourceId.Version = version.Name
+		}
+	}
+	options := metav1.ListOptions{}
+	if !claims {
+		options.LabelSelector = "app.kubernetes.io/managed-by=overlock"
+	}
+	list, err := kube.GetKubeResources(kube.ResourceParams{
+		Dynamic:    dynamicClient,
+		Ctx:        ctx,
+		Group:      resourceId.Group,
+		Version:    resourceId.Version,
+		Resource:   resourceId.Resource,
+		Namespace:  namespace,
+		ListOption: options,
+	})
+	return resourceId, list, err
 }
@@ -0,0 +1,227 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// Conditions shown for each node of resource tree
+var treeConditions = []string{"Synced", "Ready"}
+
+var (
+	conditionStyles = map[string]lipgloss.Style{
+		"True":    lipgloss.NewStyle().Foreground(lipgloss.Color("10")), // Green
+		"False":   lipgloss.NewStyle().Foreground(lipgloss.Color("9")),  // Red
+		"Unknown": lipgloss.NewStyle().Foreground(lipgloss.Color("11")), // Yellow
+	}
+	errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+)
+
+// TreeNode is resource with resources it references, composite resources
+// reference composed resources and claims reference their composite resource
+type TreeNode struct {
+	Kind      string
+	Name      string
+	Namespace string
+	// Resource is nil if it cannot be read
+	Resource *unstructured.Unstructured
+	Err      error
+	Children []*TreeNode
+}
+
+// ResolveResource finds kind of resource referenced as kind/name, kind can
+// be kind, singular or plural resource name, optionally with API group as
+// in xbuckets.example.org/name
+func ResolveResource(mapper meta.RESTMapper, ref string) (schema.GroupVersionKind, string, error) {
+	resource, name, ok := strings.Cut(ref, "/")
+	if !ok || resource == "" || name == "" {
+		return schema.GroupVersionKind{}, "", fmt.Errorf("resource %q is not in kind/name format", ref)
+	}
+	gr := schema.ParseGroupResource(strings.ToLower(resource))
+	gvr, err := mapper.ResourceFor(gr.WithVersion(""))
+	if err != nil {
+		return schema.GroupVersionKind{}, "", fmt.Errorf("cannot find resource %s: %w", resource, err)
+	}
+	gvk, err := mapper.KindFor(gvr)
+	if err != nil {
+		return schema.GroupVersionKind{}, "", err
+	}
+	return gvk, name, nil
+}
+
+// ResourceTree reads resource and walks references of resources recursively
+func ResourceTree(ctx context.Context, dc dynamic.Interface, mapper meta.RESTMapper, gvk schema.GroupVersionKind, namespace string, name string) *TreeNode {
+	return resourceTree(ctx, dc, mapper, gvk, namespace, name, map[string]bool{})
+}
+
+func resourceTree(ctx context.Context, dc dynamic.Interface, mapper meta.RESTMapper, gvk schema.GroupVersionKind, namespace string, name string, visited map[string]bool) *TreeNode {
+	node := &TreeNode{Kind: gvk.Kind, Name: name, Namespace: namespace}
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		node.Err = err
+		return node
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		node.Namespace = ""
+	}
+	node.Resource, node.Err = dc.Resource(mapping.Resource).Namespace(node.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if node.Err != nil {
+		return node
+	}
+	// References can form cycle only when resources are edited by hand
+	if visited[string(node.Resource.GetUID())] {
+		return node
+	}
+	visited[string(node.Resource.GetUID())] = true
+
+	refs, _, _ := unstructured.NestedSlice(node.Resource.Object, "spec", "resourceRefs")
+	if ref, found, _ := unstructured.NestedMap(node.Resource.Object, "spec", "resourceRef"); found {
+		refs = append(refs, ref)
+	}
+	for _, r := range refs {
+		ref, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		childGVK := schema.FromAPIVersionAndKind(fmt.Sprint(ref["apiVersion"]), fmt.Sprint(ref["kind"]))
+		// Composed resources are not named until they are created
+		childName, _ := ref["name"].(string)
+		if childName == "" {
+			node.Children = append(node.Children, &TreeNode{Kind: childGVK.Kind, Err: fmt.Errorf("not created yet")})
+			continue
+		}
+		// Namespaced resources composed by cluster scoped composite resource
+		// are referenced with their namespace
+		childNamespace, _ := ref["namespace"].(string)
+		if childNamespace == "" {
+			childNamespace = node.Namespace
+		}
+		node.Children = append(node.Children, resourceTree(ctx, dc, mapper, childGVK, childNamespace, childName, visited))
+	}
+	return node
+}
+
+type treeRow struct {
+	name   string
+	cells  []string
+	colors []lipgloss.Style
+}
+
+// PrintTree writes tree as table with conditions of each resource, colors
+// are dropped when output is not a terminal
+func PrintTree(w io.Writer, root *TreeNode) error {
+	rows := treeRows(root, "", "")
+	header := treeRow{name: "NAME"}
+	for _, t := range treeConditions {
+		header.cells = append(header.cells, strings.ToUpper(t))
+	}
+	header.cells = append(header.cells, "STATUS")
+	widths := make([]int, len(header.cells))
+	nameWidth := len(header.name)
+	for _, row := range append(rows, header) {
+		nameWidth = max(nameWidth, lipgloss.Width(row.name))
+		for i, cell := range row.cells[:len(row.cells)-1] {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+
+	for _, row := range append([]treeRow{header}, rows...) {
+		line := &strings.Builder{}
+		line.WriteString(row.name + strings.Repeat(" ", nameWidth-lipgloss.Width(row.name)+2))
+		for i, cell := range row.cells {
+			padding := ""
+			if i < len(row.cells)-1 {
+				padding = strings.Repeat(" ", widths[i]-len(cell)+2)
+			}
+			if i < len(row.colors) {
+				cell = row.colors[i].Render(cell)
+			}
+			line.WriteString(cell + padding)
+		}
+		if _, err := fmt.Fprintln(w, strings.TrimRight(line.String(), " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func treeRows(node *TreeNode, prefix string, childPrefix string) []treeRow {
+	row := treeRow{name: prefix + node.Kind + "/" + node.Name}
+	if node.Name == "" {
+		row.name = prefix + node.Kind
+	}
+	switch {
+	case kerrors.IsNotFound(node.Err):
+		row.cells = []string{"-", "-", "not found"}
+		row.colors = []lipgloss.Style{{}, {}, errorStyle}
+	case node.Err != nil:
+		row.cells = []string{"-", "-", node.Err.Error()}
+		row.colors = []lipgloss.Style{{}, {}, errorStyle}
+	default:
+		status := ""
+		for _, t := range treeConditions {
+			condition := findCondition(node.Resource, t)
+			row.cells = append(row.cells, condition.status)
+			row.colors = append(row.colors, conditionStyles[condition.status])
+			// Status of first condition which is not True explains state of resource
+			if condition.status != "True" && status == "" {
+				status = condition.String()
+			}
+		}
+		if status == "" {
+			status = findCondition(node.Resource, "Ready").reason
+		}
+		row.cells = append(row.cells, status)
+	}
+
+	rows := []treeRow{row}
+	for i, child := range node.Children {
+		if i == len(node.Children)-1 {
+			rows = append(rows, treeRows(child, childPrefix+"└─ ", childPrefix+"   ")...)
+		} else {
+			rows = append(rows, treeRows(child, childPrefix+"├─ ", childPrefix+"│  ")...)
+		}
+	}
+	return rows
+}
+
+type condition struct {
+	status  string
+	reason  string
+	message string
+}
+
+func (c condition) String() string {
+	if c.message == "" {
+		return c.reason
+	}
+	return c.reason + ": " + c.message
+}
+
+func findCondition(u *unstructured.Unstructured, conditionType string) condition {
+	conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+	for _, c := range conditions {
+		found, ok := c.(map[string]interface{})
+		if !ok || found["type"] != conditionType {
+			continue
+		}
+		result := condition{status: "Unknown"}
+		if status, ok := found["status"].(string); ok {
+			result.status = status
+		}
+		result.reason, _ = found["reason"].(string)
+		result.message, _ = found["message"].(string)
+		return result
+	}
+	return condition{status: "Unknown", reason: conditionType + " condition not reported yet"}
+}
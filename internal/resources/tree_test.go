@@ -0,0 +1,128 @@
+package resources
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestResourceTree(t *testing.T) {
+	object := func(apiVersion, kind, name string, refs []interface{}, conditions ...map[string]interface{}) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata":   map[string]interface{}{"name": name, "uid": name},
+			"spec":       map[string]interface{}{},
+		}}
+		if refs != nil {
+			_ = unstructured.SetNestedSlice(u.Object, refs, "spec", "resourceRefs")
+		}
+		list := []interface{}{}
+		for _, c := range conditions {
+			list = append(list, c)
+		}
+		_ = unstructured.SetNestedSlice(u.Object, list, "status", "conditions")
+		return u
+	}
+	ready := map[string]interface{}{"type": "Ready", "status": "True", "reason": "Available"}
+	synced := map[string]interface{}{"type": "Synced", "status": "True", "reason": "ReconcileSuccess"}
+	creating := map[string]interface{}{"type": "Ready", "status": "False", "reason": "Creating", "message": "waiting for VPC"}
+	ref := func(apiVersion, kind, name string) interface{} {
+		return map[string]interface{}{"apiVersion": apiVersion, "kind": kind, "name": name}
+	}
+
+	xr := object("example.org/v1", "XApp", "demo", []interface{}{
+		ref("example.org/v1", "XNetwork", "demo-net"),
+		ref("s3.aws.upbound.io/v1beta1", "Bucket", "demo-bucket"),
+		ref("s3.aws.upbound.io/v1beta1", "Bucket", "demo-gone"),
+	}, creating, synced)
+	network := object("example.org/v1", "XNetwork", "demo-net", []interface{}{
+		ref("ec2.aws.upbound.io/v1beta1", "VPC", "demo-vpc"),
+		ref("ec2.aws.upbound.io/v1beta1", "Subnet", ""),
+	}, ready, synced)
+	vpc := object("ec2.aws.upbound.io/v1beta1", "VPC", "demo-vpc", nil, ready, synced)
+	bucket := object("s3.aws.upbound.io/v1beta1", "Bucket", "demo-bucket", nil, synced)
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	lists := map[schema.GroupVersionResource]string{}
+	for _, gvk := range []schema.GroupVersionKind{
+		{Group: "example.org", Version: "v1", Kind: "XApp"},
+		{Group: "example.org", Version: "v1", Kind: "XNetwork"},
+		{Group: "s3.aws.upbound.io", Version: "v1beta1", Kind: "Bucket"},
+		{Group: "ec2.aws.upbound.io", Version: "v1beta1", Kind: "VPC"},
+		{Group: "ec2.aws.upbound.io", Version: "v1beta1", Kind: "Subnet"},
+	} {
+		mapper.Add(gvk, meta.RESTScopeRoot)
+		gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+		lists[gvr] = gvk.Kind + "List"
+	}
+	dc := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), lists, xr, network, vpc, bucket)
+
+	gvk, name, err := ResolveResource(mapper, "xapps.example.org/demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gvk.Kind != "XApp" || name != "demo" {
+		t.Fatalf("unexpected resource %s %s", gvk, name)
+	}
+	if _, _, err := ResolveResource(mapper, "XApp"); err == nil {
+		t.Error("expected error of resource without name")
+	}
+
+	root := ResourceTree(context.Background(), dc, mapper, gvk, "", name)
+	out := &bytes.Buffer{}
+	if err := PrintTree(out, root); err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+NAME                   SYNCED  READY    STATUS
+XApp/demo              True    False    Creating: waiting for VPC
+├─ XNetwork/demo-net   True    True     Available
+│  ├─ VPC/demo-vpc     True    True     Available
+│  └─ Subnet           -       -        not created yet
+├─ Bucket/demo-bucket  True    Unknown  Ready condition not reported yet
+└─ Bucket/demo-gone    -       -        not found
+`
+	if strings.TrimSpace(out.String()) != strings.TrimSpace(expected) {
+		t.Errorf("unexpected tree\n%s", out)
+	}
+}
+
+func TestResourceTreeNamespacedChild(t *testing.T) {
+	xr := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.org/v1",
+		"kind":       "XApp",
+		"metadata":   map[string]interface{}{"name": "demo", "uid": "demo"},
+		"spec": map[string]interface{}{"resourceRefs": []interface{}{
+			map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap", "name": "demo-config", "namespace": "apps"},
+		}},
+	}}
+	config := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "demo-config", "namespace": "apps", "uid": "demo-config"},
+	}}
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "XApp"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	dc := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		{Group: "example.org", Version: "v1", Resource: "xapps"}: "XAppList",
+		{Version: "v1", Resource: "configmaps"}:                  "ConfigMapList",
+	}, xr, config)
+
+	root := ResourceTree(context.Background(), dc, mapper, schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "XApp"}, "", "demo")
+	if root.Err != nil || len(root.Children) != 1 {
+		t.Fatalf("unexpected tree %+v", root)
+	}
+	if child := root.Children[0]; child.Err != nil || child.Namespace != "apps" {
+		t.Errorf("expected child read from its namespace, got %+v", child)
+	}
+}
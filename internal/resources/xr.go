@@ -4,19 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"regexp"
 	"strings"
-	"time"
 
 	"github.com/charmbracelet/huh"
 	"go.uber.org/zap"
 
 	"github.com/web-seven/overlock/internal/engine"
-	"github.com/web-seven/overlock/internal/kube"
 
 	crossv1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
 	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -27,8 +27,12 @@ import (
 type XResource struct {
 	Resource string
 	unstructured.Unstructured
+	schema *extv1.JSONSchemaProps
 }
 
+// Annotation of composed resources with name of resource in composition
+const AnnotationCompositionResourceName = "crossplane.io/composition-resource-name"
+
 var apiFields = []string{"apiVersion", "kind"}
 var metadataFields = []string{"metadata"}
 
@@ -46,8 +50,6 @@ func (xr *XResource) GetSchemaFormFromXRDefinition(ctx context.Context, xrd cros
 
 	runtime.DefaultUnstructuredConverter.FromUnstructured(xrdInstance.UnstructuredContent(), &xrd)
 
-	formGroups := []*huh.Group{}
-
 	selectedVersion := v1.CompositeResourceDefinitionVersion{}
 	if len(xrd.Spec.Versions) == 1 {
 		selectedVersion = xrd.Spec.Versions[0]
@@ -74,28 +76,33 @@ func (xr *XResource) GetSchemaFormFromXRDefinition(ctx context.Context, xrd cros
 	logger.Info("Type: \t\t" + xrd.Name)
 	logger.Info("Description: \t" + versionSchema.Description)
 
-	versionGroups := xr.getFormGroupsByProps(versionSchema, "")
-	formGroups = append(formGroups, versionGroups...)
-
-	xr.Unstructured.SetGroupVersionKind(schema.GroupVersionKind{
+	xr.Resource = xrd.Spec.Names.Plural
+	return xr.GetSchemaForm(schema.GroupVersionKind{
 		Group:   xrd.Spec.Group,
 		Version: selectedVersion.Name,
-	})
+		Kind:    xrd.Spec.Names.Kind,
+	}, versionSchema, "Would you like to create resource?")
+}
 
-	xr.Unstructured.SetKind(xrd.Spec.Names.Kind)
-	xr.Resource = xrd.Spec.Names.Plural
+// GetSchemaForm returns form asking for fields of resource schema, answers
+// are bound to resource and read with Resolve
+func (xr *XResource) GetSchemaForm(gvk schema.GroupVersionKind, props *extv1.JSONSchemaProps, confirm string) *huh.Form {
+	xr.schema = props
+	formGroups := xr.getFormGroupsByProps(props, "")
+	if _, ok := props.Properties["metadata"]; !ok {
+		// Schemas of XRDs usually leave metadata out, name is asked anyway
+		formGroups = append([]*huh.Group{huh.NewGroup(xr.nameField())}, formGroups...)
+	}
+	xr.Unstructured.SetGroupVersionKind(gvk)
 
 	formGroups = append(formGroups,
 		huh.NewGroup(
 			huh.NewConfirm().
 				Key("confirm").
-				Title("Would you like to create resource?"),
+				Title(confirm),
 		),
 	)
-
-	schemaForm := huh.NewForm(formGroups...)
-
-	return schemaForm
+	return huh.NewForm(formGroups...)
 }
 
 func (xr *XResource) getFormGroupsByProps(schema *extv1.JSONSchemaProps, parent string) []*huh.Group {
@@ -189,7 +196,7 @@ func (xr *XResource) getFormGroupsByProps(schema *extv1.JSONSchemaProps, parent
 					Value(&propertyValue),
 				)
 			}
-		} else if property.Type == "number" && !isStringInArray(apiFields, propertyName) {
+		} else if (property.Type == "number" || property.Type == "integer") && !isStringInArray(apiFields, propertyName) {
 			propertyValue := json.Number("")
 			(xr.Unstructured.Object)[propertyName] = &propertyValue
 			formFields = append(formFields, huh.NewInput().
@@ -215,26 +222,7 @@ func (xr *XResource) getFormGroupsByProps(schema *extv1.JSONSchemaProps, parent
 				Value(&propertyValue),
 			)
 		} else if property.Type == "object" && isStringInArray(metadataFields, propertyName) {
-			propertyValue := metav1.ObjectMeta{
-				Name: "",
-				Labels: map[string]string{
-					"app.kubernetes.io/managed-by": "overlock",
-					"creation-date":                time.Now().String(),
-					"update-date":                  time.Now().String(),
-				},
-			}
-			(xr.Unstructured.Object)[propertyName] = &propertyValue
-
-			formFields = append(formFields, huh.NewInput().
-				Validate(func(s string) error {
-					if s == "" {
-						return errors.New("name is required")
-					}
-					return nil
-				}).
-				Title("Name of resource").
-				Value(&propertyValue.Name),
-			)
+			formFields = append(formFields, xr.nameField())
 		}
 	}
 	if len(formFields) > 0 {
@@ -247,6 +235,96 @@ func (xr *XResource) getFormGroupsByProps(schema *extv1.JSONSchemaProps, parent
 	return formGroups
 }
 
+// Input of resource name bound to its metadata
+func (xr *XResource) nameField() huh.Field {
+	if xr.Unstructured.Object == nil {
+		xr.Unstructured.Object = make(map[string]interface{})
+	}
+	metadata := metav1.ObjectMeta{
+		Name:   "",
+		Labels: XResourceLabels(),
+	}
+	xr.Unstructured.Object["metadata"] = &metadata
+
+	return huh.NewInput().
+		Validate(func(s string) error {
+			if s == "" {
+				return errors.New("name is required")
+			}
+			return nil
+		}).
+		Title("Name of resource").
+		Value(&metadata.Name)
+}
+
+// Resolve replaces values bound to form fields with their values, fields
+// left empty are dropped. Defaults of schema are applied and required fields
+// are validated.
+func (xr *XResource) Resolve() error {
+	xr.ResolveValues()
+	if xr.schema == nil {
+		xr.schema = &extv1.JSONSchemaProps{}
+	}
+	return CompleteXResource(&xr.Unstructured, xr.schema)
+}
+
+// ResolveValues replaces values bound to form fields with their values,
+// fields left empty are dropped
+func (xr *XResource) ResolveValues() {
+	object, _ := formValue(xr.Unstructured.Object)
+	xr.Unstructured.Object, _ = object.(map[string]interface{})
+	if xr.Unstructured.Object == nil {
+		xr.Unstructured.Object = map[string]interface{}{}
+	}
+}
+
+// Value bound to form field and whether it is set
+func formValue(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case *map[string]interface{}:
+		return formValue(*v)
+	case map[string]interface{}:
+		object := map[string]interface{}{}
+		for name, field := range v {
+			if resolved, ok := formValue(field); ok {
+				object[name] = resolved
+			}
+		}
+		return object, len(object) > 0
+	case *[]map[string]interface{}:
+		items := []interface{}{}
+		for _, item := range *v {
+			if resolved, ok := formValue(item); ok {
+				items = append(items, resolved)
+			}
+		}
+		return items, len(items) > 0
+	case *[]string:
+		items := []interface{}{}
+		for _, item := range *v {
+			items = append(items, item)
+		}
+		return items, len(items) > 0
+	case *string:
+		return *v, *v != ""
+	case *json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, true
+		}
+		f, err := v.Float64()
+		return f, err == nil
+	case *bool:
+		return *v, true
+	case *metav1.ObjectMeta:
+		labels := map[string]interface{}{}
+		for k, label := range v.Labels {
+			labels[k] = label
+		}
+		return map[string]interface{}{"name": v.Name, "labels": labels}, true
+	}
+	return value, value != nil
+}
+
 func parseSchema(v *v1.CompositeResourceValidation, logger *zap.SugaredLogger) *extv1.JSONSchemaProps {
 	if v == nil {
 		return nil
@@ -259,6 +337,25 @@ func parseSchema(v *v1.CompositeResourceValidation, logger *zap.SugaredLogger) *
 	return s
 }
 
+// XRDSchema returns schema of composite resource version of XRD, the
+// referenceable version if version is empty
+func XRDSchema(xrd crossv1.CompositeResourceDefinition, version string) (*extv1.JSONSchemaProps, error) {
+	for _, v := range xrd.Spec.Versions {
+		if v.Name != version && (version != "" || !v.Referenceable) {
+			continue
+		}
+		props := &extv1.JSONSchemaProps{}
+		if v.Schema == nil || v.Schema.OpenAPIV3Schema.Raw == nil {
+			return props, nil
+		}
+		if err := json.Unmarshal(v.Schema.OpenAPIV3Schema.Raw, props); err != nil {
+			return nil, fmt.Errorf("cannot parse schema of %s version %s: %w", xrd.GetName(), v.Name, err)
+		}
+		return props, nil
+	}
+	return nil, fmt.Errorf("%s has no version %s", xrd.GetName(), version)
+}
+
 func isStringInArray(a []string, s string) bool {
 	for _, e := range a {
 		if s == e {
@@ -268,7 +365,7 @@ func isStringInArray(a []string, s string) bool {
 	return false
 }
 
-func ApplyResources(ctx context.Context, client *dynamic.DynamicClient, logger *zap.SugaredLogger, file string) error {
+func ApplyResources(ctx context.Context, client dynamic.Interface, logger *zap.SugaredLogger, file string) error {
 	resources, err := transformToUnstructured(file)
 
 	if err != nil {
@@ -294,69 +391,79 @@ func ApplyResources(ctx context.Context, client *dynamic.DynamicClient, logger *
 	return nil
 }
 
-func CopyComposites(ctx context.Context, logger *zap.SugaredLogger, sourceContext dynamic.Interface, destinationContext dynamic.Interface) error {
-	//Get composite resources from XRDs definition and apply them
-	XRDs, err := kube.GetKubeResources(kube.ResourceParams{
-		Dynamic:    sourceContext,
-		Ctx:        ctx,
-		Group:      "apiextensions.crossplane.io",
-		Version:    "v1",
-		Resource:   "compositeresourcedefinitions",
-		Namespace:  "",
-		ListOption: metav1.ListOptions{},
-	})
+// CopyComposites copies composite resources and claims managed by overlock
+// to destination. Claims keep reference to their composite resource, so they
+// are bound to the copied composite resource instead of creating new one.
+// Only claims of claimNamespace and composite resources not claimed from
+// other namespaces are copied, unless claimNamespace is empty.
+func CopyComposites(ctx context.Context, logger *zap.SugaredLogger, sourceContext dynamic.Interface, destinationContext dynamic.Interface, claimNamespace string) error {
+	XRDs, err := getXRDs(ctx, sourceContext)
 	if err != nil {
 		return err
 	}
+	if len(XRDs) == 0 {
+		logger.Warn("Composite resources not found")
+		return nil
+	}
 
-	if len(XRDs) > 0 {
+	// Composite resources are copied before claims bound to them
+	for _, claims := range []bool{false, true} {
 		for _, xrd := range XRDs {
-			var paramsXRs v1.CompositeResourceDefinition
-			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(xrd.UnstructuredContent(), &paramsXRs); err != nil {
-				logger.Infof("Failed to convert item %s: %v\n", xrd.GetName(), err)
-				return nil
+			if claims && xrd.Spec.ClaimNames == nil {
+				continue
 			}
-			for _, version := range paramsXRs.Spec.Versions {
-				XRs, err := kube.GetKubeResources(kube.ResourceParams{
-					Dynamic:   sourceContext,
-					Ctx:       ctx,
-					Group:     paramsXRs.Spec.Group,
-					Version:   version.Name,
-					Resource:  paramsXRs.Spec.Names.Plural,
-					Namespace: "",
-					ListOption: metav1.ListOptions{
-						LabelSelector: "app.kubernetes.io/managed-by=overlock",
-					},
-				})
-				if err != nil {
-					return err
-				}
-
-				for _, xr := range XRs {
-					xr.SetResourceVersion("")
-					xr.SetFinalizers(nil)
-					resourceId := schema.GroupVersionResource{
-						Group:    paramsXRs.Spec.Group,
-						Version:  version.Name,
-						Resource: paramsXRs.Spec.Names.Plural,
-					}
-
-					_, err = destinationContext.Resource(resourceId).Namespace("").Get(ctx, xr.GetName(), metav1.GetOptions{})
-					if err != nil {
-						_, err = destinationContext.Resource(resourceId).Namespace("").Create(ctx, &xr, metav1.CreateOptions{})
-						if err != nil {
-							logger.Warn(err)
-						} else {
-							logger.Infof("Resource created successfully %s", xr.GetName())
-						}
-					} else {
-						logger.Warnf("Resource %s with type %s already exists, skipping.", xr.GetName(), resourceId.GroupResource().String())
+			resourceId, XRs, err := listXRDResources(ctx, sourceContext, xrd, claims, claimNamespace)
+			if err != nil {
+				return err
+			}
+			for _, xr := range XRs {
+				if claims {
+					if err := ensureNamespace(ctx, destinationContext, xr.GetNamespace()); err != nil {
+						logger.Warn(err)
+						continue
 					}
+				} else if namespace, found, _ := unstructured.NestedString(xr.Object, "spec", "claimRef", "namespace"); found && claimNamespace != "" && namespace != claimNamespace {
+					continue
 				}
+				copyResource(ctx, logger, destinationContext, resourceId, xr)
 			}
 		}
+	}
+	return nil
+}
+
+func copyResource(ctx context.Context, logger *zap.SugaredLogger, destinationContext dynamic.Interface, resourceId schema.GroupVersionResource, xr unstructured.Unstructured) {
+	xr.SetResourceVersion("")
+	xr.SetUID("")
+	xr.SetFinalizers(nil)
+	client := destinationContext.Resource(resourceId).Namespace(xr.GetNamespace())
+	if _, err := client.Get(ctx, xr.GetName(), metav1.GetOptions{}); err == nil {
+		logger.Warnf("Resource %s with type %s already exists, skipping.", xr.GetName(), resourceId.GroupResource().String())
+		return
+	}
+	if _, err := client.Create(ctx, &xr, metav1.CreateOptions{}); err != nil {
+		logger.Warn(err)
+		return
+	}
+	if composite, found, _ := unstructured.NestedString(xr.Object, "spec", "resourceRef", "name"); found {
+		logger.Infof("Claim %s/%s created successfully, bound to %s", xr.GetNamespace(), xr.GetName(), composite)
 	} else {
-		logger.Warn("Composite resources not found")
+		logger.Infof("Resource created successfully %s", xr.GetName())
+	}
+}
+
+// Create namespace of claims if it does not exist
+func ensureNamespace(ctx context.Context, dc dynamic.Interface, name string) error {
+	namespaces := dc.Resource(schema.GroupVersionResource{Version: "v1", Resource: "namespaces"})
+	if _, err := namespaces.Get(ctx, name, metav1.GetOptions{}); err == nil {
+		return nil
+	}
+	namespace := &unstructured.Unstructured{}
+	namespace.SetAPIVersion("v1")
+	namespace.SetKind("Namespace")
+	namespace.SetName(name)
+	if _, err := namespaces.Create(ctx, namespace, metav1.CreateOptions{}); err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("cannot create namespace %s: %w", name, err)
 	}
 	return nil
 }
@@ -0,0 +1,104 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestCopyComposites(t *testing.T) {
+	xrd := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.crossplane.io/v1",
+		"kind":       "CompositeResourceDefinition",
+		"metadata":   map[string]interface{}{"name": "xbuckets.example.org"},
+		"spec": map[string]interface{}{
+			"group":      "example.org",
+			"names":      map[string]interface{}{"kind": "XBucket", "plural": "xbuckets"},
+			"claimNames": map[string]interface{}{"kind": "Bucket", "plural": "buckets"},
+			"versions": []interface{}{
+				map[string]interface{}{"name": "v1alpha1", "served": true, "referenceable": false},
+				map[string]interface{}{"name": "v1", "served": true, "referenceable": true},
+			},
+		},
+	}}
+	managed := map[string]interface{}{"app.kubernetes.io/managed-by": "overlock"}
+	xr := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.org/v1",
+		"kind":       "XBucket",
+		"metadata":   map[string]interface{}{"name": "demo-x7k2p", "labels": managed, "uid": "1", "resourceVersion": "5"},
+		"spec":       map[string]interface{}{"claimRef": map[string]interface{}{"name": "demo", "namespace": "team-a"}},
+	}}
+	claim := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.org/v1",
+		"kind":       "Bucket",
+		"metadata":   map[string]interface{}{"name": "demo", "namespace": "team-a", "labels": managed},
+		"spec":       map[string]interface{}{"resourceRef": map[string]interface{}{"name": "demo-x7k2p"}},
+	}}
+	other := claim.DeepCopy()
+	other.SetName("other")
+	other.SetNamespace("team-b")
+	// Claims are listed and copied whoever created them
+	other.SetLabels(nil)
+
+	lists := map[schema.GroupVersionResource]string{
+		compositeResourceDefinitions:                                "CompositeResourceDefinitionList",
+		{Group: "example.org", Version: "v1", Resource: "xbuckets"}: "XBucketList",
+		{Group: "example.org", Version: "v1", Resource: "buckets"}:  "BucketList",
+		{Version: "v1", Resource: "namespaces"}:                     "NamespaceList",
+	}
+	source := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), lists, xrd, xr, claim, other)
+	destination := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), lists, xrd)
+	ctx := context.Background()
+	logger := zap.NewNop().Sugar()
+
+	if claims := GetClaims(ctx, source, "team-a", logger); len(claims) != 1 || claims[0].GetName() != "demo" {
+		t.Errorf("unexpected claims of team-a %v", claims)
+	}
+	if claims := GetClaims(ctx, source, "", logger); len(claims) != 2 {
+		t.Errorf("unexpected claims of all namespaces %v", claims)
+	}
+
+	if err := CopyComposites(ctx, logger, source, destination, ""); err != nil {
+		t.Fatal(err)
+	}
+	copied, err := destination.Resource(schema.GroupVersionResource{Group: "example.org", Version: "v1", Resource: "buckets"}).Namespace("team-a").Get(ctx, "demo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref, _, _ := unstructured.NestedString(copied.Object, "spec", "resourceRef", "name"); ref != "demo-x7k2p" {
+		t.Errorf("claim is not bound to copied composite resource %v", copied.Object)
+	}
+	composite, err := destination.Resource(schema.GroupVersionResource{Group: "example.org", Version: "v1", Resource: "xbuckets"}).Get(ctx, "demo-x7k2p", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if composite.GetUID() != "" || composite.GetResourceVersion() == "5" {
+		t.Errorf("identity of source resource is copied %v", composite.Object)
+	}
+	for _, ns := range []string{"team-a", "team-b"} {
+		if _, err := destination.Resource(schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}).Get(ctx, ns, metav1.GetOptions{}); err != nil {
+			t.Errorf("namespace %s of claim is not created: %v", ns, err)
+		}
+	}
+
+	// Composite resources claimed from other namespaces are not copied
+	destination = fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), lists, xrd)
+	if err := CopyComposites(ctx, logger, source, destination, "team-b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := destination.Resource(schema.GroupVersionResource{Group: "example.org", Version: "v1", Resource: "buckets"}).Namespace("team-b").Get(ctx, "other", metav1.GetOptions{}); err != nil {
+		t.Errorf("claim of team-b is not copied: %v", err)
+	}
+	if _, err := destination.Resource(schema.GroupVersionResource{Group: "example.org", Version: "v1", Resource: "buckets"}).Namespace("team-a").Get(ctx, "demo", metav1.GetOptions{}); err == nil {
+		t.Error("claim of team-a is copied")
+	}
+	if _, err := destination.Resource(schema.GroupVersionResource{Group: "example.org", Version: "v1", Resource: "xbuckets"}).Get(ctx, "demo-x7k2p", metav1.GetOptions{}); err == nil {
+		t.Error("composite resource claimed from team-a is copied")
+	}
+}
@@ -0,0 +1,303 @@
+package runtimeconfig
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+
+	"github.com/web-seven/overlock/internal/packages"
+)
+
+const (
+	apiVersion = "pkg.crossplane.io/v1beta1"
+	kind       = "DeploymentRuntimeConfig"
+
+	// Name of package runtime container in Deployment created by Crossplane
+	RuntimeContainer = "package-runtime"
+	// Runtime config Crossplane uses for packages without reference
+	DefaultName = "default"
+)
+
+// Options of runtime config generated from shorthands
+type Options struct {
+	Name string
+	// Pass --debug to package runtime
+	Debug bool
+	// Additional arguments of package runtime
+	Args []string
+	// Environment variables in K=V format
+	Env    []string
+	CPU    string
+	Memory string
+	// Replicas of package runtime Deployment, zero stops in-cluster runtime
+	Replicas *int64
+	// Deployment template options are overlaid on, usually of default runtime config
+	Template map[string]interface{}
+}
+
+// Manifest generates DeploymentRuntimeConfig configuring package runtime
+// container, options are overlaid on the deployment template
+func (o *Options) Manifest() (*unstructured.Unstructured, error) {
+	template := runtime.DeepCopyJSON(o.Template)
+	if template == nil {
+		template = map[string]interface{}{}
+	}
+	rc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": o.Name},
+		"spec": map[string]interface{}{
+			"deploymentTemplate": template,
+		},
+	}}
+	deployment := []string{"spec", "deploymentTemplate", "spec"}
+	if _, found, _ := unstructured.NestedFieldNoCopy(rc.Object, append(deployment, "selector")...); !found {
+		if err := unstructured.SetNestedMap(rc.Object, map[string]interface{}{}, append(deployment, "selector")...); err != nil {
+			return nil, err
+		}
+	}
+
+	containersPath := append(deployment, "template", "spec", "containers")
+	containers, _, err := unstructured.NestedSlice(rc.Object, containersPath...)
+	if err != nil {
+		return nil, err
+	}
+	index := slices.IndexFunc(containers, func(c interface{}) bool {
+		container, _ := c.(map[string]interface{})
+		return container["name"] == RuntimeContainer
+	})
+	if index < 0 {
+		containers = append(containers, map[string]interface{}{"name": RuntimeContainer})
+		index = len(containers) - 1
+	}
+	container, _ := containers[index].(map[string]interface{})
+	if err := o.overlay(container); err != nil {
+		return nil, err
+	}
+	containers[index] = container
+	if err := unstructured.SetNestedSlice(rc.Object, containers, containersPath...); err != nil {
+		return nil, err
+	}
+
+	if o.Replicas != nil {
+		if err := unstructured.SetNestedField(rc.Object, *o.Replicas, append(deployment, "replicas")...); err != nil {
+			return nil, err
+		}
+	}
+	return rc, nil
+}
+
+// Overlay options on runtime container, arguments are appended and
+// environment variables and limits replace the ones of the same name
+func (o *Options) overlay(container map[string]interface{}) error {
+	args, _, _ := unstructured.NestedSlice(container, "args")
+	if o.Debug {
+		args = append(args, "--debug")
+	}
+	for _, arg := range o.Args {
+		args = append(args, arg)
+	}
+	if len(args) > 0 {
+		container["args"] = args
+	}
+
+	env, _, _ := unstructured.NestedSlice(container, "env")
+	for _, e := range o.Env {
+		name, value, found := strings.Cut(e, "=")
+		if !found || name == "" {
+			return fmt.Errorf("invalid environment variable %q, expected K=V", e)
+		}
+		env = slices.DeleteFunc(env, func(v interface{}) bool {
+			existing, _ := v.(map[string]interface{})
+			return existing["name"] == name
+		})
+		env = append(env, map[string]interface{}{"name": name, "value": value})
+	}
+	if len(env) > 0 {
+		container["env"] = env
+	}
+
+	limits, _, _ := unstructured.NestedMap(container, "resources", "limits")
+	if limits == nil {
+		limits = map[string]interface{}{}
+	}
+	if o.CPU != "" {
+		limits["cpu"] = o.CPU
+	}
+	if o.Memory != "" {
+		limits["memory"] = o.Memory
+	}
+	if len(limits) > 0 {
+		return unstructured.SetNestedMap(container, limits, "resources", "limits")
+	}
+	return nil
+}
+
+// DefaultTemplate returns deployment template of default runtime config,
+// which carries scheduling of engine scope, nil if it does not exist
+func DefaultTemplate(ctx context.Context, dc dynamic.Interface) (map[string]interface{}, error) {
+	rc, err := dc.Resource(packages.RuntimeConfigs).Get(ctx, DefaultName, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	template, _, err := unstructured.NestedMap(rc.Object, "spec", "deploymentTemplate")
+	return template, err
+}
+
+// Load runtime config manifest from file
+func Load(path string) (*unstructured.Unstructured, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal(content, &u.Object); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+	if u.GetKind() != kind {
+		return nil, fmt.Errorf("%s contains %s, expected %s", path, u.GetKind(), kind)
+	}
+	return u, nil
+}
+
+// Apply creates runtime config or updates existing one
+func Apply(ctx context.Context, dc dynamic.Interface, rc *unstructured.Unstructured, logger *zap.SugaredLogger) error {
+	existing, err := dc.Resource(packages.RuntimeConfigs).Get(ctx, rc.GetName(), metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		if _, err := dc.Resource(packages.RuntimeConfigs).Create(ctx, rc, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+		logger.Infof("Runtime config %s created.", rc.GetName())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	rc.SetResourceVersion(existing.GetResourceVersion())
+	if _, err := dc.Resource(packages.RuntimeConfigs).Update(ctx, rc, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	logger.Infof("Runtime config %s updated.", rc.GetName())
+	return nil
+}
+
+// List runtime configs
+func List(ctx context.Context, dc dynamic.Interface) ([]unstructured.Unstructured, error) {
+	list, err := dc.Resource(packages.RuntimeConfigs).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// Users returns providers and functions referencing runtime config, as kind/name
+func Users(ctx context.Context, dc dynamic.Interface, name string) ([]string, error) {
+	users := []string{}
+	for _, pkg := range []struct {
+		kind string
+		res  dynamic.NamespaceableResourceInterface
+	}{
+		{"Provider", dc.Resource(packages.Providers)},
+		{"Function", dc.Resource(packages.Functions)},
+	} {
+		list, err := pkg.res.List(ctx, metav1.ListOptions{})
+		if kerrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range list.Items {
+			if ref, _, _ := unstructured.NestedString(u.Object, "spec", "runtimeConfigRef", "name"); ref == name {
+				users = append(users, pkg.kind+"/"+u.GetName())
+			}
+		}
+	}
+	sort.Strings(users)
+	return users, nil
+}
+
+// Delete runtime config which is not referenced by any package
+func Delete(ctx context.Context, dc dynamic.Interface, name string, logger *zap.SugaredLogger) error {
+	users, err := Users(ctx, dc, name)
+	if err != nil {
+		return err
+	}
+	if len(users) > 0 {
+		return fmt.Errorf("runtime config %s is used by %s", name, strings.Join(users, ", "))
+	}
+	if err := dc.Resource(packages.RuntimeConfigs).Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+		return err
+	}
+	logger.Infof("Runtime config %s deleted.", name)
+	return nil
+}
+
+// Prune deletes runtime config left unused by deleted package, given as
+// kind/name. Default runtime config and configs still used by other packages
+// are kept.
+func Prune(ctx context.Context, dc dynamic.Interface, name string, deleted string, logger *zap.SugaredLogger) error {
+	if name == "" || name == DefaultName {
+		return nil
+	}
+	users, err := Users(ctx, dc, name)
+	if err != nil {
+		return err
+	}
+	users = slices.DeleteFunc(users, func(user string) bool { return user == deleted })
+	if len(users) > 0 {
+		logger.Infof("Runtime config %s is kept, used by %s.", name, strings.Join(users, ", "))
+		return nil
+	}
+	err = dc.Resource(packages.RuntimeConfigs).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return err
+	}
+	logger.Infof("Runtime config %s deleted.", name)
+	return nil
+}
+
+// Summary of runtime container arguments, environment and limits
+func Summary(rc *unstructured.Unstructured) (args string, env string, limits string) {
+	containers, _, _ := unstructured.NestedSlice(rc.Object, "spec", "deploymentTemplate", "spec", "template", "spec", "containers")
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok || container["name"] != RuntimeContainer {
+			continue
+		}
+		a, _, _ := unstructured.NestedStringSlice(container, "args")
+		args = strings.Join(a, " ")
+
+		vars, _, _ := unstructured.NestedSlice(container, "env")
+		names := []string{}
+		for _, v := range vars {
+			if e, ok := v.(map[string]interface{}); ok {
+				names = append(names, fmt.Sprint(e["name"]))
+			}
+		}
+		env = strings.Join(names, ",")
+
+		l, _, _ := unstructured.NestedStringMap(container, "resources", "limits")
+		pairs := []string{}
+		for k, v := range l {
+			pairs = append(pairs, k+"="+v)
+		}
+		sort.Strings(pairs)
+		limits = strings.Join(pairs, ",")
+	}
+	return args, env, limits
+}
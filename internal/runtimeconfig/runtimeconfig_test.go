@@ -0,0 +1,141 @@
+package runtimeconfig
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/web-seven/overlock/internal/packages"
+)
+
+func TestManifest(t *testing.T) {
+	opts := Options{Name: "debug", Debug: true, Args: []string{"--poll=1m"}, Env: []string{"AWS_REGION=eu-west-1"}, CPU: "500m", Memory: "256Mi"}
+	rc, err := opts.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rc.GetName() != "debug" || rc.GetKind() != "DeploymentRuntimeConfig" {
+		t.Errorf("unexpected runtime config %s %s", rc.GetKind(), rc.GetName())
+	}
+	args, env, limits := Summary(rc)
+	if args != "--debug --poll=1m" || env != "AWS_REGION" || limits != "cpu=500m,memory=256Mi" {
+		t.Errorf("unexpected summary %q %q %q", args, env, limits)
+	}
+
+	opts = Options{Name: "invalid", Env: []string{"AWS_REGION"}}
+	if _, err := opts.Manifest(); err == nil {
+		t.Error("expected error for environment variable without value")
+	}
+}
+
+func TestDelete(t *testing.T) {
+	rc, _ := (&Options{Name: "debug"}).Manifest()
+	provider := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "pkg.crossplane.io/v1",
+		"kind":       "Provider",
+		"metadata":   map[string]interface{}{"name": "provider-nop"},
+		"spec":       map[string]interface{}{"runtimeConfigRef": map[string]interface{}{"name": "debug"}},
+	}}
+	dc := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		packages.Providers:      "ProviderList",
+		packages.Functions:      "FunctionList",
+		packages.RuntimeConfigs: "DeploymentRuntimeConfigList",
+	}, rc, provider)
+	logger := zap.NewNop().Sugar()
+
+	err := Delete(context.Background(), dc, "debug", logger)
+	if err == nil || !strings.Contains(err.Error(), "Provider/provider-nop") {
+		t.Errorf("expected runtime config in use error, got %v", err)
+	}
+
+	if err := dc.Resource(packages.Providers).Delete(context.Background(), "provider-nop", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Delete(context.Background(), dc, "debug", logger); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	rc, _ := (&Options{Name: "debug"}).Manifest()
+	provider := func(name string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "pkg.crossplane.io/v1",
+			"kind":       "Provider",
+			"metadata":   map[string]interface{}{"name": name},
+			"spec":       map[string]interface{}{"runtimeConfigRef": map[string]interface{}{"name": "debug"}},
+		}}
+	}
+	dc := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		packages.Providers:      "ProviderList",
+		packages.Functions:      "FunctionList",
+		packages.RuntimeConfigs: "DeploymentRuntimeConfigList",
+	}, rc, provider("provider-nop"), provider("provider-helm"))
+	ctx := context.Background()
+	logger := zap.NewNop().Sugar()
+
+	if err := Prune(ctx, dc, "debug", "Provider/provider-nop", logger); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dc.Resource(packages.RuntimeConfigs).Get(ctx, "debug", metav1.GetOptions{}); err != nil {
+		t.Errorf("runtime config used by provider-helm deleted: %v", err)
+	}
+
+	if err := dc.Resource(packages.Providers).Delete(ctx, "provider-helm", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Prune(ctx, dc, "debug", "Provider/provider-nop", logger); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dc.Resource(packages.RuntimeConfigs).Get(ctx, "debug", metav1.GetOptions{}); err == nil {
+		t.Error("unused runtime config not deleted")
+	}
+}
+
+func TestManifestTemplate(t *testing.T) {
+	template := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{},
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"nodeSelector": map[string]interface{}{"overlock.io/scope": "engine"},
+					"containers": []interface{}{map[string]interface{}{
+						"name": RuntimeContainer,
+						"env":  []interface{}{map[string]interface{}{"name": "AWS_REGION", "value": "us-east-1"}},
+					}},
+				},
+			},
+		},
+	}
+	opts := Options{Name: "debug", Debug: true, Env: []string{"AWS_REGION=eu-west-1"}, Template: template}
+	rc, err := opts.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodeSelector, _, _ := unstructured.NestedStringMap(rc.Object, "spec", "deploymentTemplate", "spec", "template", "spec", "nodeSelector")
+	if nodeSelector["overlock.io/scope"] != "engine" {
+		t.Errorf("expected node selector of template, got %v", nodeSelector)
+	}
+	containers, _, _ := unstructured.NestedSlice(rc.Object, "spec", "deploymentTemplate", "spec", "template", "spec", "containers")
+	if len(containers) != 1 {
+		t.Fatalf("expected single runtime container, got %v", containers)
+	}
+	env, _, _ := unstructured.NestedSlice(containers[0].(map[string]interface{}), "env")
+	if len(env) != 1 || env[0].(map[string]interface{})["value"] != "eu-west-1" {
+		t.Errorf("expected environment variable of options, got %v", env)
+	}
+	original, _, _ := unstructured.NestedSlice(template, "spec", "template", "spec", "containers")
+	if _, found := original[0].(map[string]interface{})["args"]; found {
+		t.Error("template modified")
+	}
+	if args, _, _ := Summary(rc); args != "--debug" {
+		t.Errorf("unexpected args %q", args)
+	}
+}
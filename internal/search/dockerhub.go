@@ -0,0 +1,82 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"go.uber.org/zap"
+)
+
+const (
+	dockerHubSearchURL = "https://hub.docker.com/v2/search/repositories/"
+	dockerHubPageSize  = 50
+)
+
+type dockerHubSearchResult struct {
+	Results []struct {
+		RepoName         string `json:"repo_name"`
+		ShortDescription string `json:"short_description"`
+	} `json:"results"`
+}
+
+// Search packages on Docker Hub, which does not expose _catalog API
+func searchDockerHub(ctx context.Context, query string, org string, opts []remote.Option, logger *zap.SugaredLogger) ([]Package, error) {
+	params := url.Values{}
+	params.Set("query", strings.TrimSpace(org+" "+query))
+	params.Set("page_size", fmt.Sprint(dockerHubPageSize))
+
+	result := dockerHubSearchResult{}
+	if err := getJSON(ctx, dockerHubSearchURL+"?"+params.Encode(), &result); err != nil {
+		return nil, err
+	}
+
+	pkgs := []Package{}
+	for _, r := range result.Results {
+		if org != "" && !strings.HasPrefix(r.RepoName, org+"/") {
+			continue
+		}
+		repository, err := name.NewRepository(dockerHubDomain + "/" + r.RepoName)
+		if err != nil {
+			logger.Debugf("Skip %s: %v", r.RepoName, err)
+			continue
+		}
+		tags, err := remote.List(repository, opts...)
+		if err != nil {
+			logger.Debugf("Cannot list tags of %s: %v", r.RepoName, err)
+			continue
+		}
+		pkg := inspectRepository(repository.Name(), dockerHubDomain+"/"+r.RepoName, tags, opts, logger)
+		if pkg == nil {
+			continue
+		}
+		if pkg.Description == "" {
+			pkg.Description = r.ShortDescription
+		}
+		pkgs = append(pkgs, *pkg)
+	}
+	return pkgs, nil
+}
+
+// Request JSON document and decode it to v
+func getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response status %s from %s", resp.Status, url)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
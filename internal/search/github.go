@@ -0,0 +1,26 @@
+package search
+
+import (
+	"context"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"go.uber.org/zap"
+
+	"github.com/web-seven/overlock/internal/github"
+)
+
+// Search packages of GitHub organization, ghcr.io does not expose _catalog API
+func searchGithub(ctx context.Context, query string, token string, org string, opts []remote.Option, logger *zap.SugaredLogger) ([]Package, error) {
+	repositories, err := github.GetPackages(ctx, query, token, org, logger)
+	if err != nil {
+		return nil, err
+	}
+	pkgs := []Package{}
+	for _, repo := range repositories {
+		repository := ghcrDomain + "/" + org + "/" + repo.Name
+		if pkg := inspectRepository(repository, repository, repo.Tags, opts, logger); pkg != nil {
+			pkgs = append(pkgs, *pkg)
+		}
+	}
+	return pkgs, nil
+}
@@ -0,0 +1,66 @@
+package search
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"go.uber.org/zap"
+
+	"github.com/web-seven/overlock/internal/image"
+)
+
+// Search packages using OCI distribution _catalog and tags API.
+// Host is used to access registry and display replaces it in results.
+func searchCatalog(ctx context.Context, host string, display string, org string, query string, opts []remote.Option, logger *zap.SugaredLogger) ([]Package, error) {
+	reg, err := name.NewRegistry(host)
+	if err != nil {
+		return nil, err
+	}
+	repos, err := remote.Catalog(ctx, reg, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs := []Package{}
+	for _, repo := range repos {
+		if org != "" && !strings.HasPrefix(repo, org+"/") {
+			continue
+		}
+		if !strings.Contains(repo, query) {
+			continue
+		}
+		repository, err := name.NewRepository(host + "/" + repo)
+		if err != nil {
+			logger.Debugf("Skip %s: %v", repo, err)
+			continue
+		}
+		tags, err := remote.List(repository, opts...)
+		if err != nil {
+			logger.Debugf("Cannot list tags of %s: %v", repo, err)
+			continue
+		}
+		if pkg := inspectRepository(repository.Name(), display+"/"+repo, tags, opts, logger); pkg != nil {
+			pkgs = append(pkgs, *pkg)
+		}
+	}
+	return pkgs, nil
+}
+
+// Read kind and description of Crossplane package from image annotated with io.crossplane.xpkg
+func inspectPackage(ref string, opts []remote.Option) (string, string, error) {
+	pRef, err := name.ParseReference(ref)
+	if err != nil {
+		return "", "", err
+	}
+	img, err := remote.Image(pRef, opts...)
+	if err != nil {
+		return "", "", err
+	}
+	meta, err := image.PackageMeta(img)
+	if err != nil {
+		return "", "", err
+	}
+	return meta.GetKind(), meta.GetAnnotations()[image.DescriptionAnnotation], nil
+}
@@ -2,42 +2,193 @@ package search
 
 import (
 	"context"
+	"encoding/json"
 	"net/url"
+	"sort"
 	"strings"
 
+	semver "github.com/Masterminds/semver/v3"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/pterm/pterm"
 	"go.uber.org/zap"
 
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
-	"github.com/web-seven/overlock/internal/github"
-	overlockerrors "github.com/web-seven/overlock/pkg/errors"
 	"github.com/web-seven/overlock/pkg/registry"
 )
 
-func SearchPackages(ctx context.Context, client *kubernetes.Clientset, config *rest.Config, query string, versions bool, logger *zap.SugaredLogger) (pterm.TableData, error) {
+const (
+	ghcrDomain      = "ghcr.io"
+	dockerHubDomain = "docker.io"
+)
+
+// Package is a Crossplane package found in a registry
+type Package struct {
+	Repository  string
+	Kind        string
+	Version     string
+	Versions    []string
+	Description string
+}
+
+// SearchPackages searches Crossplane packages in registries of current context
+// and in Upbound marketplace. If registryName is set, only that registry is searched.
+func SearchPackages(ctx context.Context, client *kubernetes.Clientset, config *rest.Config, query string, versions bool, registryName string, logger *zap.SugaredLogger) (pterm.TableData, error) {
 	registries, err := registry.Registries(ctx, client)
 	if err != nil {
 		logger.Error("Cannot get registries")
 		return nil, err
 	}
 
+	header := []string{"URL", "KIND", "VERSION", "DESCRIPTION"}
+	if versions {
+		header = append(header, "VERSIONS")
+	}
+	tableRegs := pterm.TableData{header}
+
+	searchMarketplace := registryName == ""
+	pkgs := []Package{}
 	for _, r := range registries {
-		registryUrl := r.Annotations["overlock-registry-server-url"]
-		u, _ := url.Parse(registryUrl)
-		org := strings.TrimPrefix(u.Path, "/")
-		// Switch statement to handle different registry types
-		switch {
-		case strings.Contains(registryUrl, "ghcr.io"):
-			tableRegs, err := github.GetPackages(ctx, query, versions, r, registryUrl, org, logger)
-			if err != nil {
-				return nil, err
+		if registryName != "" && r.GetName() != registryName {
+			continue
+		}
+		registryUrl := r.Annotations[registry.RegistryServerLabel]
+		if strings.Contains(registryUrl, registry.DefaultRemoteDomain) {
+			searchMarketplace = true
+			continue
+		}
+		found, err := searchRegistry(ctx, config, r, registryUrl, query, logger)
+		if err != nil {
+			logger.Warnf("Cannot search packages in %s: %v", registryUrl, err)
+			continue
+		}
+		pkgs = append(pkgs, found...)
+	}
+
+	if searchMarketplace {
+		found, err := searchUpbound(ctx, query, logger)
+		if err != nil {
+			logger.Warnf("Cannot search packages in %s: %v", registry.DefaultRemoteDomain, err)
+		}
+		pkgs = append(pkgs, found...)
+	}
+
+	for _, pkg := range pkgs {
+		row := []string{pkg.Repository, pkg.Kind, pkg.Version, pkg.Description}
+		if versions {
+			row = append(row, strings.Join(pkg.Versions, ", "))
+		}
+		tableRegs = append(tableRegs, row)
+	}
+	return tableRegs, nil
+}
+
+// Dispatch search to backend by type of registry
+func searchRegistry(ctx context.Context, config *rest.Config, r *registry.Registry, registryUrl string, query string, logger *zap.SugaredLogger) ([]Package, error) {
+	if r.GetName() == registry.LocalRegistryName {
+		var pkgs []Package
+		err := registry.WithLocalRegistry(ctx, config, logger, func(host string, opts ...remote.Option) error {
+			var err error
+			pkgs, err = searchCatalog(ctx, host, r.LocalDomain(), "", query, opts, logger)
+			return err
+		})
+		return pkgs, err
+	}
+
+	host, org := splitServer(registryUrl)
+	auth := registryAuth(r, registryUrl)
+	opts := []remote.Option{remote.WithContext(ctx), remote.WithAuth(authn.Anonymous)}
+	if auth.Username != "" {
+		opts = []remote.Option{remote.WithContext(ctx), remote.WithAuth(auth)}
+	}
+
+	switch {
+	case host == ghcrDomain:
+		return searchGithub(ctx, query, auth.Password, org, opts, logger)
+	case host == dockerHubDomain || strings.HasSuffix(host, "."+dockerHubDomain):
+		// Docker Hub server is usually configured with API version path
+		return searchDockerHub(ctx, query, strings.Trim(strings.TrimPrefix(org, "v1"), "/"), opts, logger)
+	default:
+		return searchCatalog(ctx, host, host, org, query, opts, logger)
+	}
+}
+
+// Build package entry from repository tags and xpkg metadata of latest tag.
+// Returns nil if repository does not contain Crossplane package.
+func inspectRepository(repository string, display string, tags []string, opts []remote.Option, logger *zap.SugaredLogger) *Package {
+	sorted := sortTags(tags)
+	if len(sorted) == 0 {
+		return nil
+	}
+	ref := repository + ":" + sorted[0]
+	kind, description, err := inspectPackage(ref, opts)
+	if err != nil {
+		logger.Debugf("Skip %s: %v", ref, err)
+		return nil
+	}
+	return &Package{
+		Repository:  display,
+		Kind:        kind,
+		Version:     sorted[0],
+		Versions:    sorted,
+		Description: description,
+	}
+}
+
+// Sort tags by semantic version descending, non semantic tags are dropped
+// unless there are no semantic tags at all
+func sortTags(tags []string) []string {
+	versions := []*semver.Version{}
+	original := map[*semver.Version]string{}
+	for _, tag := range tags {
+		v, err := semver.NewVersion(tag)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, v)
+		original[v] = tag
+	}
+	if len(versions) == 0 {
+		for _, tag := range tags {
+			if tag == "latest" {
+				return []string{tag}
 			}
-			return tableRegs, nil
-		default:
-			return nil, overlockerrors.NewPackageNotFoundError(query, registryUrl, "", "registry type not supported for package search")
 		}
+		return tags
+	}
+	sort.Sort(sort.Reverse(semver.Collection(versions)))
+	sorted := make([]string, 0, len(versions))
+	for _, v := range versions {
+		sorted = append(sorted, original[v])
+	}
+	return sorted
+}
+
+// Split registry server URL to host and organization path
+func splitServer(registryUrl string) (string, string) {
+	if !strings.Contains(registryUrl, "://") {
+		registryUrl = "https://" + registryUrl
+	}
+	u, err := url.Parse(registryUrl)
+	if err != nil {
+		return registryUrl, ""
+	}
+	return u.Host, strings.Trim(u.Path, "/")
+}
+
+// Credentials of registry from its docker config
+func registryAuth(r *registry.Registry, registryUrl string) *authn.Basic {
+	auth := registry.RegistryConfig{}
+	if err := json.Unmarshal(r.Data[".dockerconfigjson"], &auth); err != nil {
+		return &authn.Basic{}
+	}
+	if a, ok := auth.Auths[registryUrl]; ok {
+		return &authn.Basic{Username: a.Username, Password: a.Password}
+	}
+	for _, a := range auth.Auths {
+		return &authn.Basic{Username: a.Username, Password: a.Password}
 	}
-	return nil, err
+	return &authn.Basic{}
 }
@@ -0,0 +1,55 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		want []string
+	}{
+		{
+			name: "semantic tags sorted descending",
+			tags: []string{"v0.1.0", "latest", "v0.10.0", "v0.2.1", "sha256-abc.sig"},
+			want: []string{"v0.10.0", "v0.2.1", "v0.1.0"},
+		},
+		{
+			name: "only latest tag",
+			tags: []string{"main", "latest"},
+			want: []string{"latest"},
+		},
+		{
+			name: "no semantic tags",
+			tags: []string{"main", "dev"},
+			want: []string{"main", "dev"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sortTags(tt.tags); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sortTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitServer(t *testing.T) {
+	tests := []struct {
+		server   string
+		wantHost string
+		wantOrg  string
+	}{
+		{"https://ghcr.io/acme", "ghcr.io", "acme"},
+		{"registry.example.com", "registry.example.com", ""},
+		{"https://index.docker.io/v1/", "index.docker.io", "v1"},
+	}
+	for _, tt := range tests {
+		host, org := splitServer(tt.server)
+		if host != tt.wantHost || org != tt.wantOrg {
+			t.Errorf("splitServer(%q) = %q, %q, want %q, %q", tt.server, host, org, tt.wantHost, tt.wantOrg)
+		}
+	}
+}
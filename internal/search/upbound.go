@@ -0,0 +1,71 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/web-seven/overlock/pkg/registry"
+)
+
+const (
+	upboundSearchURL = "https://api.upbound.io/v1/search"
+	upboundPageSize  = 50
+)
+
+type upboundSearchResult struct {
+	Packages []struct {
+		Account     string `json:"account"`
+		Name        string `json:"name"`
+		Repository  string `json:"repository"`
+		PackageType string `json:"packageType"`
+		Version     string `json:"version"`
+		Description string `json:"description"`
+	} `json:"packages"`
+}
+
+// Search packages in Upbound marketplace. Marketplace lists only Crossplane
+// packages, so its metadata is used instead of inspecting each image.
+func searchUpbound(ctx context.Context, query string, logger *zap.SugaredLogger) ([]Package, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("size", fmt.Sprint(upboundPageSize))
+
+	result := upboundSearchResult{}
+	if err := getJSON(ctx, upboundSearchURL+"?"+params.Encode(), &result); err != nil {
+		return nil, err
+	}
+
+	pkgs := []Package{}
+	for _, p := range result.Packages {
+		repository := p.Repository
+		if repository == "" {
+			repository = p.Name
+		}
+		pkgs = append(pkgs, Package{
+			Repository:  strings.Join([]string{registry.DefaultRemoteDomain, p.Account, repository}, "/"),
+			Kind:        kindFromType(p.PackageType),
+			Version:     p.Version,
+			Versions:    []string{p.Version},
+			Description: p.Description,
+		})
+	}
+	logger.Debugf("Found %d packages in marketplace", len(pkgs))
+	return pkgs, nil
+}
+
+// Convert marketplace package type to kind of package meta
+func kindFromType(packageType string) string {
+	switch strings.ToLower(packageType) {
+	case "provider":
+		return "Provider"
+	case "configuration":
+		return "Configuration"
+	case "function":
+		return "Function"
+	}
+	return packageType
+}
@@ -0,0 +1,138 @@
+package signature
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// Environment variable with password of encrypted cosign private key
+	PasswordEnv = "COSIGN_PASSWORD"
+
+	sigstorePrivateKeyType = "ENCRYPTED SIGSTORE PRIVATE KEY"
+	cosignPrivateKeyType   = "ENCRYPTED COSIGN PRIVATE KEY"
+	ecPrivateKeyType       = "EC PRIVATE KEY"
+	pkcs8PrivateKeyType    = "PRIVATE KEY"
+	publicKeyType          = "PUBLIC KEY"
+)
+
+// Encrypted private key envelope written by `cosign generate-key-pair`
+type encryptedKey struct {
+	KDF struct {
+		Name   string `json:"name"`
+		Params struct {
+			N int `json:"N"`
+			R int `json:"r"`
+			P int `json:"p"`
+		} `json:"params"`
+		Salt []byte `json:"salt"`
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce []byte `json:"nonce"`
+	} `json:"cipher"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// LoadPrivateKey reads ECDSA private key from PEM file. Keys encrypted by
+// cosign are decrypted with password from COSIGN_PASSWORD environment variable.
+func LoadPrivateKey(path string) (*ecdsa.PrivateKey, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrivateKey(content, []byte(os.Getenv(PasswordEnv)))
+}
+
+// ParsePrivateKey parses PEM encoded plain or cosign encrypted ECDSA private key
+func ParsePrivateKey(content []byte, password []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+
+	der := block.Bytes
+	switch block.Type {
+	case sigstorePrivateKeyType, cosignPrivateKeyType:
+		var err error
+		der, err = decrypt(block.Bytes, password)
+		if err != nil {
+			return nil, err
+		}
+	case ecPrivateKeyType:
+		return x509.ParseECPrivateKey(der)
+	case pkcs8PrivateKeyType:
+	default:
+		return nil, fmt.Errorf("unsupported private key type %s", block.Type)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("only ECDSA private keys are supported")
+	}
+	return ecKey, nil
+}
+
+// LoadPublicKey reads PEM encoded ECDSA public key
+func LoadPublicKey(path string) (*ecdsa.PublicKey, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePublicKey(content)
+}
+
+// ParsePublicKey parses PEM encoded ECDSA public key
+func ParsePublicKey(content []byte) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode(content)
+	if block == nil || block.Type != publicKeyType {
+		return nil, errors.New("public key is not PEM encoded")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	ecKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("only ECDSA public keys are supported")
+	}
+	return ecKey, nil
+}
+
+func decrypt(content []byte, password []byte) ([]byte, error) {
+	envelope := encryptedKey{}
+	if err := json.Unmarshal(content, &envelope); err != nil {
+		return nil, fmt.Errorf("cannot decode encrypted private key: %w", err)
+	}
+	if envelope.KDF.Name != "scrypt" || envelope.Cipher.Name != "nacl/secretbox" {
+		return nil, fmt.Errorf("unsupported private key encryption %s/%s", envelope.KDF.Name, envelope.Cipher.Name)
+	}
+
+	params := envelope.KDF.Params
+	derived, err := scrypt.Key(password, envelope.KDF.Salt, params.N, params.R, params.P, 32)
+	if err != nil {
+		return nil, err
+	}
+	var key [32]byte
+	copy(key[:], derived)
+	var nonce [24]byte
+	copy(nonce[:], envelope.Cipher.Nonce)
+
+	der, ok := secretbox.Open(nil, envelope.Ciphertext, &nonce, &key)
+	if !ok {
+		return nil, fmt.Errorf("cannot decrypt private key, check %s", PasswordEnv)
+	}
+	return der, nil
+}
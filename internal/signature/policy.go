@@ -0,0 +1,89 @@
+package signature
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// Environment variable overriding path of verification policy
+	PolicyPathEnv = "OVERLOCK_VERIFICATION_POLICY"
+
+	policyFileName = "verification.yaml"
+)
+
+// Policy maps package prefixes to keys trusted to sign packages under them
+type Policy struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule requires packages with reference starting with prefix to be signed by one of keys
+type Rule struct {
+	Prefix string   `json:"prefix"`
+	Keys   []string `json:"keys"`
+}
+
+// PolicyPath returns path of verification policy file
+func PolicyPath() string {
+	if path := os.Getenv(PolicyPathEnv); path != "" {
+		return path
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "overlock", policyFileName)
+}
+
+// LoadPolicy reads verification policy, missing policy file results in empty policy
+func LoadPolicy(path string) (*Policy, error) {
+	policy := &Policy{}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return policy, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(content, policy); err != nil {
+		return nil, fmt.Errorf("cannot parse verification policy %s: %w", path, err)
+	}
+	return policy, nil
+}
+
+// Rule with the longest prefix matching package reference, nil if package is not covered by policy
+func (p *Policy) RuleFor(ref string) *Rule {
+	var matched *Rule
+	for i, rule := range p.Rules {
+		if rule.Prefix == "" || !strings.HasPrefix(ref, rule.Prefix) {
+			continue
+		}
+		if matched == nil || len(rule.Prefix) > len(matched.Prefix) {
+			matched = &p.Rules[i]
+		}
+	}
+	return matched
+}
+
+// Load public keys of rule, key paths starting with ~/ are relative to home directory
+func (r *Rule) PublicKeys() ([]*ecdsa.PublicKey, error) {
+	keys := []*ecdsa.PublicKey{}
+	for _, path := range r.Keys {
+		if strings.HasPrefix(path, "~/") {
+			home, _ := os.UserHomeDir()
+			path = filepath.Join(home, path[2:])
+		}
+		key, err := LoadPublicKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load public key %s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keys configured for prefix %s", r.Prefix)
+	}
+	return keys, nil
+}
@@ -0,0 +1,146 @@
+package signature
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+const (
+	// Media type of cosign simple signing payload layer
+	SimpleSigningMediaType types.MediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	// Layer annotation holding base64 encoded signature of payload
+	SignatureAnnotation = "dev.cosignproject.cosign/signature"
+
+	signatureTagSuffix = ".sig"
+	signatureType      = "cosign container image signature"
+)
+
+// ErrNoSignature is returned when image has no signature signed by trusted keys
+var ErrNoSignature = errors.New("no valid signature found")
+
+// Simple signing payload, see https://github.com/containers/image/blob/main/docs/containers-signature.5.md
+type payload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	Optional map[string]interface{} `json:"optional"`
+}
+
+// SignatureTag returns cosign signature tag of image digest (sha256-<hex>.sig)
+func SignatureTag(repository name.Repository, digest v1.Hash) name.Tag {
+	return repository.Tag(strings.Replace(digest.String(), ":", "-", 1) + signatureTagSuffix)
+}
+
+// Sign image digest with private key and push cosign compatible signature
+// next to image in repository. Identity is the repository consumers pull
+// image from, it differs from repository reached through port-forward.
+// Existing signatures of digest are kept.
+func Sign(ctx context.Context, repository name.Repository, identity string, digest v1.Hash, key *ecdsa.PrivateKey, opts ...remote.Option) error {
+	p := payload{}
+	p.Critical.Identity.DockerReference = identity
+	p.Critical.Image.DockerManifestDigest = digest.String()
+	p.Critical.Type = signatureType
+	content, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256(content)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	if err != nil {
+		return err
+	}
+
+	opts = append(opts, remote.WithContext(ctx))
+	tag := SignatureTag(repository, digest)
+	base, err := remote.Image(tag, opts...)
+	if err != nil {
+		base = mutate.ConfigMediaType(mutate.MediaType(empty.Image, types.OCIManifestSchema1), types.OCIConfigJSON)
+	}
+	img, err := mutate.Append(base, mutate.Addendum{
+		Layer: static.NewLayer(content, SimpleSigningMediaType),
+		Annotations: map[string]string{
+			SignatureAnnotation: base64.StdEncoding.EncodeToString(sig),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return remote.Write(tag, img, opts...)
+}
+
+// Verify that image digest has signature made by one of keys
+func Verify(ctx context.Context, repository name.Repository, digest v1.Hash, keys []*ecdsa.PublicKey, opts ...remote.Option) error {
+	opts = append(opts, remote.WithContext(ctx))
+	sigImage, err := remote.Image(SignatureTag(repository, digest), opts...)
+	if err != nil {
+		return fmt.Errorf("%w for %s@%s: %v", ErrNoSignature, repository.Name(), digest, err)
+	}
+	manifest, err := sigImage.Manifest()
+	if err != nil {
+		return err
+	}
+
+	for _, desc := range manifest.Layers {
+		if desc.MediaType != SimpleSigningMediaType {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(desc.Annotations[SignatureAnnotation])
+		if err != nil {
+			continue
+		}
+		content, err := layerContent(sigImage, desc.Digest)
+		if err != nil {
+			return err
+		}
+		p := payload{}
+		if err := json.Unmarshal(content, &p); err != nil {
+			continue
+		}
+		if p.Critical.Image.DockerManifestDigest != digest.String() {
+			continue
+		}
+		hash := sha256.Sum256(content)
+		for _, key := range keys {
+			if ecdsa.VerifyASN1(key, hash[:], sig) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w for %s@%s", ErrNoSignature, repository.Name(), digest)
+}
+
+func layerContent(img v1.Image, digest v1.Hash) ([]byte, error) {
+	layer, err := img.LayerByDigest(digest)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
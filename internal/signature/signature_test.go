@@ -0,0 +1,124 @@
+package signature
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+func TestSignVerify(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+
+	repository, err := name.NewRepository(u.Host + "/acme/provider-foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, _ := random.Image(256, 1)
+	if err := remote.Write(repository.Tag("v1.0.0"), img); err != nil {
+		t.Fatal(err)
+	}
+	digest, _ := img.Digest()
+
+	trusted, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	untrusted, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ctx := context.Background()
+	identity := "registry.overlock.svc.cluster.local/acme/provider-foo"
+
+	if err := Verify(ctx, repository, digest, []*ecdsa.PublicKey{&trusted.PublicKey}); !errors.Is(err, ErrNoSignature) {
+		t.Fatalf("expected ErrNoSignature for unsigned image, got %v", err)
+	}
+
+	if err := Sign(ctx, repository, identity, digest, untrusted); err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(ctx, repository, digest, []*ecdsa.PublicKey{&trusted.PublicKey}); !errors.Is(err, ErrNoSignature) {
+		t.Fatalf("expected ErrNoSignature for untrusted signature, got %v", err)
+	}
+
+	if err := Sign(ctx, repository, identity, digest, trusted); err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(ctx, repository, digest, []*ecdsa.PublicKey{&trusted.PublicKey}); err != nil {
+		t.Fatalf("expected valid signature, got %v", err)
+	}
+
+	sigImage, err := remote.Image(SignatureTag(repository, digest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, _ := sigImage.Manifest()
+	content, err := layerContent(sigImage, manifest.Layers[0].Digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := payload{}
+	if err := json.Unmarshal(content, &p); err != nil || p.Critical.Identity.DockerReference != identity {
+		t.Errorf("expected signature identity %q, got %q", identity, p.Critical.Identity.DockerReference)
+	}
+}
+
+func TestParseEncryptedPrivateKey(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	password := []byte("secret")
+
+	envelope := encryptedKey{}
+	envelope.KDF.Name = "scrypt"
+	envelope.KDF.Params.N, envelope.KDF.Params.R, envelope.KDF.Params.P = 1024, 8, 1
+	envelope.KDF.Salt = []byte("0123456789abcdef0123456789abcdef")
+	envelope.Cipher.Name = "nacl/secretbox"
+	envelope.Cipher.Nonce = []byte("0123456789abcdef01234567")
+	derived, _ := scrypt.Key(password, envelope.KDF.Salt, 1024, 8, 1, 32)
+	var secret [32]byte
+	var nonce [24]byte
+	copy(secret[:], derived)
+	copy(nonce[:], envelope.Cipher.Nonce)
+	envelope.Ciphertext = secretbox.Seal(nil, der, &nonce, &secret)
+	content, _ := json.Marshal(envelope)
+	encoded := pem.EncodeToMemory(&pem.Block{Type: sigstorePrivateKeyType, Bytes: content})
+
+	parsed, err := ParsePrivateKey(encoded, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Equal(key) {
+		t.Error("decrypted key does not match original key")
+	}
+	if _, err := ParsePrivateKey(encoded, []byte("wrong")); err == nil {
+		t.Error("expected error for wrong password")
+	}
+}
+
+func TestPolicyRuleFor(t *testing.T) {
+	policy := Policy{Rules: []Rule{
+		{Prefix: "ghcr.io/acme/", Keys: []string{"acme.pub"}},
+		{Prefix: "ghcr.io/acme/internal/", Keys: []string{"internal.pub"}},
+	}}
+
+	if rule := policy.RuleFor("ghcr.io/acme/internal/provider-foo:v1"); rule == nil || rule.Keys[0] != "internal.pub" {
+		t.Errorf("expected longest prefix rule, got %+v", rule)
+	}
+	if rule := policy.RuleFor("ghcr.io/acme/provider-foo:v1"); rule == nil || rule.Keys[0] != "acme.pub" {
+		t.Errorf("expected acme rule, got %+v", rule)
+	}
+	if rule := policy.RuleFor("xpkg.upbound.io/crossplane-contrib/provider-aws:v1"); rule != nil {
+		t.Errorf("expected no rule, got %+v", rule)
+	}
+}
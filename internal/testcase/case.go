@@ -0,0 +1,171 @@
+package testcase
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// Suffix of test case files
+	FileSuffix = ".test.yaml"
+	// Time given to test case when not set
+	DefaultTimeout = 5 * time.Minute
+)
+
+// Conditions of composite resource awaited when not set
+var DefaultConditions = []string{"Ready", "Synced"}
+
+// Case applies composite resource and asserts its state and state of its
+// composed resources
+type Case struct {
+	// Name of test case, file name by default
+	Name string `json:"name,omitempty"`
+	// Manifest with composite resource as the first object, relative to test case file
+	XR string `json:"xr"`
+	// Time given to composite resource to reach expected state
+	Timeout string `json:"timeout,omitempty"`
+	// Conditions of composite resource which must be True
+	Conditions []string `json:"conditions,omitempty"`
+	// Assertions on composite resource
+	Assertions []Assertion `json:"assertions,omitempty"`
+	// Expected composed resources
+	Composed []Composed `json:"composed,omitempty"`
+
+	// Test case file
+	File    string `json:"-"`
+	timeout time.Duration
+}
+
+// Composed resource expected for composite resource
+type Composed struct {
+	// Name of resource in composition
+	Name string `json:"name"`
+	// Kind of resource, any kind if empty
+	Kind       string      `json:"kind,omitempty"`
+	Assertions []Assertion `json:"assertions,omitempty"`
+}
+
+// Assertion on field of resource selected by JSONPath
+type Assertion struct {
+	Path string `json:"path"`
+	// Expected value of field, compared as text
+	Value interface{} `json:"value,omitempty"`
+	// Field exists or not, checked if value is not set
+	Exists *bool `json:"exists,omitempty"`
+}
+
+// Load reads test cases from files with test case suffix in directory
+// and its subdirectories, ordered by path
+func Load(dir string) ([]Case, error) {
+	cases := []Case{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, FileSuffix) {
+			return err
+		}
+		c, err := LoadCase(path)
+		if err != nil {
+			return err
+		}
+		cases = append(cases, *c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("no *%s files found in %s", FileSuffix, dir)
+	}
+	sort.Slice(cases, func(i, j int) bool { return cases[i].File < cases[j].File })
+	return cases, nil
+}
+
+// LoadCase reads and validates test case file
+func LoadCase(path string) (*Case, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Case{}
+	if err := yaml.UnmarshalStrict(content, c); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+	c.File = path
+	if c.Name == "" {
+		c.Name = strings.TrimSuffix(filepath.Base(path), FileSuffix)
+	}
+	if c.XR == "" {
+		return nil, fmt.Errorf("%s: xr is required", path)
+	}
+	if !filepath.IsAbs(c.XR) {
+		c.XR = filepath.Join(filepath.Dir(path), c.XR)
+	}
+	c.timeout = DefaultTimeout
+	if c.Timeout != "" {
+		if c.timeout, err = time.ParseDuration(c.Timeout); err != nil {
+			return nil, fmt.Errorf("%s: invalid timeout: %w", path, err)
+		}
+	}
+	if c.Conditions == nil {
+		c.Conditions = DefaultConditions
+	}
+	assertions := c.Assertions
+	for _, composed := range c.Composed {
+		if composed.Name == "" {
+			return nil, fmt.Errorf("%s: name of composed resource is required", path)
+		}
+		assertions = append(assertions, composed.Assertions...)
+	}
+	for _, a := range assertions {
+		if _, err := a.parse(); err != nil {
+			return nil, fmt.Errorf("%s: invalid path %s: %w", path, a.Path, err)
+		}
+	}
+	return c, nil
+}
+
+func (a Assertion) parse() (*jsonpath.JSONPath, error) {
+	path := a.Path
+	if !strings.Contains(path, "{") {
+		path = "{" + path + "}"
+	}
+	jp := jsonpath.New(a.Path)
+	return jp, jp.Parse(path)
+}
+
+// Check assertion against object, returns failure message
+func (a Assertion) Check(obj map[string]interface{}) string {
+	jp, err := a.parse()
+	if err != nil {
+		return err.Error()
+	}
+	out := &bytes.Buffer{}
+	err = jp.Execute(out, obj)
+	exists := err == nil && out.Len() > 0
+	switch {
+	case a.Value != nil:
+		if err != nil {
+			return fmt.Sprintf("%s: %v", a.Path, err)
+		}
+		if expected := fmt.Sprint(a.Value); out.String() != expected {
+			return fmt.Sprintf("%s: expected %q, got %q", a.Path, expected, out.String())
+		}
+	case a.Exists != nil && !*a.Exists:
+		if exists {
+			return fmt.Sprintf("%s: expected not to exist, got %q", a.Path, out.String())
+		}
+	default:
+		if !exists {
+			return fmt.Sprintf("%s: expected to exist", a.Path)
+		}
+	}
+	return ""
+}
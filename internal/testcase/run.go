@@ -0,0 +1,243 @@
+package testcase
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/web-seven/overlock/internal/image"
+	"github.com/web-seven/overlock/internal/resources"
+)
+
+// Interval of checks of composite resource state
+const pollInterval = 2 * time.Second
+
+// Runner of test cases against environment
+type Runner struct {
+	Dynamic dynamic.Interface
+	Mapper  meta.RESTMapper
+	// Apply manifest file, resources.ApplyResources by default
+	Apply func(ctx context.Context, file string) error
+	// Leave resources of test cases in environment
+	Keep   bool
+	Logger *zap.SugaredLogger
+}
+
+// Result of test case
+type Result struct {
+	Case     Case
+	Duration time.Duration
+	Failures []string
+}
+
+func (r Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// Run test cases one by one
+func (r *Runner) Run(ctx context.Context, cases []Case) []Result {
+	if r.Apply == nil {
+		r.Apply = func(ctx context.Context, file string) error {
+			return resources.ApplyResources(ctx, r.Dynamic, r.Logger, file)
+		}
+	}
+	results := []Result{}
+	for _, c := range cases {
+		if ctx.Err() != nil {
+			break
+		}
+		start := time.Now()
+		failures := r.runCase(ctx, c)
+		result := Result{Case: c, Duration: time.Since(start), Failures: failures}
+		if result.Passed() {
+			r.Logger.Infof("PASS %s (%s)", c.Name, result.Duration.Round(time.Millisecond))
+		} else {
+			r.Logger.Errorf("FAIL %s (%s)\n  %s", c.Name, result.Duration.Round(time.Millisecond), strings.Join(failures, "\n  "))
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func (r *Runner) runCase(ctx context.Context, c Case) []string {
+	content, err := os.ReadFile(c.XR)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	objects, err := image.ParseObjects(content)
+	if err != nil || len(objects) == 0 {
+		return []string{fmt.Sprintf("%s has no composite resource: %v", c.XR, err)}
+	}
+	xr := objects[0]
+	gvr, err := r.resource(xr.GroupVersionKind())
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	// Objects applied before failure are cleaned up as well
+	if !r.Keep {
+		defer r.cleanup(objects, c.timeout)
+	}
+	if err := r.Apply(ctx, c.XR); err != nil {
+		return []string{fmt.Sprintf("cannot apply %s: %v", c.XR, err)}
+	}
+
+	var failures []string
+	err = wait.PollUntilContextTimeout(ctx, pollInterval, c.timeout, true, func(ctx context.Context) (bool, error) {
+		var err error
+		failures, err = r.check(ctx, c, gvr, xr.GetName())
+		return len(failures) == 0, err
+	})
+	if err != nil && !wait.Interrupted(err) {
+		return append(failures, err.Error())
+	}
+	if err != nil && len(failures) > 0 {
+		failures[0] = fmt.Sprintf("not met after %s: %s", c.timeout, failures[0])
+	}
+	return failures
+}
+
+// Check conditions and assertions of composite resource and its composed
+// resources, returns failures
+func (r *Runner) check(ctx context.Context, c Case, gvr schema.GroupVersionResource, name string) ([]string, error) {
+	xr, err := r.Dynamic.Resource(gvr).Get(ctx, name, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return []string{fmt.Sprintf("%s %s not found", gvr.Resource, name)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	failures := conditionFailures(xr, c.Conditions)
+	for _, a := range c.Assertions {
+		if failure := a.Check(xr.Object); failure != "" {
+			failures = append(failures, xr.GetKind()+" "+failure)
+		}
+	}
+	if len(c.Composed) == 0 {
+		return failures, nil
+	}
+
+	composed, err := r.composed(ctx, xr)
+	if err != nil {
+		return nil, err
+	}
+	for _, expected := range c.Composed {
+		found := false
+		for _, obj := range composed {
+			if obj.GetAnnotations()[resources.AnnotationCompositionResourceName] != expected.Name || (expected.Kind != "" && obj.GetKind() != expected.Kind) {
+				continue
+			}
+			found = true
+			for _, a := range expected.Assertions {
+				if failure := a.Check(obj.Object); failure != "" {
+					failures = append(failures, expected.Name+" "+failure)
+				}
+			}
+		}
+		if !found {
+			failures = append(failures, fmt.Sprintf("composed resource %s not found", expected.Name))
+		}
+	}
+	return failures, nil
+}
+
+// Conditions which are not True, with their reasons
+func conditionFailures(u *unstructured.Unstructured, types []string) []string {
+	conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+	failures := []string{}
+	for _, t := range types {
+		status, message := "Unknown", "condition not reported yet"
+		for _, c := range conditions {
+			condition, ok := c.(map[string]interface{})
+			if !ok || condition["type"] != t {
+				continue
+			}
+			status, _ = condition["status"].(string)
+			message = strings.TrimSpace(fmt.Sprint(condition["reason"], " ", condition["message"]))
+		}
+		if status != "True" {
+			failures = append(failures, fmt.Sprintf("%s=%s: %s", t, status, message))
+		}
+	}
+	return failures
+}
+
+// Composed resources referenced by composite resource
+func (r *Runner) composed(ctx context.Context, xr *unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+	refs, _, _ := unstructured.NestedSlice(xr.Object, "spec", "resourceRefs")
+	composed := []unstructured.Unstructured{}
+	for _, ref := range refs {
+		ref, ok := ref.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		gvk := schema.FromAPIVersionAndKind(fmt.Sprint(ref["apiVersion"]), fmt.Sprint(ref["kind"]))
+		gvr, err := r.resource(gvk)
+		if err != nil {
+			return nil, err
+		}
+		obj, err := r.Dynamic.Resource(gvr).Get(ctx, fmt.Sprint(ref["name"]), metav1.GetOptions{})
+		if kerrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		composed = append(composed, *obj)
+	}
+	sort.Slice(composed, func(i, j int) bool { return composed[i].GetName() < composed[j].GetName() })
+	return composed, nil
+}
+
+func (r *Runner) resource(gvk schema.GroupVersionKind) (schema.GroupVersionResource, error) {
+	mapping, err := r.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return schema.GroupVersionResource{}, fmt.Errorf("cannot find resource of %s: %w", gvk.Kind, err)
+	}
+	return mapping.Resource, nil
+}
+
+// Delete objects of test case in reverse order and wait until composite
+// resource is gone, so its composed resources are removed
+func (r *Runner) cleanup(objects []unstructured.Unstructured, timeout time.Duration) {
+	// Test context can be cancelled at this point
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	for i := len(objects) - 1; i >= 0; i-- {
+		obj := objects[i]
+		gvr, err := r.resource(obj.GroupVersionKind())
+		if err != nil {
+			r.Logger.Warn(err)
+			continue
+		}
+		policy := metav1.DeletePropagationForeground
+		err = r.Dynamic.Resource(gvr).Namespace(obj.GetNamespace()).Delete(ctx, obj.GetName(), metav1.DeleteOptions{PropagationPolicy: &policy})
+		if err != nil && !kerrors.IsNotFound(err) {
+			r.Logger.Warnf("Cannot delete %s %s: %v", obj.GetKind(), obj.GetName(), err)
+		}
+	}
+	xr := objects[0]
+	gvr, err := r.resource(xr.GroupVersionKind())
+	if err != nil {
+		return
+	}
+	err = wait.PollUntilContextCancel(ctx, pollInterval, true, func(ctx context.Context) (bool, error) {
+		_, err := r.Dynamic.Resource(gvr).Get(ctx, xr.GetName(), metav1.GetOptions{})
+		return kerrors.IsNotFound(err), nil
+	})
+	if err != nil {
+		r.Logger.Warnf("%s %s not removed after %s.", xr.GetKind(), xr.GetName(), timeout)
+	}
+}
@@ -0,0 +1,135 @@
+package testcase
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/web-seven/overlock/internal/image"
+	"github.com/web-seven/overlock/internal/resources"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"xr.yaml": `apiVersion: example.org/v1
+kind: XBucket
+metadata:
+  name: demo
+spec:
+  region: eu-central-1
+`,
+		"bucket.test.yaml": `xr: xr.yaml
+timeout: 1s
+assertions:
+  - path: .spec.region
+    value: eu-central-1
+composed:
+  - name: bucket
+    kind: Bucket
+    assertions:
+      - path: '{.spec.forProvider.region}'
+        value: eu-central-1
+      - path: .spec.forProvider.acl
+        exists: false
+`,
+		"failing.test.yaml": `name: wrong region
+xr: xr.yaml
+timeout: 1s
+conditions: [Ready]
+composed:
+  - name: bucket
+    assertions:
+      - path: .spec.forProvider.region
+        value: us-east-1
+  - name: policy
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cases, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) != 2 || cases[0].Name != "bucket" || cases[1].Name != "wrong region" {
+		t.Fatalf("unexpected cases %v", cases)
+	}
+
+	xrs := schema.GroupVersionResource{Group: "example.org", Version: "v1", Resource: "xbuckets"}
+	buckets := schema.GroupVersionResource{Group: "s3.aws.upbound.io", Version: "v1beta1", Resource: "buckets"}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "XBucket"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "s3.aws.upbound.io", Version: "v1beta1", Kind: "Bucket"}, meta.RESTScopeRoot)
+	dc := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		xrs:     "XBucketList",
+		buckets: "BucketList",
+	})
+	ctx := context.Background()
+
+	// Crossplane is simulated by applying reconciled composite and composed resources
+	apply := func(ctx context.Context, file string) error {
+		content, _ := os.ReadFile(file)
+		objects, _ := image.ParseObjects(content)
+		xr := objects[0]
+		xr.Object["status"] = map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Ready", "status": "True"},
+			map[string]interface{}{"type": "Synced", "status": "True"},
+		}}
+		_ = unstructured.SetNestedSlice(xr.Object, []interface{}{
+			map[string]interface{}{"apiVersion": "s3.aws.upbound.io/v1beta1", "kind": "Bucket", "name": "demo-x7k2p"},
+		}, "spec", "resourceRefs")
+		bucket := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "s3.aws.upbound.io/v1beta1",
+			"kind":       "Bucket",
+			"metadata": map[string]interface{}{
+				"name":        "demo-x7k2p",
+				"annotations": map[string]interface{}{resources.AnnotationCompositionResourceName: "bucket"},
+			},
+			"spec": map[string]interface{}{"forProvider": map[string]interface{}{"region": "eu-central-1"}},
+		}}
+		if _, err := dc.Resource(xrs).Create(ctx, &xr, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+		// Composed resources are not garbage collected by fake client
+		if _, err := dc.Resource(buckets).Create(ctx, bucket, metav1.CreateOptions{}); err != nil && !kerrors.IsAlreadyExists(err) {
+			return err
+		}
+		return nil
+	}
+	runner := &Runner{Dynamic: dc, Mapper: mapper, Apply: apply, Logger: zap.NewNop().Sugar()}
+
+	results := runner.Run(ctx, cases)
+	if !results[0].Passed() {
+		t.Errorf("expected first case to pass, got %v", results[0].Failures)
+	}
+	if _, err := dc.Resource(xrs).Get(ctx, "demo", metav1.GetOptions{}); err == nil {
+		t.Error("composite resource not cleaned up")
+	}
+	failures := strings.Join(results[1].Failures, "\n")
+	if !strings.Contains(failures, `expected "us-east-1", got "eu-central-1"`) || !strings.Contains(failures, "composed resource policy not found") {
+		t.Errorf("unexpected failures of second case %q", failures)
+	}
+
+	report := &bytes.Buffer{}
+	if err := WriteJUnit(report, "bucket", results); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(report.String(), `<testsuite name="bucket" tests="2" failures="1"`) || !strings.Contains(report.String(), `<failure message="not met after 1s`) {
+		t.Errorf("unexpected report\n%s", report)
+	}
+}
@@ -0,0 +1,251 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rjeczalik/notify"
+	"go.uber.org/zap"
+)
+
+const DefaultDebounce = 500 * time.Millisecond
+
+// Paths matching these patterns (by any path element) never trigger a cycle
+var DefaultIgnore = []string{
+	".git", ".idea", ".vscode", "vendor", "node_modules", "bin", "dist", "_output", ".work",
+	"*.xpkg", "*.swp", "*.swx", "*~", ".#*",
+}
+
+// Handler runs one build cycle, ctx is cancelled when new changes arrive
+type Handler func(ctx context.Context, cycle *Cycle) error
+
+type Options struct {
+	// Quiet period after the last change before cycle is started
+	Debounce time.Duration
+	// Extensions of files triggering cycle, any file triggers cycle when empty
+	Extensions []string
+	// Additional ignore patterns, appended to DefaultIgnore
+	Ignore []string
+}
+
+// Watcher runs build cycles on changes of files under path
+type Watcher struct {
+	path    string
+	options Options
+	ignore  []string
+	logger  *zap.SugaredLogger
+}
+
+// New creates watcher of path
+func New(path string, logger *zap.SugaredLogger, options Options) *Watcher {
+	if options.Debounce == 0 {
+		options.Debounce = DefaultDebounce
+	}
+	return &Watcher{
+		path:    filepath.Clean(path),
+		options: options,
+		ignore:  append(append([]string{}, DefaultIgnore...), options.Ignore...),
+		logger:  logger,
+	}
+}
+
+// Run handler once and then on every batch of changes until ctx is cancelled
+func (w *Watcher) Run(ctx context.Context, handler Handler) error {
+	c := make(chan notify.EventInfo, 64)
+	if err := notify.Watch(filepath.Join(w.path, "..."), c, notify.Create, notify.Write, notify.Rename, notify.Remove); err != nil {
+		return err
+	}
+	defer notify.Stop(c)
+
+	events := make(chan string)
+	go func() {
+		for {
+			select {
+			case ev := <-c:
+				select {
+				case events <- ev.Path():
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	w.logger.Infof("Watching %s for changes, press Ctrl+C to stop.", w.path)
+	return w.loop(ctx, events, handler)
+}
+
+func (w *Watcher) loop(ctx context.Context, events <-chan string, handler Handler) error {
+	var (
+		number  int
+		cancel  context.CancelFunc = func() {}
+		done    chan struct{}
+		changes []string
+	)
+	start := func() {
+		number++
+		cycle := newCycle(number, changes)
+		changes = nil
+		var cycleCtx context.Context
+		cycleCtx, cancel = context.WithCancel(ctx)
+		done = make(chan struct{})
+		go func(ctx context.Context, done chan struct{}) {
+			defer close(done)
+			err := handler(ctx, cycle)
+			w.report(ctx, cycle, err)
+			if err == nil && ctx.Err() == nil && cycle.follow != nil {
+				if err := cycle.follow(ctx); err != nil && ctx.Err() == nil {
+					w.logger.Warn(err)
+				}
+			}
+		}(cycleCtx, done)
+	}
+	wait := func() {
+		if done != nil {
+			<-done
+		}
+	}
+
+	start()
+
+	timer := time.NewTimer(w.options.Debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			cancel()
+			wait()
+			w.logger.Info("Serve stopped.")
+			return nil
+		case path := <-events:
+			if !w.relevant(path) {
+				continue
+			}
+			w.logger.Debugf("Changed file: %s", path)
+			changes = appendUnique(changes, path)
+			cancel()
+			timer.Reset(w.options.Debounce)
+		case <-timer.C:
+			wait()
+			start()
+		}
+	}
+}
+
+// Report cycle result with timing summary
+func (w *Watcher) report(ctx context.Context, cycle *Cycle, err error) {
+	switch {
+	case ctx.Err() != nil && (err == nil || errors.Is(err, context.Canceled)):
+		w.logger.Infof("%s cancelled.", cycle.Summary())
+	case err != nil:
+		w.logger.Errorf("%s failed: %v", cycle.Summary(), err)
+	default:
+		w.logger.Infof("%s done.", cycle.Summary())
+	}
+}
+
+// Check if changed path should trigger cycle
+func (w *Watcher) relevant(path string) bool {
+	rel, err := filepath.Rel(w.path, path)
+	if err != nil {
+		rel = path
+	}
+	for _, elem := range strings.Split(filepath.ToSlash(rel), "/") {
+		for _, pattern := range w.ignore {
+			if matched, _ := filepath.Match(pattern, elem); matched {
+				return false
+			}
+		}
+	}
+	if len(w.options.Extensions) == 0 {
+		return true
+	}
+	ext := filepath.Ext(path)
+	for _, e := range w.options.Extensions {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+func appendUnique(list []string, item string) []string {
+	for _, i := range list {
+		if i == item {
+			return list
+		}
+	}
+	return append(list, item)
+}
+
+// Step of cycle with its duration
+type Step struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Cycle is one build-push-apply iteration triggered by a batch of changes
+type Cycle struct {
+	Number  int
+	Changes []string
+	started time.Time
+	steps   []Step
+	follow  func(ctx context.Context) error
+}
+
+func newCycle(number int, changes []string) *Cycle {
+	return &Cycle{
+		Number:  number,
+		Changes: changes,
+		started: time.Now(),
+	}
+}
+
+// Step runs fn as named step of cycle and records its duration
+func (c *Cycle) Step(name string, fn func() error) error {
+	started := time.Now()
+	err := fn()
+	c.steps = append(c.steps, Step{Name: name, Duration: time.Since(started)})
+	return err
+}
+
+// Follow registers fn to run after cycle succeeded and its summary is
+// printed, e.g. to stream logs. fn runs until next cycle starts.
+func (c *Cycle) Follow(fn func(ctx context.Context) error) {
+	c.follow = fn
+}
+
+// Steps finished in cycle
+func (c *Cycle) Steps() []Step {
+	return c.steps
+}
+
+// Summary of cycle timing, e.g. `Cycle #2 (3 file(s) changed) in 4.2s: build 3.1s, push 0.8s, apply 0.3s`
+func (c *Cycle) Summary() string {
+	summary := fmt.Sprintf("Cycle #%d", c.Number)
+	if len(c.Changes) > 0 {
+		summary += fmt.Sprintf(" (%d file(s) changed)", len(c.Changes))
+	}
+	summary += " in " + round(time.Since(c.started)).String()
+	steps := []string{}
+	for _, step := range c.steps {
+		steps = append(steps, step.Name+" "+round(step.Duration).String())
+	}
+	if len(steps) > 0 {
+		summary += ": " + strings.Join(steps, ", ")
+	}
+	return summary
+}
+
+func round(d time.Duration) time.Duration {
+	if d > time.Second {
+		return d.Round(100 * time.Millisecond)
+	}
+	return d.Round(time.Millisecond)
+}
@@ -0,0 +1,127 @@
+package watcher
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestLoopDebounceAndCancel(t *testing.T) {
+	w := New("/src", zap.NewNop().Sugar(), Options{Debounce: 50 * time.Millisecond, Extensions: []string{".go"}})
+	ctx, stop := context.WithCancel(context.Background())
+	events := make(chan string)
+
+	var mu sync.Mutex
+	started, cancelled := []int{}, []int{}
+	changes := map[int][]string{}
+	handler := func(ctx context.Context, cycle *Cycle) error {
+		mu.Lock()
+		started = append(started, cycle.Number)
+		changes[cycle.Number] = cycle.Changes
+		mu.Unlock()
+		select {
+		case <-ctx.Done():
+			mu.Lock()
+			cancelled = append(cancelled, cycle.Number)
+			mu.Unlock()
+			return ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+			return nil
+		}
+	}
+
+	result := make(chan error)
+	go func() { result <- w.loop(ctx, events, handler) }()
+
+	// Burst of changes during initial cycle cancels it and results in a single cycle
+	for _, path := range []string{"/src/a.go", "/src/b.go", "/src/a.go", "/src/.git/index", "/src/README.md"} {
+		events <- path
+	}
+	time.Sleep(400 * time.Millisecond)
+	stop()
+
+	if err := <-result; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(started) != 2 {
+		t.Fatalf("expected 2 cycles, got %v", started)
+	}
+	if len(cancelled) != 1 || cancelled[0] != 1 {
+		t.Errorf("expected initial cycle to be cancelled, got %v", cancelled)
+	}
+	if got := changes[2]; len(got) != 2 || got[0] != "/src/a.go" || got[1] != "/src/b.go" {
+		t.Errorf("unexpected changes of second cycle: %v", got)
+	}
+}
+
+func TestRelevant(t *testing.T) {
+	w := New("/src", zap.NewNop().Sugar(), Options{Extensions: []string{".go", ".yaml"}, Ignore: []string{"zz_*.go"}})
+
+	cases := map[string]bool{
+		"/src/main.go":                  true,
+		"/src/package/crd.yaml":         true,
+		"/src/README.md":                false,
+		"/src/.git/HEAD":                false,
+		"/src/vendor/lib/lib.go":        false,
+		"/src/internal/zz_generated.go": false,
+		"/src/.main.go.swp":             false,
+	}
+	for path, want := range cases {
+		if got := w.relevant(path); got != want {
+			t.Errorf("relevant(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestCycleSummary(t *testing.T) {
+	cycle := newCycle(3, []string{"a.go"})
+	_ = cycle.Step("build", func() error { return nil })
+	_ = cycle.Step("push", func() error { return nil })
+
+	summary := cycle.Summary()
+	if len(cycle.Steps()) != 2 {
+		t.Fatalf("expected 2 steps, got %d", len(cycle.Steps()))
+	}
+	for _, part := range []string{"Cycle #3", "(1 file(s) changed)", "build ", "push "} {
+		if !strings.Contains(summary, part) {
+			t.Errorf("summary %q does not contain %q", summary, part)
+		}
+	}
+}
+
+func TestLoopFollow(t *testing.T) {
+	w := New("/src", zap.NewNop().Sugar(), Options{Debounce: 10 * time.Millisecond})
+	ctx, stop := context.WithCancel(context.Background())
+	events := make(chan string)
+
+	followed := make(chan int, 2)
+	handler := func(ctx context.Context, cycle *Cycle) error {
+		cycle.Follow(func(ctx context.Context) error {
+			followed <- cycle.Number
+			<-ctx.Done()
+			return nil
+		})
+		return nil
+	}
+
+	result := make(chan error)
+	go func() { result <- w.loop(ctx, events, handler) }()
+
+	if n := <-followed; n != 1 {
+		t.Fatalf("expected first cycle to follow, got %d", n)
+	}
+	events <- "/src/main.go"
+	if n := <-followed; n != 2 {
+		t.Fatalf("expected second cycle to follow, got %d", n)
+	}
+	stop()
+	if err := <-result; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
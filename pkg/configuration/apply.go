@@ -13,6 +13,8 @@ import (
 	"k8s.io/client-go/rest"
 
 	"github.com/web-seven/overlock/internal/engine"
+	"github.com/web-seven/overlock/internal/packages"
+	"github.com/web-seven/overlock/pkg/registry"
 
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	crossv1 "github.com/crossplane/crossplane/apis/pkg/v1"
@@ -21,36 +23,18 @@ import (
 
 const apiName = "configurations.pkg.crossplane.io"
 
-// RunConfigurationHealthCheck performs a health check on configurations defined by the links string.
-func HealthCheck(ctx context.Context, dc dynamic.Interface, links string, wait bool, timeoutChan <-chan time.Time, logger *zap.SugaredLogger) error {
-	linkSet := make(map[string]struct{})
-	for _, link := range strings.Split(links, ",") {
-		linkSet[link] = struct{}{}
+// HealthCheck waits until configurations applied for links are healthy, zero
+// timeout waits without limit
+func HealthCheck(ctx context.Context, dc dynamic.Interface, config *rest.Config, links string, timeout time.Duration, logger *zap.SugaredLogger) error {
+	names, err := registry.PackageNames(ctx, config, strings.Split(links, ","))
+	if err != nil {
+		return err
 	}
-	cfgs := GetConfigurations(ctx, dc)
-
-	for {
-		select {
-		case <-timeoutChan:
-			logger.Error("Timeout reached.")
-			return nil
-		default:
-			allHealthy := true
-			for _, cfg := range cfgs {
-				if _, linkMatched := linkSet[cfg.Spec.Package]; linkMatched {
-					if !CheckHealthStatus(cfg.Status.Conditions) {
-						allHealthy = false
-						break
-					}
-				}
-			}
-			if allHealthy {
-				logger.Info("Configuration(s) are healthy.")
-				return nil
-			}
-			time.Sleep(5 * time.Second)
-		}
+	if err := packages.WaitHealthy(ctx, dc, packages.Configurations, names, timeout, logger); err != nil {
+		return err
 	}
+	logger.Info("Configuration(s) are healthy.")
+	return nil
 }
 
 func (c *Configuration) Apply(ctx context.Context, config *rest.Config, logger *zap.SugaredLogger) error {
@@ -62,13 +46,27 @@ func (c *Configuration) Apply(ctx context.Context, config *rest.Config, logger *
 		return nil
 	}
 
+	routes, err := registry.PackageRoutes(ctx, config)
+	if err != nil {
+		return err
+	}
+	verifier, err := registry.NewPackageVerifier(config, logger)
+	if err != nil {
+		return err
+	}
+
 	scheme := runtime.NewScheme()
 	crossv1.AddToScheme(scheme)
 	if kube, err := client.New(config, client.Options{Scheme: scheme}); err == nil {
 		for _, link := range strings.Split(c.Name, ",") {
 			cfg := &crossv1.Configuration{}
 			logger.Debugf("Building package %s", link)
-			engine.BuildPack(cfg, link, map[string]string{})
+			engine.BuildPack(cfg, link, map[string]string{}, routes...)
+			source, err := verifier.VerifyPackage(ctx, cfg.GetSource())
+			if err != nil {
+				return err
+			}
+			cfg.SetSource(source)
 			pa := resource.NewAPIPatchingApplicator(kube)
 
 			if err := pa.Apply(ctx, cfg); err != nil {
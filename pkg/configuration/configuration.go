@@ -29,6 +29,8 @@ const (
 type Configuration struct {
 	Name  string
 	Image image.Image
+	// Load directory without linting it
+	SkipLint bool
 	packages.Package
 }
 
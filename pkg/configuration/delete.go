@@ -4,20 +4,21 @@ import (
 	"context"
 	"strings"
 
-	crossv1 "github.com/crossplane/crossplane/apis/pkg/v1"
 	"go.uber.org/zap"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
 
-	"github.com/web-seven/overlock/internal/engine"
+	"github.com/web-seven/overlock/pkg/registry"
 )
 
-func DeleteConfiguration(ctx context.Context, urls string, dynamicClient *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
-	for _, url := range strings.Split(urls, ",") {
-		cfg := crossv1.Configuration{}
-		engine.BuildPack(&cfg, url, map[string]string{})
-
-		err := dynamicClient.Resource(ResourceId()).Namespace("").Delete(ctx, cfg.GetName(), metav1.DeleteOptions{})
+func DeleteConfiguration(ctx context.Context, config *rest.Config, urls string, dynamicClient *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
+	names, err := registry.PackageNames(ctx, config, strings.Split(urls, ","))
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		err := dynamicClient.Resource(ResourceId()).Namespace("").Delete(ctx, name, metav1.DeleteOptions{})
 		if err != nil {
 			return err
 		}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/web-seven/overlock/internal/image"
 	"github.com/web-seven/overlock/internal/kube"
+	"github.com/web-seven/overlock/internal/lint"
 	"github.com/web-seven/overlock/internal/packages"
 	"github.com/web-seven/overlock/pkg/registry"
 )
@@ -67,17 +68,35 @@ func (c *Configuration) LoadStdinArchive(ctx context.Context, config *rest.Confi
 
 // Load configuration package from directory
 func (c *Configuration) LoadDirectory(ctx context.Context, config *rest.Config, logger *zap.SugaredLogger, path string) error {
-	packageLayer, err := image.LoadPackageLayerDirectory(ctx, config, path, []string{"Configuration", "CompositeResourceDefinition", "Composition"})
-	if err != nil {
+	if err := c.lint(path, logger); err != nil {
+		return err
+	}
+	if err := c.buildDirectory(ctx, config, path); err != nil {
 		return err
 	}
+	return c.load(ctx, config, logger)
+}
 
-	c.Image.Image, err = mutate.AppendLayers(c.Image, packageLayer)
+// Build configuration image from directory
+func (c *Configuration) buildDirectory(ctx context.Context, config *rest.Config, path string) error {
+	packageLayer, err := image.LoadPackageLayerDirectory(ctx, config, path, lint.PackageKinds[lint.KindConfiguration])
 	if err != nil {
 		return err
 	}
 
-	return c.load(ctx, config, logger)
+	c.Image.Image, err = mutate.Append(c.Image, mutate.Addendum{
+		Layer:       packageLayer,
+		Annotations: map[string]string{image.AnnotationKey: image.AnnotationBase},
+	})
+	return err
+}
+
+// Lint configuration directory unless linting is skipped
+func (c *Configuration) lint(path string, logger *zap.SugaredLogger) error {
+	if c.SkipLint {
+		return nil
+	}
+	return lint.Check(path, lint.KindConfiguration, logger)
 }
 
 // Load configuration to registry
@@ -0,0 +1,258 @@
+package configuration
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	crossv1 "github.com/crossplane/crossplane/apis/pkg/v1"
+	crossv1beta1 "github.com/crossplane/crossplane/apis/pkg/v1beta1"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/web-seven/overlock/internal/engine"
+	"github.com/web-seven/overlock/internal/packages"
+	"github.com/web-seven/overlock/pkg/registry"
+)
+
+const (
+	LockFileName = "overlock.lock"
+	lockVersion  = 1
+)
+
+// Lock of configurations with their resolved transitive dependencies
+type Lock struct {
+	Version int `json:"version"`
+	// Configurations the lock was created for, as requested
+	Configurations []string `json:"configurations"`
+	// Resolved packages, dependencies precede packages depending on them
+	Packages []LockedPackage `json:"packages"`
+}
+
+// LockedPackage is a package pinned to digest
+type LockedPackage struct {
+	Package   string   `json:"package"`
+	Kind      string   `json:"kind"`
+	Version   string   `json:"version,omitempty"`
+	Digest    string   `json:"digest"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// Source of locked package pinned to digest
+func (p LockedPackage) Source() string {
+	return p.Package + "@" + p.Digest
+}
+
+// Sources of locked packages of kind
+func (l *Lock) Sources(kind string) []string {
+	sources := []string{}
+	for _, pkg := range l.Packages {
+		if pkg.Kind == kind {
+			sources = append(sources, pkg.Source())
+		}
+	}
+	return sources
+}
+
+// ResolveLock resolves dependency graph of configurations from package
+// metadata of their images. Packages are pulled from their registries, the
+// local registry included, package names without registry host refer to the
+// default remote registry as they do for Crossplane package manager.
+func ResolveLock(ctx context.Context, links []string, config *rest.Config, logger *zap.SugaredLogger) (*Lock, error) {
+	routes, err := registry.PackageRoutes(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	refs := []string{}
+	for _, link := range links {
+		refs = append(refs, engine.ExpandPackage(link, routes))
+	}
+
+	resolver := packages.NewResolver(registry.DefaultRemoteDomain, logger)
+	resolver.WithRegistry(registry.NewPackageRegistry(config, logger))
+	resolved, err := resolver.Resolve(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	locked := []LockedPackage{}
+	for _, pkg := range resolved {
+		lp := LockedPackage{
+			Package: pkg.Repository,
+			Kind:    pkg.Kind,
+			Version: pkg.Tag,
+			Digest:  pkg.Digest.String(),
+		}
+		for _, dep := range pkg.Dependencies {
+			repository, err := dependencyRepository(dep)
+			if err != nil {
+				return nil, err
+			}
+			lp.DependsOn = append(lp.DependsOn, repository)
+		}
+		locked = append(locked, lp)
+	}
+	return &Lock{Version: lockVersion, Configurations: links, Packages: dependencyOrder(locked)}, nil
+}
+
+// Packages in topological order, every package follows all packages it
+// depends on. Order of resolution is kept where dependencies allow it and
+// dependency cycles are broken at the package visited first.
+func dependencyOrder(locked []LockedPackage) []LockedPackage {
+	index := map[string]int{}
+	for i, pkg := range locked {
+		index[pkg.Package] = i
+	}
+	ordered := []LockedPackage{}
+	visited := map[string]bool{}
+	var visit func(pkg LockedPackage)
+	visit = func(pkg LockedPackage) {
+		if visited[pkg.Package] {
+			return
+		}
+		visited[pkg.Package] = true
+		for _, dep := range pkg.DependsOn {
+			if i, ok := index[dep]; ok {
+				visit(locked[i])
+			}
+		}
+		ordered = append(ordered, pkg)
+	}
+	for _, pkg := range locked {
+		visit(pkg)
+	}
+	return ordered
+}
+
+// Fully qualified repository of dependency, names without registry host
+// refer to the default remote registry
+func dependencyRepository(dep packages.Dependency) (string, error) {
+	repo, _ := packages.SplitVersion(dep.Package)
+	repository, err := name.NewRepository(repo, name.WithDefaultRegistry(registry.DefaultRemoteDomain))
+	if err != nil {
+		return "", err
+	}
+	return repository.Name(), nil
+}
+
+// ReadLock reads lock file
+func ReadLock(path string) (*Lock, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	lock := &Lock{}
+	if err := yaml.Unmarshal(content, lock); err != nil {
+		return nil, fmt.Errorf("cannot parse lock file %s: %w", path, err)
+	}
+	if lock.Version != lockVersion {
+		return nil, fmt.Errorf("unsupported lock file version %d", lock.Version)
+	}
+	return lock, nil
+}
+
+// Write lock file
+func (l *Lock) Write(path string) error {
+	content, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o644)
+}
+
+// ApplyLocked installs all packages of lock pinned to their digests with
+// dependency resolution of package manager disabled. Packages already
+// installed, e.g. as dependencies by package manager, are updated in place.
+func ApplyLocked(ctx context.Context, lock *Lock, config *rest.Config, logger *zap.SugaredLogger) error {
+	scheme := runtime.NewScheme()
+	crossv1.AddToScheme(scheme)
+	crossv1beta1.AddToScheme(scheme)
+	kube, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+	pa := resource.NewAPIPatchingApplicator(kube)
+
+	routes, err := registry.PackageRoutes(ctx, config)
+	if err != nil {
+		return err
+	}
+	verifier, err := registry.NewPackageVerifier(config, logger)
+	if err != nil {
+		return err
+	}
+	installed, err := installedPackages(ctx, kube)
+	if err != nil {
+		return err
+	}
+
+	skip := true
+	for _, pkg := range lock.Packages {
+		var pack crossv1.Package
+		switch pkg.Kind {
+		case "Configuration":
+			pack = &crossv1.Configuration{}
+		case "Provider":
+			pack = &crossv1.Provider{}
+		case "Function":
+			pack = &crossv1beta1.Function{}
+		default:
+			return fmt.Errorf("unsupported kind %s of locked package %s", pkg.Kind, pkg.Package)
+		}
+		if err := engine.BuildPack(pack, pkg.Source(), installed[pkg.Kind], routes...); err != nil {
+			return err
+		}
+		pack.SetSkipDependencyResolution(&skip)
+		source, err := verifier.VerifyPackage(ctx, pack.GetSource())
+		if err != nil {
+			return err
+		}
+		pack.SetSource(source)
+		logger.Debugf("Applying locked %s %s as %s", pkg.Kind, pack.GetSource(), pack.GetName())
+		if err := pa.Apply(ctx, pack); err != nil {
+			return errors.Wrapf(err, "error applying locked package %s", pkg.Package)
+		}
+	}
+	logger.Infof("%d locked package(s) applied successfully.", len(lock.Packages))
+	return nil
+}
+
+// Names of installed package objects by kind and package repository path,
+// as expected by engine.BuildPack
+func installedPackages(ctx context.Context, kube client.Client) (map[string]map[string]string, error) {
+	providers := &crossv1.ProviderList{}
+	if err := kube.List(ctx, providers); err != nil {
+		return nil, err
+	}
+	configurations := &crossv1.ConfigurationList{}
+	if err := kube.List(ctx, configurations); err != nil {
+		return nil, err
+	}
+	functions := &crossv1beta1.FunctionList{}
+	if err := kube.List(ctx, functions); err != nil {
+		return nil, err
+	}
+
+	installed := map[string]map[string]string{"Provider": {}, "Configuration": {}, "Function": {}}
+	add := func(kind string, pack crossv1.Package) {
+		if ref, err := name.ParseReference(pack.GetSource(), name.WithDefaultRegistry("")); err == nil {
+			installed[kind][ref.Context().RepositoryStr()] = pack.GetName()
+		}
+	}
+	for i := range providers.Items {
+		add("Provider", &providers.Items[i])
+	}
+	for i := range configurations.Items {
+		add("Configuration", &configurations.Items[i])
+	}
+	for i := range functions.Items {
+		add("Function", &functions.Items[i])
+	}
+	return installed, nil
+}
@@ -0,0 +1,122 @@
+package configuration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	crossv1 "github.com/crossplane/crossplane/apis/pkg/v1"
+	crossv1beta1 "github.com/crossplane/crossplane/apis/pkg/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/web-seven/overlock/internal/engine"
+	"github.com/web-seven/overlock/internal/packages"
+)
+
+func TestLockRoundTrip(t *testing.T) {
+	lock := &Lock{
+		Version:        lockVersion,
+		Configurations: []string{"acme/platform:v1.0.0"},
+		Packages: []LockedPackage{
+			{Package: "xpkg.upbound.io/crossplane-contrib/provider-nop", Kind: "Provider", Version: "v0.2.1", Digest: "sha256:aaa"},
+			{Package: "xpkg.upbound.io/acme/platform", Kind: "Configuration", Version: "v1.0.0", Digest: "sha256:bbb", DependsOn: []string{"xpkg.upbound.io/crossplane-contrib/provider-nop"}},
+		},
+	}
+	path := filepath.Join(t.TempDir(), LockFileName)
+	if err := lock.Write(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(lock, got) {
+		t.Errorf("expected %+v, got %+v", lock, got)
+	}
+	if sources := got.Sources("Configuration"); len(sources) != 1 || sources[0] != "xpkg.upbound.io/acme/platform@sha256:bbb" {
+		t.Errorf("unexpected configuration sources %v", sources)
+	}
+
+	if err := os.WriteFile(path, []byte("version: 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadLock(path); err == nil {
+		t.Error("expected error for unsupported version")
+	}
+}
+
+func TestDependencyRepository(t *testing.T) {
+	cases := map[string]string{
+		"xpkg.upbound.io/crossplane-contrib/provider-nop":            "xpkg.upbound.io/crossplane-contrib/provider-nop",
+		"crossplane-contrib/provider-nop":                            "xpkg.upbound.io/crossplane-contrib/provider-nop",
+		"crossplane-contrib/provider-helm:v0.19.0":                   "xpkg.upbound.io/crossplane-contrib/provider-helm",
+		"registry.overlock.svc.cluster.local/acme/platform@sha256:a": "registry.overlock.svc.cluster.local/acme/platform",
+	}
+	for dep, want := range cases {
+		got, err := dependencyRepository(packages.Dependency{Package: dep})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%s: expected %s, got %s", dep, want, got)
+		}
+	}
+}
+
+func TestInstalledPackages(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = crossv1.AddToScheme(scheme)
+	_ = crossv1beta1.AddToScheme(scheme)
+	provider := &crossv1.Provider{ObjectMeta: metav1.ObjectMeta{Name: "crossplane-contrib-provider-nop"}}
+	provider.SetSource("xpkg.upbound.io/crossplane-contrib/provider-nop:v0.2.1")
+	kube := fake.NewClientBuilder().WithScheme(scheme).WithObjects(provider).Build()
+
+	installed, err := installedPackages(context.Background(), kube)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := installed["Provider"]["crossplane-contrib/provider-nop"]; name != "crossplane-contrib-provider-nop" {
+		t.Errorf("unexpected installed providers %v", installed["Provider"])
+	}
+
+	// Locked package updates installed dependency instead of creating another one
+	pack := &crossv1.Provider{}
+	if err := engine.BuildPack(pack, "xpkg.upbound.io/crossplane-contrib/provider-nop@sha256:"+strings.Repeat("a", 64), installed["Provider"]); err != nil {
+		t.Fatal(err)
+	}
+	if pack.GetName() != provider.GetName() {
+		t.Errorf("expected installed provider name, got %s", pack.GetName())
+	}
+}
+
+func TestDependencyOrder(t *testing.T) {
+	// Diamond resolved breadth first: platform depends on network and
+	// database, both depend on provider, database also on network
+	locked := []LockedPackage{
+		{Package: "platform", DependsOn: []string{"network", "database"}},
+		{Package: "network", DependsOn: []string{"provider"}},
+		{Package: "database", DependsOn: []string{"provider", "network"}},
+		{Package: "provider"},
+	}
+	got := []string{}
+	for _, pkg := range dependencyOrder(locked) {
+		got = append(got, pkg.Package)
+	}
+	want := []string{"provider", "network", "database", "platform"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	cycle := []LockedPackage{
+		{Package: "a", DependsOn: []string{"b"}},
+		{Package: "b", DependsOn: []string{"a"}},
+	}
+	if ordered := dependencyOrder(cycle); len(ordered) != 2 || ordered[0].Package != "b" {
+		t.Errorf("unexpected order of cycle %v", ordered)
+	}
+}
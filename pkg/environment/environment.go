@@ -240,8 +240,9 @@ func (e *Environment) GetContextName() string {
 	return context
 }
 
-// Copy Environment from source to destination contexts
-func (e *Environment) CopyEnvironment(ctx context.Context, logger *zap.SugaredLogger, source string, destination string) error {
+// Copy Environment from source to destination contexts, claims are copied
+// from claimNamespace or from all namespaces if it is empty
+func (e *Environment) CopyEnvironment(ctx context.Context, logger *zap.SugaredLogger, source string, destination string, claimNamespace string) error {
 	// Create a REST clients
 	sourceConfig, err := kube.Config(source)
 	if err != nil {
@@ -291,7 +292,7 @@ func (e *Environment) CopyEnvironment(ctx context.Context, logger *zap.SugaredLo
 	logger.Info("Engine copied successfully!")
 
 	// Copy composite
-	err = resources.CopyComposites(ctx, logger, sourceContext, destinationContext)
+	err = resources.CopyComposites(ctx, logger, sourceContext, destinationContext, claimNamespace)
 	if err != nil {
 		return err
 	}
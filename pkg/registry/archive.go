@@ -0,0 +1,73 @@
+package registry
+
+import (
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+)
+
+// OCI media types
+const (
+	HelmConfigMediaType  = "application/vnd.cncf.helm.config.v1+json"
+	HelmContentMediaType = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+	OCIManifestSchema1   = "application/vnd.oci.image.manifest.v1+json"
+	OCIConfigMediaType   = "application/vnd.oci.image.config.v1+json"
+	OCILayerMediaType    = "application/vnd.oci.image.layer.v1.tar+gzip"
+)
+
+// ArchiveImage creates an OCI image from an archive with empty base layer
+// If helm is true, applies Helm-specific media types
+func ArchiveImage(archivePath string, helm bool) (regv1.Image, error) {
+	// Start with empty OCI base image and append the archive as a layer
+	img, err := crane.Append(empty.Image, archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to append layer to empty image: %w", err)
+	}
+
+	// Get the layer we just added
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image layers: %w", err)
+	}
+
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("no layers found in image")
+	}
+
+	if helm {
+		// Rebuild image with Helm-specific media types
+		baseImg := mutate.ConfigMediaType(empty.Image, HelmConfigMediaType)
+
+		// Add the layer with Helm content media type
+		img, err = mutate.Append(baseImg, mutate.Addendum{
+			Layer:     layers[len(layers)-1],
+			MediaType: HelmContentMediaType,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to append layer with Helm media type: %w", err)
+		}
+
+		// Set the manifest media type to OCI
+		img = mutate.MediaType(img, OCIManifestSchema1)
+	} else {
+		// Use standard OCI media types
+		baseImg := mutate.ConfigMediaType(empty.Image, OCIConfigMediaType)
+
+		// Add the layer with standard OCI layer media type
+		img, err = mutate.Append(baseImg, mutate.Addendum{
+			Layer:     layers[len(layers)-1],
+			MediaType: OCILayerMediaType,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to append layer: %w", err)
+		}
+
+		// Set the manifest media type to OCI
+		img = mutate.MediaType(img, OCIManifestSchema1)
+	}
+
+	return img, nil
+}
@@ -0,0 +1,312 @@
+package registry
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"go.uber.org/zap"
+	"k8s.io/client-go/rest"
+
+	"github.com/web-seven/overlock/internal/image"
+	"github.com/web-seven/overlock/internal/loader"
+	"github.com/web-seven/overlock/internal/packages"
+)
+
+// Annotation of OCI layout index entry holding original reference of image
+const BundleRefAnnotation = "org.opencontainers.image.ref.name"
+
+// ExportBundle resolves dependency trees of packages, pulls packages together
+// with their runtime images and writes them to OCI layout tarball. Archives are
+// given as path=reference, package archives have their dependencies resolved too.
+func ExportBundle(ctx context.Context, path string, refs []string, archives []string, logger *zap.SugaredLogger) error {
+	opts := []remote.Option{remote.WithAuthFromKeychain(authn.DefaultKeychain)}
+	resolver := packages.NewResolver(DefaultRemoteDomain, logger, opts...)
+	artifacts := map[string]regv1.Image{}
+	for _, archive := range archives {
+		archivePath, ref, found := strings.Cut(archive, "=")
+		if !found || ref == "" {
+			return fmt.Errorf("archive %q must be given as path=reference", archive)
+		}
+		img, err := loader.LoadPathArchive(archivePath)
+		if err != nil {
+			// Archives which are not image tarballs are bundled as OCI artifacts
+			logger.Debug(err)
+			if img, err = ArchiveImage(archivePath, false); err != nil {
+				return err
+			}
+		}
+		isPackage, err := image.IsPackage(img)
+		if err != nil {
+			return err
+		}
+		if !isPackage {
+			tag, err := name.NewTag(ref, name.WithDefaultRegistry(DefaultRemoteDomain))
+			if err != nil {
+				return err
+			}
+			artifacts[tag.Name()] = img
+			continue
+		}
+		if err := resolver.WithImage(ref, img); err != nil {
+			return err
+		}
+		refs = append(refs, ref)
+	}
+	resolved, err := resolver.Resolve(ctx, refs)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "overlock-bundle-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	lp, err := layout.Write(dir, empty.Index)
+	if err != nil {
+		return err
+	}
+
+	runtimeImages := []string{}
+	for _, pkg := range resolved {
+		logger.Infof("Adding %s %s to bundle", pkg.Kind, pkg.Source())
+		if err := lp.AppendImage(pkg.Image, layout.WithAnnotations(map[string]string{BundleRefAnnotation: pkg.Source()})); err != nil {
+			return err
+		}
+		runtimeImages = append(runtimeImages, pkg.RuntimeImages...)
+	}
+	for ref, img := range artifacts {
+		logger.Infof("Adding archive %s to bundle", ref)
+		if err := lp.AppendImage(img, layout.WithAnnotations(map[string]string{BundleRefAnnotation: ref})); err != nil {
+			return err
+		}
+	}
+
+	for _, img := range runtimeImages {
+		ref, err := name.ParseReference(img)
+		if err != nil {
+			return err
+		}
+		logger.Infof("Adding runtime image %s to bundle", ref.Name())
+		desc, err := remote.Get(ref, append(opts, remote.WithContext(ctx))...)
+		if err != nil {
+			return fmt.Errorf("cannot pull runtime image %s: %w", ref.Name(), err)
+		}
+		annotations := layout.WithAnnotations(map[string]string{BundleRefAnnotation: ref.Name()})
+		// Image indexes are kept whole, so bundle runs on every platform of image
+		if desc.MediaType.IsIndex() {
+			index, err := desc.ImageIndex()
+			if err != nil {
+				return err
+			}
+			err = lp.AppendIndex(index, annotations)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		runtimeImage, err := desc.Image()
+		if err != nil {
+			return err
+		}
+		if err := lp.AppendImage(runtimeImage, annotations); err != nil {
+			return err
+		}
+	}
+
+	if err := archiveDirectory(dir, path); err != nil {
+		return err
+	}
+	logger.Infof("Bundle with %d image(s) written to %s", len(resolved)+len(artifacts)+len(runtimeImages), path)
+	return nil
+}
+
+// ImportBundle pushes all images of OCI layout tarball to the local registry.
+// Images are pushed under their original repository path without registry host,
+// dependencies and controller images of packages which are part of bundle are
+// rewritten to the local registry, so bundle is installed without internet access.
+func ImportBundle(ctx context.Context, path string, config *rest.Config, logger *zap.SugaredLogger) error {
+	dir, err := os.MkdirTemp("", "overlock-bundle-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := loader.ExtractArchive(path, dir); err != nil {
+		return err
+	}
+
+	index, err := layout.ImageIndexFromPath(dir)
+	if err != nil {
+		return fmt.Errorf("%s is not an OCI layout bundle: %w", path, err)
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return err
+	}
+
+	bundled := map[string]bool{}
+	for _, desc := range manifest.Manifests {
+		if ref, err := name.ParseReference(desc.Annotations[BundleRefAnnotation]); err == nil {
+			bundled[ref.Context().Name()] = true
+		}
+	}
+	local := NewLocal()
+	// Digests of packages changed by rewrite, to follow dependencies pinned by digest
+	digests := map[string]string{}
+	rewrite := func(pkg string) (string, bool) {
+		repo, suffix := pkg, ""
+		if i := strings.Index(pkg, "@"); i >= 0 {
+			repo, suffix = pkg[:i], pkg[i:]
+			if digest, ok := digests[suffix[1:]]; ok {
+				suffix = "@" + digest
+			}
+		} else if i := strings.LastIndex(pkg, ":"); i > strings.LastIndex(pkg, "/") {
+			repo, suffix = pkg[:i], pkg[i:]
+		}
+		repository, err := name.NewRepository(repo, name.WithDefaultRegistry(DefaultRemoteDomain))
+		if err != nil || !bundled[repository.Name()] {
+			return "", false
+		}
+		return local.LocalDomain() + "/" + repository.RepositoryStr() + suffix, true
+	}
+
+	return WithLocalRegistry(ctx, config, logger, func(host string, opts ...remote.Option) error {
+		// Packages are bundled dependents first, so dependencies are pushed before packages requiring them
+		for i := len(manifest.Manifests) - 1; i >= 0; i-- {
+			desc := manifest.Manifests[i]
+			source, ok := desc.Annotations[BundleRefAnnotation]
+			if !ok {
+				logger.Warnf("Skip image %s without reference annotation", desc.Digest)
+				continue
+			}
+			ref, err := name.ParseReference(source)
+			if err != nil {
+				return err
+			}
+			target := host + "/" + ref.Context().RepositoryStr()
+
+			if desc.MediaType.IsIndex() {
+				imageIndex, err := index.ImageIndex(desc.Digest)
+				if err != nil {
+					return err
+				}
+				targetRef, err := bundleTarget(target, ref, desc.Digest)
+				if err != nil {
+					return err
+				}
+				logger.Infof("Pushing %s to local registry", source)
+				if err := remote.WriteIndex(targetRef, imageIndex, opts...); err != nil {
+					return err
+				}
+				continue
+			}
+
+			img, err := index.Image(desc.Digest)
+			if err != nil {
+				return err
+			}
+			img, err = rewritePackage(img, rewrite)
+			if err != nil {
+				return fmt.Errorf("cannot rewrite dependencies of %s: %w", source, err)
+			}
+			digest, err := img.Digest()
+			if err != nil {
+				return err
+			}
+			if digest != desc.Digest {
+				logger.Debugf("Dependencies of %s rewritten to local registry", source)
+				digests[desc.Digest.String()] = digest.String()
+			}
+			targetRef, err := bundleTarget(target, ref, digest)
+			if err != nil {
+				return err
+			}
+			logger.Infof("Pushing %s to local registry", source)
+			if err := remote.Write(targetRef, img, opts...); err != nil {
+				return err
+			}
+		}
+		logger.Info("Bundle imported successfully.")
+		return nil
+	})
+}
+
+// Package image with bundled dependencies referenced from the local registry,
+// images which are not packages are returned as is
+func rewritePackage(img regv1.Image, rewrite func(pkg string) (string, bool)) (regv1.Image, error) {
+	isPackage, err := image.IsPackage(img)
+	if err != nil || !isPackage {
+		return img, err
+	}
+	objects, err := image.PackageObjects(img)
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 || !packages.RewriteDependencies(&objects[0], rewrite) {
+		return img, nil
+	}
+	return image.WithPackageObjects(img, objects)
+}
+
+// Reference of image in local registry, tagged as source or pinned to digest
+func bundleTarget(target string, source name.Reference, digest regv1.Hash) (name.Reference, error) {
+	if _, ok := source.(name.Tag); ok {
+		return name.ParseReference(target + ":" + source.Identifier())
+	}
+	return name.ParseReference(target + "@" + digest.String())
+}
+
+// Write content of directory to tar archive
+func archiveDirectory(dir string, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	tw := tar.NewWriter(file)
+	err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || p == dir {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
@@ -0,0 +1,172 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	b64 "encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	CredentialCommandAnnotation = "overlock-registry-credential-command"
+	CredentialTTLAnnotation     = "overlock-registry-credential-ttl"
+	CredentialExpiryAnnotation  = "overlock-registry-credential-expiry"
+)
+
+// Set command which prints registry password or token to STDOUT
+// and lifetime of credentials issued by it
+func (r *Registry) WithCredentialSource(command string, ttl time.Duration) {
+	if r.Secret.Annotations == nil {
+		r.Secret.Annotations = map[string]string{}
+	}
+	if command != "" {
+		r.Secret.Annotations[CredentialCommandAnnotation] = command
+	}
+	if ttl > 0 {
+		r.Secret.Annotations[CredentialTTLAnnotation] = ttl.String()
+		r.Secret.Annotations[CredentialExpiryAnnotation] = time.Now().Add(ttl).UTC().Format(time.RFC3339)
+	}
+}
+
+// Command which issues credentials of registry
+func (r *Registry) CredentialCommand() string {
+	return r.Secret.Annotations[CredentialCommandAnnotation]
+}
+
+// Expiration time of registry credentials, false if credentials do not expire
+func (r *Registry) Expiry() (time.Time, bool) {
+	expiry, ok := r.Secret.Annotations[CredentialExpiryAnnotation]
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, expiry)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// Run credential source command and return trimmed STDOUT as password
+func RunCredentialCommand(ctx context.Context, command string) (string, error) {
+	stdout := new(bytes.Buffer)
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrapf(err, "credential command %q failed", command)
+	}
+	password := strings.TrimSpace(stdout.String())
+	if password == "" {
+		return "", fmt.Errorf("credential command %q returned empty output", command)
+	}
+	return password, nil
+}
+
+// Refresh re-runs credential source command of registry and patches its
+// pull secret and its copies referenced in other namespaces
+func (r *Registry) Refresh(ctx context.Context, client *kubernetes.Clientset, logger *zap.SugaredLogger) error {
+	command := r.CredentialCommand()
+	if command == "" {
+		return fmt.Errorf("registry %s has no credential source command", r.GetName())
+	}
+
+	logger.Debugf("Running credential command of registry %s", r.GetName())
+	password, err := RunCredentialCommand(ctx, command)
+	if err != nil {
+		return err
+	}
+
+	var conf RegistryConfig
+	if err := json.Unmarshal(r.Secret.Data[".dockerconfigjson"], &conf); err != nil {
+		return errors.Wrap(err, "failed to decode existing registry config")
+	}
+	for server, auth := range conf.Auths {
+		auth.Password = password
+		auth.Auth = b64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + password))
+		conf.Auths[server] = auth
+	}
+	regConf, err := json.Marshal(conf)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode updated registry config")
+	}
+
+	r.Secret.Data[".dockerconfigjson"] = regConf
+	r.Secret.Data["password"] = []byte(password)
+	if ttl, err := time.ParseDuration(r.Secret.Annotations[CredentialTTLAnnotation]); err == nil {
+		r.WithCredentialSource("", ttl)
+	}
+
+	if _, err := secretClient(client).Update(ctx, &r.Secret, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	logger.Debugf("Registry %s secret updated.", r.GetName())
+
+	return r.syncCopies(ctx, client, logger)
+}
+
+// Patch copies of registry secret in other namespaces, which are referenced
+// as image pull secret by service accounts or pods of these namespaces
+func (r *Registry) syncCopies(ctx context.Context, client *kubernetes.Clientset, logger *zap.SugaredLogger) error {
+	namespaces := map[string]bool{}
+	accounts, err := client.CoreV1().ServiceAccounts(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, account := range accounts.Items {
+		if referencesSecret(account.ImagePullSecrets, r.GetName()) {
+			namespaces[account.GetNamespace()] = true
+		}
+	}
+	pods, err := client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods.Items {
+		if referencesSecret(pod.Spec.ImagePullSecrets, r.GetName()) {
+			namespaces[pod.GetNamespace()] = true
+		}
+	}
+	delete(namespaces, r.GetNamespace())
+
+	for ns := range namespaces {
+		secret, err := client.CoreV1().Secrets(ns).Get(ctx, r.GetName(), metav1.GetOptions{})
+		if kerrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		for k, v := range r.Secret.Data {
+			secret.Data[k] = v
+		}
+		if _, err := client.CoreV1().Secrets(ns).Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		logger.Debugf("Registry secret copy %s/%s updated.", ns, secret.GetName())
+	}
+	return nil
+}
+
+func referencesSecret(refs []corev1.LocalObjectReference, name string) bool {
+	for _, ref := range refs {
+		if ref.Name == name {
+			return true
+		}
+	}
+	return false
+}
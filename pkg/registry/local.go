@@ -382,77 +382,31 @@ func IsLocalRegistry(ctx context.Context, client *kubernetes.Clientset) (bool, e
 }
 
 func PushLocalRegistry(ctx context.Context, imageName string, image regv1.Image, config *rest.Config, logger *zap.SugaredLogger) error {
-	client, err := kube.Client(config)
-	if err != nil {
-		return err
-	}
-
-	pods := client.CoreV1().Pods(namespace.Namespace)
-	regs, err := pods.List(ctx, v1.ListOptions{Limit: 1, LabelSelector: "app=" + deployName})
-	if err != nil {
-		return err
-	}
-
-	roundTripper, upgrader, err := spdy.RoundTripperFor(config)
-	if err != nil {
-		return err
-	}
-
-	lPort, err := getFreePort()
-	if err != nil {
-		return err
-	}
-
-	logger.Debugf("Found local registry with name: %s", regs.Items[0].GetName())
-
-	path := fmt.Sprintf("/api/v1/namespaces/%s/pods/%s/portforward", namespace.Namespace, regs.Items[0].GetName())
-	hostIP := strings.TrimLeft(config.Host, "htps:/")
-	serverURL := url.URL{Scheme: "https", Path: path, Host: hostIP}
-
-	logger.Debugf("Dialer server URL: %s", serverURL.String())
-
-	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: roundTripper}, http.MethodPost, &serverURL)
-	stopChan, readyChan := make(chan struct{}, 1), make(chan struct{}, 1)
-	out, errOut := new(bytes.Buffer), new(bytes.Buffer)
-	forwarder, err := portforward.New(dialer, []string{fmt.Sprint(lPort) + ":" + fmt.Sprint(deployPort)}, stopChan, readyChan, out, errOut)
-	if err != nil {
-		return err
-	}
-
-	go func() {
-		for range readyChan {
-		}
-		if len(errOut.String()) != 0 {
-			close(stopChan)
-		} else if len(out.String()) != 0 {
-			logger.Debug(out.String())
-		}
-		refName := "localhost:" + fmt.Sprint(lPort) + "/" + imageName
+	return WithLocalRegistry(ctx, config, logger, func(host string, opts ...remote.Option) error {
+		refName := host + "/" + imageName
 		logger.Debugf("Try to push to reference: %s", refName)
 		ref, err := name.ParseReference(refName)
 		if err != nil {
-			logger.Error(err)
-			close(stopChan)
-			return
-		}
-		// Use insecure transport for self-signed certificate
-		transport := &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			return err
 		}
-		err = remote.Write(ref, image, remote.WithTransport(transport))
-		if err != nil {
-			logger.Error(err)
-			close(stopChan)
-			return
+		if err := remote.Write(ref, image, opts...); err != nil {
+			return err
 		}
 		logger.Debug("Pushed to remote registry.")
-		close(stopChan)
-	}()
+		return nil
+	})
+}
 
-	if err = forwarder.ForwardPorts(); err != nil {
-		return err
-	}
-	return nil
+// Push multi-arch image index to local registry
+func PushLocalRegistryIndex(ctx context.Context, imageName string, index regv1.ImageIndex, config *rest.Config, logger *zap.SugaredLogger) error {
+	return WithLocalRegistry(ctx, config, logger, func(host string, opts ...remote.Option) error {
+		ref, err := name.ParseReference(host + "/" + imageName)
+		if err != nil {
+			return err
+		}
+		logger.Debugf("Try to push index to reference: %s", ref.String())
+		return remote.WriteIndex(ref, index, opts...)
+	})
 }
 
 func getFreePort() (port int, err error) {
@@ -469,29 +423,50 @@ func getFreePort() (port int, err error) {
 
 // ListLocalRegistryTags lists all tags for an image in the local registry
 func ListLocalRegistryTags(ctx context.Context, imageName string, config *rest.Config, logger *zap.SugaredLogger) ([]string, error) {
+	var tags []string
+	err := WithLocalRegistry(ctx, config, logger, func(host string, opts ...remote.Option) error {
+		repoName := host + "/" + imageName
+		logger.Debugf("Listing tags for repository: %s", repoName)
+		repo, err := name.NewRepository(repoName)
+		if err != nil {
+			return err
+		}
+		tags, err = remote.List(repo, opts...)
+		if err != nil {
+			logger.Debugf("Failed to list tags: %v", err)
+		}
+		return err
+	})
+	return tags, err
+}
+
+// WithLocalRegistry forwards port of the local registry and calls fn with
+// local address of registry and remote options required to access it.
+// Port forwarding is closed after fn returns.
+func WithLocalRegistry(ctx context.Context, config *rest.Config, logger *zap.SugaredLogger, fn func(host string, opts ...remote.Option) error) error {
 	client, err := kube.Client(config)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	pods := client.CoreV1().Pods(namespace.Namespace)
 	regs, err := pods.List(ctx, v1.ListOptions{Limit: 1, LabelSelector: "app=" + deployName})
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	if len(regs.Items) == 0 {
-		return nil, fmt.Errorf("local registry not found")
+		return fmt.Errorf("local registry not found")
 	}
 
 	roundTripper, upgrader, err := spdy.RoundTripperFor(config)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	lPort, err := getFreePort()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	logger.Debugf("Found local registry with name: %s", regs.Items[0].GetName())
@@ -507,41 +482,29 @@ func ListLocalRegistryTags(ctx context.Context, imageName string, config *rest.C
 	out, errOut := new(bytes.Buffer), new(bytes.Buffer)
 	forwarder, err := portforward.New(dialer, []string{fmt.Sprint(lPort) + ":" + fmt.Sprint(deployPort)}, stopChan, readyChan, out, errOut)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	var tags []string
-	var listErr error
+	var fnErr error
 
 	go func() {
+		defer close(stopChan)
 		for range readyChan {
 		}
 		if len(errOut.String()) != 0 {
-			close(stopChan)
-			return
-		}
-		repoName := "localhost:" + fmt.Sprint(lPort) + "/" + imageName
-		logger.Debugf("Listing tags for repository: %s", repoName)
-		repo, err := name.NewRepository(repoName)
-		if err != nil {
-			listErr = err
-			close(stopChan)
+			fnErr = errors.New(errOut.String())
 			return
 		}
 		// Use insecure transport for self-signed certificate
 		transport := &http.Transport{
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 		}
-		tags, listErr = remote.List(repo, remote.WithTransport(transport))
-		if listErr != nil {
-			logger.Debugf("Failed to list tags: %v", listErr)
-		}
-		close(stopChan)
+		fnErr = fn("localhost:"+fmt.Sprint(lPort), remote.WithTransport(transport), remote.WithContext(ctx))
 	}()
 
 	if err = forwarder.ForwardPorts(); err != nil {
-		return nil, err
+		return err
 	}
 
-	return tags, listErr
+	return fnErr
 }
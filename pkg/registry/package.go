@@ -0,0 +1,203 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+
+	"github.com/web-seven/overlock/internal/engine"
+	"github.com/web-seven/overlock/internal/image"
+	"github.com/web-seven/overlock/internal/packages"
+)
+
+// PackageReference parses package source, names without registry host
+// refer to the default registry of Crossplane, as they do for its package
+// manager
+func PackageReference(ctx context.Context, source string, config *rest.Config) (name.Reference, error) {
+	ref, err := name.ParseReference(source, name.WithDefaultRegistry(""))
+	if err != nil {
+		return nil, err
+	}
+	if ref.Context().RegistryStr() != "" {
+		return ref, nil
+	}
+	return name.ParseReference(source, name.WithDefaultRegistry(EngineRegistry(config)))
+}
+
+// EngineRegistry returns default registry of Crossplane set by `--registry`
+// argument of engine release, the default remote registry otherwise
+func EngineRegistry(config *rest.Config) string {
+	installer, err := engine.GetEngine(config)
+	if err != nil {
+		return DefaultRemoteDomain
+	}
+	release, err := installer.GetRelease()
+	if err != nil || release.Config == nil {
+		return DefaultRemoteDomain
+	}
+	return registryArg(release.Config["args"])
+}
+
+// Registry domain of `--registry` argument in engine release args
+func registryArg(args any) string {
+	list, _ := args.([]interface{})
+	for _, arg := range list {
+		value, _ := arg.(string)
+		if domain, ok := strings.CutPrefix(value, "--registry="); ok && domain != "" {
+			return domain
+		}
+	}
+	return DefaultRemoteDomain
+}
+
+// WithPackageRepository calls fn with repository of package reference
+// reachable from this host, the local registry is port-forwarded
+func WithPackageRepository(ctx context.Context, ref name.Reference, config *rest.Config, logger *zap.SugaredLogger, fn func(repository name.Repository, opts ...remote.Option) error) error {
+	local := NewLocal()
+	if ref.Context().RegistryStr() != local.LocalDomain() {
+		return fn(ref.Context(), remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	}
+	return WithLocalRegistry(ctx, config, logger, func(host string, opts ...remote.Option) error {
+		repository, err := name.NewRepository(host + "/" + ref.Context().RepositoryStr())
+		if err != nil {
+			return err
+		}
+		return fn(repository, opts...)
+	})
+}
+
+// Package registry reading packages of cluster routes, the local registry
+// included
+type packageRegistry struct {
+	config *rest.Config
+	logger *zap.SugaredLogger
+}
+
+// NewPackageRegistry returns registry for package resolver, which reads
+// packages from their registries, the local registry included
+func NewPackageRegistry(config *rest.Config, logger *zap.SugaredLogger) packages.PackageRegistry {
+	return &packageRegistry{config: config, logger: logger}
+}
+
+func (r *packageRegistry) Tags(ctx context.Context, repository name.Repository) ([]string, error) {
+	return PackageTags(ctx, repository.Name(), r.config, r.logger)
+}
+
+// Package image is read within access to its registry, so it is not
+// returned
+func (r *packageRegistry) Package(ctx context.Context, ref name.Reference) (regv1.Image, *unstructured.Unstructured, regv1.Hash, error) {
+	var (
+		meta   *unstructured.Unstructured
+		digest regv1.Hash
+	)
+	err := WithPackageRepository(ctx, ref, r.config, r.logger, func(repository name.Repository, opts ...remote.Option) error {
+		img, err := remote.Image(repositoryReference(repository, ref), append(opts, remote.WithContext(ctx))...)
+		if err != nil {
+			return err
+		}
+		if digest, err = img.Digest(); err != nil {
+			return err
+		}
+		meta, err = image.PackageMeta(img)
+		return err
+	})
+	return nil, meta, digest, err
+}
+
+// PackageTags lists tags of package repository
+func PackageTags(ctx context.Context, source string, config *rest.Config, logger *zap.SugaredLogger) ([]string, error) {
+	ref, err := PackageReference(ctx, source, config)
+	if err != nil {
+		return nil, err
+	}
+	var tags []string
+	err = WithPackageRepository(ctx, ref, config, logger, func(repository name.Repository, opts ...remote.Option) error {
+		tags, err = remote.List(repository, append(opts, remote.WithContext(ctx))...)
+		return err
+	})
+	return tags, err
+}
+
+// PackageDigest resolves digest of package source in its registry
+func PackageDigest(ctx context.Context, source string, config *rest.Config, logger *zap.SugaredLogger) (regv1.Hash, error) {
+	ref, err := PackageReference(ctx, source, config)
+	if err != nil {
+		return regv1.Hash{}, err
+	}
+	var digest regv1.Hash
+	err = WithPackageRepository(ctx, ref, config, logger, func(repository name.Repository, opts ...remote.Option) error {
+		desc, err := remote.Head(repositoryReference(repository, ref), append(opts, remote.WithContext(ctx))...)
+		if err != nil {
+			return err
+		}
+		digest = desc.Digest
+		return nil
+	})
+	if err != nil {
+		return regv1.Hash{}, fmt.Errorf("cannot resolve %s: %w", ref.Name(), err)
+	}
+	return digest, nil
+}
+
+// PackageObjects pulls package source from its registry and returns objects
+// of its package.yaml with digest of package image. Objects are read while
+// the local registry is port-forwarded.
+func PackageObjects(ctx context.Context, source string, config *rest.Config, logger *zap.SugaredLogger) ([]unstructured.Unstructured, regv1.Hash, error) {
+	ref, err := PackageReference(ctx, source, config)
+	if err != nil {
+		return nil, regv1.Hash{}, err
+	}
+	var (
+		objects []unstructured.Unstructured
+		digest  regv1.Hash
+	)
+	err = WithPackageRepository(ctx, ref, config, logger, func(repository name.Repository, opts ...remote.Option) error {
+		img, err := remote.Image(repositoryReference(repository, ref), append(opts, remote.WithContext(ctx))...)
+		if err != nil {
+			return err
+		}
+		if digest, err = img.Digest(); err != nil {
+			return err
+		}
+		objects, err = image.PackageObjects(img)
+		return err
+	})
+	if err != nil {
+		return nil, regv1.Hash{}, fmt.Errorf("cannot pull %s: %w", ref.Name(), err)
+	}
+	return objects, digest, nil
+}
+
+// Tag or digest of reference in repository
+func repositoryReference(repository name.Repository, ref name.Reference) name.Reference {
+	if _, ok := ref.(name.Digest); ok {
+		return repository.Digest(ref.Identifier())
+	}
+	return repository.Tag(ref.Identifier())
+}
+
+// PackageNames returns names of package objects applied for links, package
+// routes are applied to links the same way as on apply
+func PackageNames(ctx context.Context, config *rest.Config, links []string) ([]string, error) {
+	routes, err := PackageRoutes(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, link := range links {
+		name, err := engine.PackageName(link, routes...)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
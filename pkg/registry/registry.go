@@ -196,6 +196,19 @@ func (r *Registry) Update(ctx context.Context, client *kubernetes.Clientset, use
 	existing.Secret.Data["username"] = []byte(auth.Username)
 	existing.Secret.Data["password"] = []byte(auth.Password)
 
+	// Credential source given with update replaces existing one and routes
+	// extend existing ones, annotations absent in r are kept so refresh keeps
+	// working
+	if existing.Secret.Annotations == nil {
+		existing.Secret.Annotations = map[string]string{}
+	}
+	for k, v := range r.Secret.Annotations {
+		if k != RegistryServerLabel && k != RoutesAnnotation {
+			existing.Secret.Annotations[k] = v
+		}
+	}
+	existing.AddRoutes(r.Routes()...)
+
 	if len(r.Labels) > 0 {
 		if existing.Secret.Labels == nil {
 			existing.Secret.Labels = make(map[string]string)
@@ -0,0 +1,99 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/web-seven/overlock/internal/engine"
+	"github.com/web-seven/overlock/internal/kube"
+)
+
+var RoutesAnnotation = "overlock-registry-routes"
+
+// Package routes stored in registry secret
+func (r *Registry) Routes() []engine.Route {
+	routes := []engine.Route{}
+	raw, ok := r.Secret.Annotations[RoutesAnnotation]
+	if !ok {
+		return routes
+	}
+	if err := json.Unmarshal([]byte(raw), &routes); err != nil {
+		return []engine.Route{}
+	}
+	return routes
+}
+
+// Add package routes to registry, route with the same pattern is replaced
+func (r *Registry) AddRoutes(routes ...engine.Route) {
+	existing := r.Routes()
+	for _, route := range routes {
+		replaced := false
+		for i := range existing {
+			if existing[i].Pattern == route.Pattern {
+				existing[i] = route
+				replaced = true
+			}
+		}
+		if !replaced {
+			existing = append(existing, route)
+		}
+	}
+	r.setRoutes(existing)
+}
+
+// Remove package route by pattern, returns false if route not found
+func (r *Registry) RemoveRoute(pattern string) bool {
+	routes := []engine.Route{}
+	for _, route := range r.Routes() {
+		if route.Pattern != pattern {
+			routes = append(routes, route)
+		}
+	}
+	if len(routes) == len(r.Routes()) {
+		return false
+	}
+	r.setRoutes(routes)
+	return true
+}
+
+// Save package routes of existing registry
+func (r *Registry) SaveRoutes(ctx context.Context, client *kubernetes.Clientset) error {
+	_, err := secretClient(client).Update(ctx, &r.Secret, metav1.UpdateOptions{})
+	return err
+}
+
+func (r *Registry) setRoutes(routes []engine.Route) {
+	if r.Secret.Annotations == nil {
+		r.Secret.Annotations = map[string]string{}
+	}
+	if len(routes) == 0 {
+		delete(r.Secret.Annotations, RoutesAnnotation)
+		return
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Pattern < routes[j].Pattern })
+	raw, _ := json.Marshal(routes)
+	r.Secret.Annotations[RoutesAnnotation] = string(raw)
+}
+
+// Package routes of all registries in context of config
+func PackageRoutes(ctx context.Context, config *rest.Config) ([]engine.Route, error) {
+	client, err := kube.Client(config)
+	if err != nil {
+		return nil, err
+	}
+	registries, err := Registries(ctx, client)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list registries")
+	}
+	routes := []engine.Route{}
+	for _, reg := range registries {
+		routes = append(routes, reg.Routes()...)
+	}
+	return routes, nil
+}
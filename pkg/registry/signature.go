@@ -0,0 +1,101 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"go.uber.org/zap"
+	"k8s.io/client-go/rest"
+
+	"github.com/web-seven/overlock/internal/signature"
+)
+
+// SignLocalImage signs image pushed to the local registry with private key
+// and pushes cosign compatible signature next to it
+func SignLocalImage(ctx context.Context, imageName string, image regv1.Image, keyPath string, config *rest.Config, logger *zap.SugaredLogger) error {
+	key, err := signature.LoadPrivateKey(keyPath)
+	if err != nil {
+		return fmt.Errorf("cannot load signing key: %w", err)
+	}
+	digest, err := image.Digest()
+	if err != nil {
+		return err
+	}
+	ref, err := name.ParseReference(imageName, name.WithDefaultRegistry(""))
+	if err != nil {
+		return err
+	}
+
+	local := NewLocal()
+	identity := local.LocalDomain() + "/" + ref.Context().RepositoryStr()
+	return WithLocalRegistry(ctx, config, logger, func(host string, opts ...remote.Option) error {
+		repository, err := name.NewRepository(host + "/" + ref.Context().RepositoryStr())
+		if err != nil {
+			return err
+		}
+		logger.Debugf("Signing %s@%s", identity, digest)
+		return signature.Sign(ctx, repository, identity, digest, key, opts...)
+	})
+}
+
+// PackageVerifier checks signatures of packages against verification
+// policy, which is loaded once for all packages verified by it
+type PackageVerifier struct {
+	policy *signature.Policy
+	config *rest.Config
+	logger *zap.SugaredLogger
+}
+
+// NewPackageVerifier loads verification policy
+func NewPackageVerifier(config *rest.Config, logger *zap.SugaredLogger) (*PackageVerifier, error) {
+	policy, err := signature.LoadPolicy(signature.PolicyPath())
+	if err != nil {
+		return nil, err
+	}
+	return &PackageVerifier{policy: policy, config: config, logger: logger}, nil
+}
+
+// VerifyPackage checks signature of package against verification policy and
+// returns source pinned to verified digest, so moved tag cannot install
+// unverified image. Packages not covered by policy are returned as is.
+// Package names without registry host are verified in the default registry
+// of Crossplane.
+func (v *PackageVerifier) VerifyPackage(ctx context.Context, source string) (string, error) {
+	if len(v.policy.Rules) == 0 {
+		return source, nil
+	}
+
+	ref, err := PackageReference(ctx, source, v.config)
+	if err != nil {
+		return "", err
+	}
+
+	rule := v.policy.RuleFor(ref.Name())
+	if rule == nil {
+		v.logger.Debugf("Package %s is not covered by verification policy", ref.Name())
+		return source, nil
+	}
+	keys, err := rule.PublicKeys()
+	if err != nil {
+		return "", err
+	}
+
+	v.logger.Debugf("Verifying signature of %s", ref.Name())
+	var digest regv1.Hash
+	err = WithPackageRepository(ctx, ref, v.config, v.logger, func(repository name.Repository, opts ...remote.Option) error {
+		desc, err := remote.Head(repositoryReference(repository, ref), append(opts, remote.WithContext(ctx))...)
+		if err != nil {
+			return err
+		}
+		digest = desc.Digest
+		return signature.Verify(ctx, repository, digest, keys, opts...)
+	})
+	if err != nil {
+		return "", fmt.Errorf("signature verification of %s failed: %w", source, err)
+	}
+	v.logger.Infof("Signature of %s verified.", source)
+	return ref.Context().Name() + "@" + digest.String(), nil
+}